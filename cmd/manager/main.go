@@ -19,16 +19,23 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 
 	"github.com/Ridecell/ridecell-operator/pkg/apis"
 	"github.com/Ridecell/ridecell-operator/pkg/controller"
+	"github.com/Ridecell/ridecell-operator/pkg/webhook"
+	"k8s.io/apimachinery/pkg/types"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+	crwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
 func main() {
+	enableWebhooks := flag.Bool("enable-webhooks", false, "Run the admission webhook server")
+	webhookPort := flag.Int("webhook-port", 9876, "Port for the admission webhook server")
+	webhookCertDir := flag.String("webhook-cert-dir", "/tmp/cert", "Directory for the admission webhook server certificates")
 	flag.Parse()
 
 	// Get a config to talk to the apiserver
@@ -55,6 +62,36 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Setup all Webhooks
+	if *enableWebhooks {
+		namespace := os.Getenv("POD_NAMESPACE")
+		secretName := os.Getenv("SECRET_NAME")
+		if secretName == "" {
+			secretName = "ridecell-operator-webhook-server-secret"
+		}
+		serviceName := os.Getenv("SERVICE_NAME")
+		if serviceName == "" {
+			serviceName = "ridecell-operator-controller-manager-service"
+		}
+		err = webhook.AddToManager(mgr, crwebhook.ServerOptions{
+			Port:    int32(*webhookPort),
+			CertDir: *webhookCertDir,
+			BootstrapOptions: &crwebhook.BootstrapOptions{
+				MutatingWebhookConfigName:   "ridecell-operator-mutating-webhook-configuration",
+				ValidatingWebhookConfigName: "ridecell-operator-validating-webhook-configuration",
+				Secret:                      &types.NamespacedName{Namespace: namespace, Name: secretName},
+				Service: &crwebhook.Service{
+					Namespace: namespace,
+					Name:      serviceName,
+					Selectors: map[string]string{"control-plane": "controller-manager"},
+				},
+			},
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	log.Printf("Starting the Cmd.")
 
 	// Start the Cmd
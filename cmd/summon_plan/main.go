@@ -0,0 +1,164 @@
+/*
+Copyright 2019 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// summon_plan shows what the operator would create, update, or delete for a SummonPlatform without changing
+// anything in the cluster.
+//
+// Usage:
+//
+//	summon_plan -namespace summon-dev -name foo-dev
+//	summon_plan -f foo-dev.yml -o json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/Ridecell/ridecell-operator/pkg/apis"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/controller/summon"
+	"github.com/Ridecell/ridecell-operator/pkg/dryrun"
+)
+
+func main() {
+	namespace := flag.String("namespace", "", "namespace of an existing SummonPlatform")
+	name := flag.String("name", "", "name of an existing SummonPlatform")
+	file := flag.String("f", "", "YAML file with a proposed SummonPlatform, compared against the live one of the same name")
+	output := flag.String("o", "text", "output format, text or json")
+	flag.Parse()
+
+	err := apis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Get a config to talk to the apiserver
+	cfg, err := config.GetConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mapper, err := apiutil.NewDiscoveryRESTMapper(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c, err := client.New(cfg, client.Options{Scheme: scheme.Scheme, Mapper: mapper})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	instance, err := loadInstance(c, *file, *namespace, *name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	plan, err := dryrun.Run(c, scheme.Scheme, summon.Templates, instance, summon.Components())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(plan)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printPlan(plan)
+	}
+	if plan.Error != "" {
+		os.Exit(1)
+	}
+}
+
+// Find the SummonPlatform to plan. When given a file, the live status is copied over so the components pick up
+// where the operator currently is.
+func loadInstance(c client.Client, file, namespace, name string) (*summonv1beta1.SummonPlatform, error) {
+	live := &summonv1beta1.SummonPlatform{}
+	if file == "" {
+		if namespace == "" || name == "" {
+			return nil, fmt.Errorf("either -f or both -namespace and -name are required")
+		}
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, live)
+		return live, err
+	}
+
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	instance, ok := obj.(*summonv1beta1.SummonPlatform)
+	if !ok {
+		return nil, fmt.Errorf("%s does not contain a SummonPlatform", file)
+	}
+
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}, live)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		instance.UID = live.UID
+		instance.ResourceVersion = live.ResourceVersion
+		instance.Generation = live.Generation
+		instance.Status = live.Status
+	}
+	return instance, nil
+}
+
+func printPlan(plan *dryrun.Plan) {
+	if len(plan.Changes) == 0 {
+		fmt.Println("No changes.")
+	}
+	for _, change := range plan.Changes {
+		fmt.Printf("%s %s %s/%s\n", change.Action, change.Kind, change.Namespace, change.Name)
+		for _, field := range change.Fields {
+			switch {
+			case field.Old == nil:
+				fmt.Printf("  + %s: %v\n", field.Path, field.New)
+			case field.New == nil:
+				fmt.Printf("  - %s: %v\n", field.Path, field.Old)
+			default:
+				fmt.Printf("  ~ %s: %v -> %v\n", field.Path, field.Old, field.New)
+			}
+		}
+	}
+	if len(plan.Waiting) > 0 {
+		fmt.Printf("\nNot ready to reconcile yet, so not included: %s\n", strings.Join(plan.Waiting, ", "))
+	}
+	if plan.Error != "" {
+		fmt.Printf("\nStopped on error: %s\n", plan.Error)
+	}
+}
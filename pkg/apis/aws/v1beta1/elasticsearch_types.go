@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // ElasticSearchSpec defines the desired state of ElasticSearch
@@ -48,6 +50,10 @@ type ElasticSearchStatus struct {
 	Status         string `json:"status"`
 	Message        string `json:"message"`
 	DomainEndpoint string `json:"domainEndpoint"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
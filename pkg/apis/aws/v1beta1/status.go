@@ -33,6 +33,18 @@ func (sb *S3Bucket) SetErrorStatus(errorMsg string) {
 	sb.Status.Message = errorMsg
 }
 
+func (sb *S3Bucket) GetConditions() []components.Condition {
+	return sb.Status.Conditions
+}
+
+func (sb *S3Bucket) SetConditions(conditions []components.Condition) {
+	sb.Status.Conditions = conditions
+}
+
+func (sb *S3Bucket) GetStatusSummary() (string, string) {
+	return sb.Status.Status, sb.Status.Message
+}
+
 func (iu *IAMUser) GetStatus() components.Status {
 	return iu.Status
 }
@@ -46,6 +58,18 @@ func (iu *IAMUser) SetErrorStatus(errorMsg string) {
 	iu.Status.Message = errorMsg
 }
 
+func (iu *IAMUser) GetConditions() []components.Condition {
+	return iu.Status.Conditions
+}
+
+func (iu *IAMUser) SetConditions(conditions []components.Condition) {
+	iu.Status.Conditions = conditions
+}
+
+func (iu *IAMUser) GetStatusSummary() (string, string) {
+	return iu.Status.Status, iu.Status.Message
+}
+
 func (es *ElasticSearch) GetStatus() components.Status {
 	return es.Status
 }
@@ -58,3 +82,15 @@ func (es *ElasticSearch) SetErrorStatus(errorMsg string) {
 	es.Status.Status = StatusError
 	es.Status.Message = errorMsg
 }
+
+func (es *ElasticSearch) GetConditions() []components.Condition {
+	return es.Status.Conditions
+}
+
+func (es *ElasticSearch) SetConditions(conditions []components.Condition) {
+	es.Status.Conditions = conditions
+}
+
+func (es *ElasticSearch) GetStatusSummary() (string, string) {
+	return es.Status.Status, es.Status.Message
+}
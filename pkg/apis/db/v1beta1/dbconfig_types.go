@@ -20,6 +20,8 @@ import (
 	postgresv1 "github.com/zalando-incubator/postgres-operator/pkg/apis/acid.zalan.do/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // A copy of postgresv1.PostgresParam, see below for why. This time it's the
@@ -91,6 +93,10 @@ type DbConfigStatus struct {
 	Message       string                 `json:"message"`
 	Postgres      PostgresDbConfigStatus `json:"postgres"`
 	RDSInstanceID string                 `json:"rdsInstanceId,omitempty"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -19,6 +19,8 @@ package v1beta1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // PostgresDatabaseSpec defines the desired state of PostgresDatabase
@@ -41,6 +43,20 @@ type PostgresDatabaseSpec struct {
 	// Migration override settings.
 	// +optional
 	MigrationOverrides MigrationOverridesSpec `json:"migrationOverrides,omitempty"`
+	// Data to restore when the database is first created. Restored databases always get their own RDS instance,
+	// since a snapshot covers every database on the source instance.
+	// +optional
+	RestoreFrom *PostgresRestoreSpec `json:"restoreFrom,omitempty"`
+}
+
+// PostgresRestoreSpec defines an existing database to copy the data for a new PostgresDatabase from.
+type PostgresRestoreSpec struct {
+	RestoreSpec `json:",inline"`
+	// Name of the database on the source instance, which gets renamed to DatabaseName.
+	DatabaseName string `json:"databaseName"`
+	// Owner of the database on the source instance, whose objects get reassigned to Owner.
+	// +optional
+	Owner string `json:"owner,omitempty"`
 }
 
 // PostgresDatabaseStatus defines the observed state of PostgresDatabase
@@ -55,6 +71,10 @@ type PostgresDatabaseStatus struct {
 	AdminConnection       PostgresConnection `json:"adminConnection"`
 	SharedUsers           SharedUsersStatus  `json:"sharedUsers"`
 	RDSInstanceID         string             `json:"rdsInstanceId,omitempty"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
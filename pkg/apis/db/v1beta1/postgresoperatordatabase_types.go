@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 type PostgresDBRef struct {
@@ -34,6 +36,10 @@ type PostgresOperatorDatabaseSpec struct {
 type PostgresOperatorDatabaseStatus struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // RDSInstanceSpec defines the desired state of RDS
@@ -34,6 +36,9 @@ type RDSInstanceSpec struct {
 	Username          string            `json:"username,omitempty"`
 	SubnetGroupName   string            `json:"subnetGroupName,omitempty"`
 	VPCID             string            `json:"vpcID,omitempty"`
+	// Data to restore when the instance is first created. Ignored once it exists.
+	// +optional
+	RestoreFrom *RestoreSpec `json:"restoreFrom,omitempty"`
 }
 
 // RDSInstanceStatus defines the observed state of RDSInstance
@@ -43,6 +48,10 @@ type RDSInstanceStatus struct {
 	Connection      PostgresConnection `json:"rdsConnection"`
 	InstanceID      string             `json:"instanceID"`
 	SecurityGroupID string             `json:"securityGroupID"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
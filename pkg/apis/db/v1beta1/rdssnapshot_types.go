@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // RDSSnapshotSpec defines the desired state of RDSSnapshot
@@ -37,6 +39,10 @@ type RDSSnapshotStatus struct {
 	Status     string `json:"status"`
 	Message    string `json:"message"`
 	SnapshotID string `json:"snapshotId"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
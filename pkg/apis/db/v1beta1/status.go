@@ -33,6 +33,18 @@ func (pe *PostgresExtension) SetErrorStatus(errorMsg string) {
 	pe.Status.Message = errorMsg
 }
 
+func (pe *PostgresExtension) GetConditions() []components.Condition {
+	return pe.Status.Conditions
+}
+
+func (pe *PostgresExtension) SetConditions(conditions []components.Condition) {
+	pe.Status.Conditions = conditions
+}
+
+func (pe *PostgresExtension) GetStatusSummary() (string, string) {
+	return pe.Status.Status, pe.Status.Message
+}
+
 func (po *PostgresOperatorDatabase) GetStatus() components.Status {
 	return po.Status
 }
@@ -46,6 +58,18 @@ func (po *PostgresOperatorDatabase) SetErrorStatus(errorMsg string) {
 	po.Status.Message = errorMsg
 }
 
+func (po *PostgresOperatorDatabase) GetConditions() []components.Condition {
+	return po.Status.Conditions
+}
+
+func (po *PostgresOperatorDatabase) SetConditions(conditions []components.Condition) {
+	po.Status.Conditions = conditions
+}
+
+func (po *PostgresOperatorDatabase) GetStatusSummary() (string, string) {
+	return po.Status.Status, po.Status.Message
+}
+
 func (pe *RabbitmqVhost) GetStatus() components.Status {
 	return pe.Status
 }
@@ -59,6 +83,18 @@ func (pe *RabbitmqVhost) SetErrorStatus(errorMsg string) {
 	pe.Status.Message = errorMsg
 }
 
+func (pe *RabbitmqVhost) GetConditions() []components.Condition {
+	return pe.Status.Conditions
+}
+
+func (pe *RabbitmqVhost) SetConditions(conditions []components.Condition) {
+	pe.Status.Conditions = conditions
+}
+
+func (pe *RabbitmqVhost) GetStatusSummary() (string, string) {
+	return pe.Status.Status, pe.Status.Message
+}
+
 func (pe *RabbitmqUser) GetStatus() components.Status {
 	return pe.Status
 }
@@ -72,6 +108,18 @@ func (pe *RabbitmqUser) SetErrorStatus(errorMsg string) {
 	pe.Status.Message = errorMsg
 }
 
+func (pe *RabbitmqUser) GetConditions() []components.Condition {
+	return pe.Status.Conditions
+}
+
+func (pe *RabbitmqUser) SetConditions(conditions []components.Condition) {
+	pe.Status.Conditions = conditions
+}
+
+func (pe *RabbitmqUser) GetStatusSummary() (string, string) {
+	return pe.Status.Status, pe.Status.Message
+}
+
 func (rds *RDSInstance) GetStatus() components.Status {
 	return rds.Status
 }
@@ -85,6 +133,18 @@ func (rds *RDSInstance) SetErrorStatus(errorMsg string) {
 	rds.Status.Message = errorMsg
 }
 
+func (rds *RDSInstance) GetConditions() []components.Condition {
+	return rds.Status.Conditions
+}
+
+func (rds *RDSInstance) SetConditions(conditions []components.Condition) {
+	rds.Status.Conditions = conditions
+}
+
+func (rds *RDSInstance) GetStatusSummary() (string, string) {
+	return rds.Status.Status, rds.Status.Message
+}
+
 func (snap *RDSSnapshot) GetStatus() components.Status {
 	return snap.Status
 }
@@ -98,6 +158,18 @@ func (snap *RDSSnapshot) SetErrorStatus(errorMsg string) {
 	snap.Status.Message = errorMsg
 }
 
+func (snap *RDSSnapshot) GetConditions() []components.Condition {
+	return snap.Status.Conditions
+}
+
+func (snap *RDSSnapshot) SetConditions(conditions []components.Condition) {
+	snap.Status.Conditions = conditions
+}
+
+func (snap *RDSSnapshot) GetStatusSummary() (string, string) {
+	return snap.Status.Status, snap.Status.Message
+}
+
 func (pgu *PostgresUser) GetStatus() components.Status {
 	return pgu.Status
 }
@@ -111,6 +183,18 @@ func (pgu *PostgresUser) SetErrorStatus(errorMsg string) {
 	pgu.Status.Message = errorMsg
 }
 
+func (pgu *PostgresUser) GetConditions() []components.Condition {
+	return pgu.Status.Conditions
+}
+
+func (pgu *PostgresUser) SetConditions(conditions []components.Condition) {
+	pgu.Status.Conditions = conditions
+}
+
+func (pgu *PostgresUser) GetStatusSummary() (string, string) {
+	return pgu.Status.Status, pgu.Status.Message
+}
+
 func (pgu *PostgresDatabase) GetStatus() components.Status {
 	return pgu.Status
 }
@@ -124,6 +208,18 @@ func (pgu *PostgresDatabase) SetErrorStatus(errorMsg string) {
 	pgu.Status.Message = errorMsg
 }
 
+func (pgu *PostgresDatabase) GetConditions() []components.Condition {
+	return pgu.Status.Conditions
+}
+
+func (pgu *PostgresDatabase) SetConditions(conditions []components.Condition) {
+	pgu.Status.Conditions = conditions
+}
+
+func (pgu *PostgresDatabase) GetStatusSummary() (string, string) {
+	return pgu.Status.Status, pgu.Status.Message
+}
+
 func (pgu *DbConfig) GetStatus() components.Status {
 	return pgu.Status
 }
@@ -136,3 +232,15 @@ func (pgu *DbConfig) SetErrorStatus(errorMsg string) {
 	pgu.Status.Status = StatusError
 	pgu.Status.Message = errorMsg
 }
+
+func (pgu *DbConfig) GetConditions() []components.Condition {
+	return pgu.Status.Conditions
+}
+
+func (pgu *DbConfig) SetConditions(conditions []components.Condition) {
+	pgu.Status.Conditions = conditions
+}
+
+func (pgu *DbConfig) GetStatusSummary() (string, string) {
+	return pgu.Status.Status, pgu.Status.Message
+}
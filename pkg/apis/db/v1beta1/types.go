@@ -65,3 +65,16 @@ type MigrationOverridesSpec struct {
 	// +optional
 	RDSMasterUsername string `json:"rdsMasterUsername,omitempty"`
 }
+
+// RestoreSpec defines an existing RDS database to copy the data for a new one from.
+type RestoreSpec struct {
+	// ID of an RDS snapshot to restore.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// ID of an RDS instance to restore to a point in time, used when SnapshotID isn't set.
+	// +optional
+	SourceInstanceID string `json:"sourceInstanceID,omitempty"`
+	// Point in time to restore SourceInstanceID to, as RFC3339. Defaults to the latest restorable time.
+	// +optional
+	RestoreTime string `json:"restoreTime,omitempty"`
+}
@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // ServiceAccountSpec defines the desired state of ServiceAccount
@@ -32,6 +34,10 @@ type GCPServiceAccountStatus struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 	Email   string `json:"email"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
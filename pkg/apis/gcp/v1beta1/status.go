@@ -32,3 +32,15 @@ func (sa *GCPServiceAccount) SetErrorStatus(errorMsg string) {
 	sa.Status.Status = StatusError
 	sa.Status.Message = errorMsg
 }
+
+func (sa *GCPServiceAccount) GetConditions() []components.Condition {
+	return sa.Status.Conditions
+}
+
+func (sa *GCPServiceAccount) SetConditions(conditions []components.Condition) {
+	sa.Status.Conditions = conditions
+}
+
+func (sa *GCPServiceAccount) GetStatusSummary() (string, string) {
+	return sa.Status.Status, sa.Status.Message
+}
@@ -32,3 +32,15 @@ func (pe *RidecellIngress) SetErrorStatus(errorMsg string) {
 	pe.Status.Status = "Error"
 	pe.Status.Message = errorMsg
 }
+
+func (pe *RidecellIngress) GetConditions() []components.Condition {
+	return pe.Status.Conditions
+}
+
+func (pe *RidecellIngress) SetConditions(conditions []components.Condition) {
+	pe.Status.Conditions = conditions
+}
+
+func (pe *RidecellIngress) GetStatusSummary() (string, string) {
+	return pe.Status.Status, pe.Status.Message
+}
@@ -33,6 +33,18 @@ func (amc *AlertManagerConfig) SetErrorStatus(errorMsg string) {
 	amc.Status.Message = errorMsg
 }
 
+func (amc *AlertManagerConfig) GetConditions() []components.Condition {
+	return amc.Status.Conditions
+}
+
+func (amc *AlertManagerConfig) SetConditions(conditions []components.Condition) {
+	amc.Status.Conditions = conditions
+}
+
+func (amc *AlertManagerConfig) GetStatusSummary() (string, string) {
+	return amc.Status.Status, amc.Status.Message
+}
+
 func (mon *Monitor) GetStatus() components.Status {
 	return mon.Status
 }
@@ -45,3 +57,15 @@ func (mon *Monitor) SetErrorStatus(errorMsg string) {
 	mon.Status.Status = StatusError
 	mon.Status.Message = errorMsg
 }
+
+func (mon *Monitor) GetConditions() []components.Condition {
+	return mon.Status.Conditions
+}
+
+func (mon *Monitor) SetConditions(conditions []components.Condition) {
+	mon.Status.Conditions = conditions
+}
+
+func (mon *Monitor) GetStatusSummary() (string, string) {
+	return mon.Status.Status, mon.Status.Message
+}
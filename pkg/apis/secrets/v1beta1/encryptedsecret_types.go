@@ -18,6 +18,8 @@ package v1beta1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // KMS doesn't allow encrypting an empty string so use a magic constant to represent it.
@@ -27,6 +29,10 @@ const EncryptedSecretEmptyKey = "___empty_string___"
 type EncryptedSecretStatus struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -33,6 +33,18 @@ func (s *PullSecret) SetErrorStatus(errorMsg string) {
 	s.Status.Message = errorMsg
 }
 
+func (s *PullSecret) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *PullSecret) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *PullSecret) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
 func (es *EncryptedSecret) GetStatus() components.Status {
 	return es.Status
 }
@@ -45,3 +57,15 @@ func (es *EncryptedSecret) SetErrorStatus(errorMsg string) {
 	es.Status.Status = StatusError
 	es.Status.Message = errorMsg
 }
+
+func (es *EncryptedSecret) GetConditions() []components.Condition {
+	return es.Status.Conditions
+}
+
+func (es *EncryptedSecret) SetConditions(conditions []components.Condition) {
+	es.Status.Conditions = conditions
+}
+
+func (es *EncryptedSecret) GetStatusSummary() (string, string) {
+	return es.Status.Status, es.Status.Message
+}
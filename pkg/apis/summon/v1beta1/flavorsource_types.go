@@ -0,0 +1,95 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// S3FlavorSourceSpec loads flavors from an S3 compatible bucket.
+type S3FlavorSourceSpec struct {
+	Bucket string `json:"bucket"`
+	// AWS region the bucket lives in. Defaults to the flavor bucket region of the instance's RegionProfile.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// Endpoint URL for S3 compatible storage like MinIO. Buckets are addressed by path when this is set.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Key prefix the flavor files live under.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Secret in the same namespace with AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys to sign the download
+	// URLs with. Defaults to the operator's own credentials.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// HTTPFlavorSourceSpec loads flavors from plain HTTP URLs.
+type HTTPFlavorSourceSpec struct {
+	// URL the flavor files live under, flavor foo is loaded from <baseURL>/foo.json.bz2.
+	BaseURL string `json:"baseURL"`
+}
+
+// ConfigMapFlavorSourceSpec loads flavors from a ConfigMap, keyed by file name like foo.json.bz2.
+type ConfigMapFlavorSourceSpec struct {
+	Name string `json:"name"`
+}
+
+// PVCFlavorSourceSpec loads flavors from a PersistentVolumeClaim.
+type PVCFlavorSourceSpec struct {
+	ClaimName string `json:"claimName"`
+	// Directory in the volume the flavor files live in.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// FlavorSourceSpec defines where flavors get loaded from. Exactly one source must be set.
+type FlavorSourceSpec struct {
+	// +optional
+	S3 *S3FlavorSourceSpec `json:"s3,omitempty"`
+	// +optional
+	HTTP *HTTPFlavorSourceSpec `json:"http,omitempty"`
+	// +optional
+	ConfigMap *ConfigMapFlavorSourceSpec `json:"configMap,omitempty"`
+	// +optional
+	PersistentVolumeClaim *PVCFlavorSourceSpec `json:"persistentVolumeClaim,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FlavorSource is the Schema for the flavorsources API
+// +k8s:openapi-gen=true
+type FlavorSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FlavorSourceSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// FlavorSourceList contains a list of FlavorSource
+type FlavorSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FlavorSource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FlavorSource{}, &FlavorSourceList{})
+}
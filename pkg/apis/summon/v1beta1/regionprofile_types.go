@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RegionProfileSpec defines the region-specific settings used by every SummonPlatform placed in a region.
+type RegionProfileSpec struct {
+	// AWS region the instances run in, e.g. us-west-2.
+	Region string `json:"region"`
+	// Short region name used in global service hostnames, e.g. us for global.us.prod.svc.ridecell.io.
+	GatewayRegion string `json:"gatewayRegion"`
+	// Data pipeline SQS queue names keyed by environment. The "default" key is used for any other environment.
+	// +optional
+	SQSQueues map[string]string `json:"sqsQueues,omitempty"`
+	// AWS regions instances are allowed to send to their data pipeline queue in. Defaults to the profile region.
+	// +optional
+	SQSRegions []string `json:"sqsRegions,omitempty"`
+	// S3 bucket to load flavors from.
+	// +optional
+	FlavorBucket string `json:"flavorBucket,omitempty"`
+	// AWS region the flavor bucket lives in. Defaults to the profile region.
+	// +optional
+	FlavorBucketRegion string `json:"flavorBucketRegion,omitempty"`
+	// AWS region of the KMS keys used to decrypt EncryptedSecrets. Defaults to the profile region.
+	// +optional
+	KMSRegion string `json:"kmsRegion,omitempty"`
+	// Base hostnames for new instances keyed by environment, an instance foo gets foo.<base>. The "default" key is
+	// used for any other environment.
+	// +optional
+	BaseHostnames map[string]string `json:"baseHostnames,omitempty"`
+	// Default DbConfig for instances that don't set spec.database.dbConfigRef.
+	// +optional
+	DbConfigRef corev1.ObjectReference `json:"dbConfigRef,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegionProfile is the Schema for the regionprofiles API
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:scope=Cluster
+type RegionProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RegionProfileSpec `json:"spec,omitempty"`
+}
+
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegionProfileList contains a list of RegionProfile
+type RegionProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RegionProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RegionProfile{}, &RegionProfileList{})
+}
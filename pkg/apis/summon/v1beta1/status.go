@@ -33,6 +33,28 @@ func (s *SummonPlatform) SetErrorStatus(errorMsg string) {
 	s.Status.Message = errorMsg
 }
 
+func (s *SummonPlatform) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *SummonPlatform) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *SummonPlatform) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
+func (s *SummonPlatform) StatusKind(status string) components.StatusKind {
+	switch status {
+	case StatusRolledBack:
+		return components.StatusKindDegraded
+	case StatusWaitingForWindow, StatusHibernating:
+		return components.StatusKindIdle
+	}
+	return ""
+}
+
 func (s *DjangoUser) GetStatus() components.Status {
 	return s.Status
 }
@@ -46,6 +68,18 @@ func (s *DjangoUser) SetErrorStatus(errorMsg string) {
 	s.Status.Message = errorMsg
 }
 
+func (s *DjangoUser) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *DjangoUser) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *DjangoUser) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
 func (s *MockCarServerTenant) GetStatus() components.Status {
 	return s.Status
 }
@@ -58,3 +92,77 @@ func (s *MockCarServerTenant) SetErrorStatus(errorMsg string) {
 	s.Status.Status = StatusError
 	s.Status.Message = errorMsg
 }
+
+func (s *MockCarServerTenant) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *MockCarServerTenant) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *MockCarServerTenant) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
+func (s *SummonPreviewTemplate) GetStatus() components.Status {
+	return s.Status
+}
+
+func (s *SummonPreviewTemplate) SetStatus(status components.Status) {
+	s.Status = status.(SummonPreviewTemplateStatus)
+}
+
+func (s *SummonPreviewTemplate) SetErrorStatus(errorMsg string) {
+	s.Status.Status = StatusError
+	s.Status.Message = errorMsg
+}
+
+func (s *SummonPreviewTemplate) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *SummonPreviewTemplate) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *SummonPreviewTemplate) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
+func (s *SummonCommand) GetStatus() components.Status {
+	return s.Status
+}
+
+func (s *SummonCommand) SetStatus(status components.Status) {
+	s.Status = status.(SummonCommandStatus)
+}
+
+func (s *SummonCommand) SetErrorStatus(errorMsg string) {
+	s.Status.Status = StatusError
+	s.Status.Message = errorMsg
+}
+
+func (s *SummonCommand) GetConditions() []components.Condition {
+	return s.Status.Conditions
+}
+
+func (s *SummonCommand) SetConditions(conditions []components.Condition) {
+	s.Status.Conditions = conditions
+}
+
+func (s *SummonCommand) GetStatusSummary() (string, string) {
+	return s.Status.Status, s.Status.Message
+}
+
+func (s *SummonCommand) StatusKind(status string) components.StatusKind {
+	switch status {
+	case CommandStatusSucceeded:
+		return components.StatusKindReady
+	case CommandStatusFailed:
+		return components.StatusKindDegraded
+	case CommandStatusAwaitingApproval:
+		return components.StatusKindIdle
+	}
+	return ""
+}
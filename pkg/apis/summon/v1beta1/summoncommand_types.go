@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// SummonCommandSpec defines a one-off Django management command to run against a SummonPlatform.
+type SummonCommandSpec struct {
+	// Name of the SummonPlatform in the same namespace to run the command against.
+	Platform string `json:"platform"`
+	// Arguments for manage.py, like ["clear_cache"] or ["rebuild_index", "--noinput"].
+	Command []string `json:"command"`
+	// What to do while another command is running against the same SummonPlatform. Forbid waits for it to
+	// finish, Replace stops it and Allow runs both. Defaults to Forbid.
+	// +optional
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// How long the command may run before it is stopped, in seconds. Defaults to one hour.
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// SummonCommandStatus defines the observed state of SummonCommand
+type SummonCommandStatus struct {
+	// Overall object status, see the CommandStatus constants.
+	Status string `json:"status,omitempty"`
+	// Message related to the current status.
+	Message string `json:"message,omitempty"`
+	// Job running the command.
+	// +optional
+	Job string `json:"job,omitempty"`
+	// Summon version the command ran with.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Who approved running the command, for SummonPlatforms which require approval.
+	// +optional
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// The time the command started.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	StartTime string `json:"startTime,omitempty"`
+	// The time the command finished.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	CompletionTime string `json:"completionTime,omitempty"`
+	// How long the command ran for.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Exit code of the command.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// The last lines the command logged.
+	// +optional
+	Logs string `json:"logs,omitempty"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SummonCommand is the Schema for the summoncommands API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Platform",type="string",JSONPath=".spec.platform",description="SummonPlatform the command runs against"
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.status",description="object status"
+// +kubebuilder:printcolumn:name="Exit Code",type="integer",JSONPath=".status.exitCode",description="command exit code"
+type SummonCommand struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SummonCommandSpec   `json:"spec,omitempty"`
+	Status SummonCommandStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SummonCommandList contains a list of SummonCommand
+type SummonCommandList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SummonCommand `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SummonCommand{}, &SummonCommandList{})
+}
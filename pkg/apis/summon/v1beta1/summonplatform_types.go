@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 )
 
 // Gross workaround for limitations the Kubernetes code generator and interface{}.
@@ -55,6 +56,27 @@ type DatabaseSpec struct {
 	DbConfigRef corev1.ObjectReference `json:"dbConfigRef,omitempty"`
 }
 
+// CeleryAutoscalingSpec defines scaling celeryd on the depth of the instance's RabbitMQ queues.
+type CeleryAutoscalingSpec struct {
+	// Minimum number of celeryd pods. Defaults to the celeryd replicas setting.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// Maximum number of celeryd pods.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// Number of waiting messages each celeryd pod is expected to keep up with. Defaults to 100.
+	// +optional
+	MessagesPerReplica int32 `json:"messagesPerReplica,omitempty"`
+	// Names of the queues to count. Defaults to every queue in the vhost.
+	// +optional
+	Queues []string `json:"queues,omitempty"`
+	// Minimum time between scaling up. Defaults to 1m.
+	// +optional
+	ScaleUpCooldown metav1.Duration `json:"scaleUpCooldown,omitempty"`
+	// Minimum time between scaling down. Defaults to 5m.
+	// +optional
+	ScaleDownCooldown metav1.Duration `json:"scaleDownCooldown,omitempty"`
+}
+
 // CelerySpec defines configuration and settings for Celery.
 type CelerySpec struct {
 	// Setting for --concurrency.
@@ -64,6 +86,9 @@ type CelerySpec struct {
 	// +optional
 	// +kubebuilder:validation:Enum=prefork,eventlet,gevent,solo
 	Pool string `json:"pool,omitempty"`
+	// Scale celeryd on queue depth. Can't be combined with autoscaling.celeryd.
+	// +optional
+	Autoscaling *CeleryAutoscalingSpec `json:"autoscaling,omitempty"`
 }
 
 // RedisSpec defines resource configuration for redis deployment.
@@ -132,6 +157,99 @@ type ReplicasSpec struct {
 	BusinessPortal *int32 `json:"businessPortal,omitempty"`
 }
 
+// AutoscalingMetricSpec defines a custom per-pod metric to scale on.
+type AutoscalingMetricSpec struct {
+	// Name of the pods metric, as served by the custom metrics API.
+	Name string `json:"name"`
+	// Target average value of the metric across all pods, as a quantity like "100" or "500m".
+	TargetAverageValue string `json:"targetAverageValue"`
+}
+
+// ProcessAutoscalingSpec defines the HorizontalPodAutoscaler settings for a single process type.
+type ProcessAutoscalingSpec struct {
+	// Minimum number of pods. Defaults to the replicas setting for this process type.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// Maximum number of pods.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// Target average CPU utilization, as a percentage of the CPU request. Defaults to 70 unless custom metrics are set.
+	// +optional
+	TargetCPUUtilization *int32 `json:"targetCPUUtilization,omitempty"`
+	// Custom per-pod metrics to scale on.
+	// +optional
+	Metrics []AutoscalingMetricSpec `json:"metrics,omitempty"`
+}
+
+// AutoscalingSpec defines which process types are scaled by a HorizontalPodAutoscaler rather than a fixed replica
+// count. The matching replicas setting is then only used as the default minimum and initial size.
+type AutoscalingSpec struct {
+	// Autoscaling for web (twisted) pods.
+	// +optional
+	Web *ProcessAutoscalingSpec `json:"web,omitempty"`
+	// Autoscaling for celeryd pods.
+	// +optional
+	Celeryd *ProcessAutoscalingSpec `json:"celeryd,omitempty"`
+	// Autoscaling for channelworker pods.
+	// +optional
+	ChannelWorker *ProcessAutoscalingSpec `json:"channelWorker,omitempty"`
+}
+
+// PodOverrideSpec defines changes to make to the pods of a single process type, on top of the defaults from the
+// templates.
+type PodOverrideSpec struct {
+	// Resource requests and limits for the main container. Each entry replaces the template's value for that
+	// resource, any resource not listed keeps the default.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// Node labels the pods must be scheduled onto.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations to add to the pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity rules for the pods. Each of nodeAffinity, podAffinity and podAntiAffinity replaces the default
+	// rules of the same kind when set.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PriorityClass to run the pods with.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Extra environment variables for the main container. Variables with the same name as a default one replace it.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Extra annotations for the pods.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PodOverridesSpec defines the pod overrides for each process type.
+type PodOverridesSpec struct {
+	// Overrides for web (twisted) pods.
+	// +optional
+	Web *PodOverrideSpec `json:"web,omitempty"`
+	// Overrides for daphne pods.
+	// +optional
+	Daphne *PodOverrideSpec `json:"daphne,omitempty"`
+	// Overrides for celeryd pods.
+	// +optional
+	Celeryd *PodOverrideSpec `json:"celeryd,omitempty"`
+	// Overrides for celerybeat pods.
+	// +optional
+	CeleryBeat *PodOverrideSpec `json:"celeryBeat,omitempty"`
+	// Overrides for channelworker pods.
+	// +optional
+	ChannelWorker *PodOverrideSpec `json:"channelWorker,omitempty"`
+	// Overrides for caddy pods.
+	// +optional
+	Static *PodOverrideSpec `json:"static,omitempty"`
+	// Overrides for dispatch pods.
+	// +optional
+	Dispatch *PodOverrideSpec `json:"dispatch,omitempty"`
+	// Overrides for business-portal pods.
+	// +optional
+	BusinessPortal *PodOverrideSpec `json:"businessPortal,omitempty"`
+}
+
 // MonitorSpec will enable in monitoring. (In future we can use it to configure monitor.ridecell.io)
 type MonitoringSpec struct {
 	Enabled *bool `json:"enabled,omitempty"`
@@ -155,6 +273,139 @@ type CompBusinessPortalSpec struct {
 	Version string `json:"version"`
 }
 
+// CanarySpec defines how a canary web Deployment is run and judged.
+type CanarySpec struct {
+	// Number of canary web pods to run. Traffic is split by pod count through the shared web Service, so a single
+	// canary next to 4 stable pods gets roughly 20% of requests. Defaults to 1.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// How long the canary has to stay healthy before it is promoted. Defaults to 5m.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Names of the checks used to judge the canary. Defaults to ["readiness", "errorRate"].
+	// +optional
+	Checks []string `json:"checks,omitempty"`
+	// Highest percentage of 5xx responses from the canary pods the errorRate check allows. Defaults to 5.
+	// +optional
+	MaxErrorRate *int32 `json:"maxErrorRate,omitempty"`
+}
+
+// DeployWindowSpec defines a recurring time range during which new versions may be rolled out.
+type DeployWindowSpec struct {
+	// Days of the week the window opens on, as Mon, Tue, etc. Defaults to every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// Time of day the window opens, as 24-hour HH:MM.
+	Start string `json:"start"`
+	// How long the window stays open. May run past midnight.
+	Duration metav1.Duration `json:"duration"`
+	// IANA timezone for the start time, e.g. America/Los_Angeles. Defaults to UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// RolloutSpec defines how a new version is rolled out to the Summon pods.
+type RolloutSpec struct {
+	// Rollout strategy. AllAtOnce updates every Deployment as soon as migrations finish, Canary first runs the new
+	// version in a separate web Deployment and only continues if it stays healthy. Defaults to AllAtOnce.
+	// +optional
+	// +kubebuilder:validation:Enum=AllAtOnce,Canary
+	Strategy string `json:"strategy,omitempty"`
+	// Canary settings, only used with the Canary strategy.
+	// +optional
+	Canary CanarySpec `json:"canary,omitempty"`
+}
+
+// AutoRollbackSpec defines when a failed version is rolled back to the last good one.
+type AutoRollbackSpec struct {
+	// Roll back when migrations for a new version fail or its rollout misses the deadline.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Number of deploys kept in status.deployHistory, whether or not automatic rollbacks are enabled. Defaults to 10.
+	// +optional
+	HistoryLimit int `json:"historyLimit,omitempty"`
+	// How long the Deployments for a new version have to become available. Defaults to 15m.
+	// +optional
+	RolloutDeadline metav1.Duration `json:"rolloutDeadline,omitempty"`
+	// Keep the RDSSnapshot taken before a rolled back version's migrations, rather than letting its TTL expire, so
+	// the database can be restored from it.
+	// +optional
+	PreserveSnapshot bool `json:"preserveSnapshot,omitempty"`
+	// Restore the database from the RDSSnapshot taken before a rolled back version's migrations. RDS restores
+	// snapshots to a new instance, so the PostgresDatabase is deleted and re-created from the snapshot, losing
+	// anything written since it was taken. Implies PreserveSnapshot.
+	// +optional
+	RestoreSnapshot bool `json:"restoreSnapshot,omitempty"`
+}
+
+// HTTPCheckSpec defines one HTTP request made against an instance's Service.
+type HTTPCheckSpec struct {
+	// Service to send the request to, one of web, daphne, dispatch or businessPortal.
+	// +kubebuilder:validation:Enum=web,daphne,dispatch,businessPortal
+	Service string `json:"service"`
+	// Path to request. Defaults to /.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Status codes which count as passing. Defaults to any 2xx or 3xx.
+	// +optional
+	ExpectedStatus []int `json:"expectedStatus,omitempty"`
+	// Longest the response may take. Defaults to 5s.
+	// +optional
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// SelfCheckSpec defines the HTTP checks an instance has to pass before a new version is marked Ready.
+type SelfCheckSpec struct {
+	// Skip the HTTP checks, and mark the instance Ready as soon as its pods are available.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// Checks to run. Defaults to /healthz on web and daphne, plus / on dispatch and business portal when they are
+	// deployed.
+	// +optional
+	Checks []HTTPCheckSpec `json:"checks,omitempty"`
+}
+
+// HibernationScheduleSpec defines a recurring time range during which an instance is scaled down to nothing.
+type HibernationScheduleSpec struct {
+	// Days of the week the hibernation starts on, as Mon, Tue, etc. Defaults to every day.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// Time of day the hibernation starts, as 24-hour HH:MM.
+	Start string `json:"start"`
+	// How long the instance hibernates for. May run past midnight.
+	Duration metav1.Duration `json:"duration"`
+	// IANA timezone for the start time, e.g. America/Los_Angeles. Defaults to UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// HibernationSpec defines when a non-production instance scales every workload to zero. Setting the
+// ridecell.io/hibernate annotation to true or false overrides the schedules.
+type HibernationSpec struct {
+	// Recurring times to hibernate, like nights and weekends. Not allowed in the uat and prod environments.
+	// +optional
+	Schedules []HibernationScheduleSpec `json:"schedules,omitempty"`
+}
+
+// CloneFromSpec defines an existing instance to copy the data for a new one from.
+type CloneFromSpec struct {
+	// Name of the SummonPlatform to clone.
+	Instance string `json:"instance"`
+	// Namespace of the SummonPlatform to clone. Defaults to the same namespace. Cloning from another namespace
+	// needs a summon.ridecell.io/allowCloneFrom annotation on the source listing this instance's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// Existing RDS snapshot of the source database to restore. Defaults to taking a new snapshot.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// Point in time to restore the source database to, as RFC3339. Can't be combined with SnapshotID.
+	// +optional
+	PointInTime string `json:"pointInTime,omitempty"`
+	// Don't copy the static and MIV buckets.
+	// +optional
+	SkipBuckets bool `json:"skipBuckets,omitempty"`
+}
+
 // SummonPlatformSpec defines the desired state of SummonPlatform
 type SummonPlatformSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
@@ -186,7 +437,10 @@ type SummonPlatformSpec struct {
 	FernetKeyLifetime time.Duration `json:"fernetKeyLifetime,omitempty"`
 	// Disable the creation of the dispatcher@ridecell.com superuser.
 	NoCreateSuperuser bool `json:"noCreateSuperuser,omitempty"`
-	// AWS Region setting
+	// Name of the cluster RegionProfile supplying region-specific defaults. Defaults to the AWS region.
+	// +optional
+	RegionProfile string `json:"regionProfile,omitempty"`
+	// AWS Region setting. Defaults to the region from the RegionProfile.
 	// +optional
 	AwsRegion string `json:"awsRegion,omitempty"`
 	// SQS queue setting
@@ -198,12 +452,20 @@ type SummonPlatformSpec struct {
 	// The flavor of data to be imported upon creation
 	// +optional
 	Flavor string `json:"flavor,omitempty"`
+	// Name of the FlavorSource in the same namespace to load the flavor from. Defaults to the region profile's
+	// flavor bucket.
+	// +optional
+	FlavorSource string `json:"flavorSource,omitempty"`
 	// Manual Identity Verification settings.
 	// +optional
 	MIV MIVSpec `json:"miv,omitempty"`
 	// Environment setting.
 	// +optional
 	Environment string `json:"environment,omitempty"`
+	// Whether SummonCommands against this instance wait for a summon.ridecell.io/approvedBy annotation before
+	// running. Defaults to true for prod environments.
+	// +optional
+	RequireCommandApproval *bool `json:"requireCommandApproval,omitempty"`
 	// Enable NewRelic APM.
 	// +optional
 	EnableNewRelic *bool `json:"enableNewRelic,omitempty"`
@@ -225,6 +487,12 @@ type SummonPlatformSpec struct {
 	// Pod replica settings.
 	// +optional
 	Replicas ReplicasSpec `json:"replicas,omitempty"`
+	// Pod autoscaling settings.
+	// +optional
+	Autoscaling AutoscalingSpec `json:"autoscaling,omitempty"`
+	// Per-process resources, scheduling and environment overrides.
+	// +optional
+	PodOverrides PodOverridesSpec `json:"podOverrides,omitempty"`
 	// Google Cloud project to use.
 	// +optional
 	GCPProject string `json:"gcpProject,omitempty"`
@@ -247,6 +515,26 @@ type SummonPlatformSpec struct {
 	// Settings for comp-business-portal.
 	// +optional
 	BusinessPortal CompBusinessPortalSpec `json:"businessPortal,omitempty"`
+	// Version rollout settings.
+	// +optional
+	Rollout RolloutSpec `json:"rollout,omitempty"`
+	// Time windows new versions can be deployed in. Backups, migrations and the version change are held until the
+	// next window opens, config and secret changes still go out straight away. Defaults to any time.
+	// +optional
+	DeployWindows []DeployWindowSpec `json:"deployWindows,omitempty"`
+	// Automatic rollback settings.
+	// +optional
+	AutoRollback AutoRollbackSpec `json:"autoRollback,omitempty"`
+	// HTTP self check settings.
+	// +optional
+	SelfCheck SelfCheckSpec `json:"selfCheck,omitempty"`
+	// Scheduled scale-to-zero settings.
+	// +optional
+	Hibernation HibernationSpec `json:"hibernation,omitempty"`
+	// Instance to copy the database, buckets, config and secrets from when this one is created. Outbound
+	// integrations like SMS and email are left out of the copied config and secrets.
+	// +optional
+	CloneFrom *CloneFromSpec `json:"cloneFrom,omitempty"`
 	// Feature flag to disable the CORE-1540 fixup in case it goes AWOL.
 	// To be removed when support for the 1540 fixup is removed in summon.
 	// +optional
@@ -275,6 +563,302 @@ type WaitStatus struct {
 	Until string `json:"until,omitempty"`
 }
 
+// CeleryAutoscalingStatus is the output information for queue-depth driven celeryd scaling.
+type CeleryAutoscalingStatus struct {
+	// Number of waiting messages seen on the last check.
+	// +optional
+	QueueDepth int `json:"queueDepth,omitempty"`
+	// Number of celeryd pods wanted for that queue depth, before cooldowns.
+	// +optional
+	DesiredReplicas int32 `json:"desiredReplicas,omitempty"`
+	// Number of celeryd pods currently requested.
+	// +optional
+	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
+	// The last time celeryd was scaled.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	LastScaleTime string `json:"lastScaleTime,omitempty"`
+	// Details on the last scaling decision.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutStatus is the output information for version rollouts.
+type RolloutStatus struct {
+	// Current phase of the canary, one of Canary, Promoted or RolledBack.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Last version which was fully deployed and became ready. Both canaries and automatic rollbacks fall back on
+	// it, and each deploy record starts from it.
+	// +optional
+	StableVersion string `json:"stableVersion,omitempty"`
+	// Version the most recent canary was run for.
+	// +optional
+	CanaryVersion string `json:"canaryVersion,omitempty"`
+	// The time the canary was started.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	StartTime string `json:"startTime,omitempty"`
+	// Details on the outcome of the canary checks.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AutoRollbackStatus is the output information for automatic rollbacks.
+type AutoRollbackStatus struct {
+	// Version whose rollout deadline is being tracked.
+	// +optional
+	RolloutVersion string `json:"rolloutVersion,omitempty"`
+	// The time the Deployments for RolloutVersion started rolling out.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	RolloutStartTime string `json:"rolloutStartTime,omitempty"`
+	// Version which was rolled back. Stays rolled back until spec.version changes.
+	// +optional
+	FailedVersion string `json:"failedVersion,omitempty"`
+	// Version the Deployments were rolled back to.
+	// +optional
+	RevertedTo string `json:"revertedTo,omitempty"`
+	// ID of the RDS snapshot kept from before the failed version's migrations, if any.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// Progress restoring the database from a snapshot, when spec.autoRollback.restoreSnapshot is set.
+	// +optional
+	Restore SnapshotRestoreStatus `json:"restore,omitempty"`
+}
+
+// SnapshotRestoreStatus is the output information for restoring the database after an automatic rollback. Kept
+// once complete, since the PostgresDatabase stays restored from the snapshot.
+type SnapshotRestoreStatus struct {
+	// Current restore phase, see the RestorePhase constants.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// ID of the RDS snapshot being restored.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// Name of the database in the snapshot.
+	// +optional
+	Database string `json:"database,omitempty"`
+	// Owner of the database in the snapshot.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+}
+
+// DeployPhaseDurations is how long a deploy spent in each phase.
+type DeployPhaseDurations struct {
+	// Time spent waiting for a deploy window.
+	// +optional
+	WaitingForWindow metav1.Duration `json:"waitingForWindow,omitempty"`
+	// Time spent creating the pre-migration backup.
+	// +optional
+	Backup metav1.Duration `json:"backup,omitempty"`
+	// Time spent running migrations.
+	// +optional
+	Migrate metav1.Duration `json:"migrate,omitempty"`
+	// Time spent in the post-migrate wait.
+	// +optional
+	PostMigrateWait metav1.Duration `json:"postMigrateWait,omitempty"`
+	// Time spent rolling out the Deployments, including any canary.
+	// +optional
+	Rollout metav1.Duration `json:"rollout,omitempty"`
+}
+
+// DeployRecord is one entry in the deploy history.
+type DeployRecord struct {
+	// Last version which was ready before this deploy started.
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+	// Version being deployed.
+	ToVersion string `json:"toVersion"`
+	// What started the deploy, one of AutoDeploy or Manual.
+	Trigger string `json:"trigger"`
+	// The time the deploy was first seen.
+	// Real type = time.Time, same workaround as WaitStatus.
+	StartTime string `json:"startTime"`
+	// The time the deploy finished, empty while it is in progress.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	EndTime string `json:"endTime,omitempty"`
+	// How the deploy ended, one of InProgress, Succeeded, Failed, RolledBack or Superseded.
+	Outcome string `json:"outcome"`
+	// Status message from when a deploy failed or was rolled back.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Phase the deploy is currently in, used to fill in PhaseDurations.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// The time the current phase started.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	PhaseStartTime string `json:"phaseStartTime,omitempty"`
+	// How long the deploy spent in each phase.
+	// +optional
+	PhaseDurations DeployPhaseDurations `json:"phaseDurations,omitempty"`
+}
+
+// HTTPCheckResult is the outcome of one HTTP self check.
+type HTTPCheckResult struct {
+	// Service the request was sent to.
+	Service string `json:"service"`
+	// URL requested.
+	URL string `json:"url"`
+	// Response status code, 0 if there was no response.
+	// +optional
+	StatusCode int `json:"statusCode,omitempty"`
+	// How long the response took.
+	// +optional
+	ResponseTime metav1.Duration `json:"responseTime,omitempty"`
+	// Whether the check passed.
+	Passed bool `json:"passed"`
+	// Why the check failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// HibernationStatus is the output information for hibernation.
+type HibernationStatus struct {
+	// Why the instance is hibernating, Schedule or Annotation. Empty while it is awake.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// The time the instance went into hibernation.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	Since string `json:"since,omitempty"`
+	// The time the current schedule ends and the instance wakes up, empty when hibernating by annotation.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	WakeAt string `json:"wakeAt,omitempty"`
+}
+
+// FlavorStatus is the output information for loading the flavor.
+type FlavorStatus struct {
+	// Flavor being or last loaded.
+	// +optional
+	Flavor string `json:"flavor,omitempty"`
+	// FlavorSource it was loaded from, empty for the region profile's flavor bucket.
+	// +optional
+	Source string `json:"source,omitempty"`
+	// Importing or Imported.
+	// +optional
+	Status string `json:"status,omitempty"`
+	// Version whose migrations loaded the flavor.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// The time the flavor finished loading.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	ImportedAt string `json:"importedAt,omitempty"`
+}
+
+// CloneBucketStatus is the progress of copying one S3 bucket for a clone.
+type CloneBucketStatus struct {
+	// Bucket being copied from.
+	Source string `json:"source"`
+	// Bucket being copied to.
+	Destination string `json:"destination"`
+	// Where to pick up listing the source bucket.
+	// +optional
+	ContinuationToken string `json:"continuationToken,omitempty"`
+	// Number of objects copied so far.
+	// +optional
+	CopiedObjects int `json:"copiedObjects,omitempty"`
+	// Whether every object has been copied.
+	// +optional
+	Done bool `json:"done,omitempty"`
+}
+
+// CloneStatus is the output information for cloning from another instance.
+type CloneStatus struct {
+	// Current clone phase, see the ClonePhase constants.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Secret holding the secrets copied from the source.
+	// +optional
+	Secret string `json:"secret,omitempty"`
+	// Config copied from the source, used where this instance doesn't set a value itself.
+	// +optional
+	Config map[string]ConfigValue `json:"config,omitempty"`
+	// Region of the source's AWS resources.
+	// +optional
+	SourceRegion string `json:"sourceRegion,omitempty"`
+	// RDS instance the source database lives on.
+	// +optional
+	SourceRDSInstanceID string `json:"sourceRDSInstanceID,omitempty"`
+	// Name of the source database.
+	// +optional
+	SourceDatabase string `json:"sourceDatabase,omitempty"`
+	// Owner of the source database.
+	// +optional
+	SourceOwner string `json:"sourceOwner,omitempty"`
+	// RDS snapshot the database is restored from.
+	// +optional
+	SnapshotID string `json:"snapshotID,omitempty"`
+	// Point in time the database is restored to, when not using a snapshot.
+	// +optional
+	RestoreTime string `json:"restoreTime,omitempty"`
+	// Progress of the bucket copies.
+	// +optional
+	Buckets []CloneBucketStatus `json:"buckets,omitempty"`
+}
+
+// SelfCheckStatus is the output information for the HTTP self checks.
+type SelfCheckStatus struct {
+	// Version the checks last passed for.
+	// +optional
+	PassedVersion string `json:"passedVersion,omitempty"`
+	// The time the checks first failed for the version being deployed, empty while they pass.
+	// Real type = time.Time, same workaround as WaitStatus.
+	// +optional
+	FailingSince string `json:"failingSince,omitempty"`
+	// Results from the last run of the checks.
+	// +optional
+	Results []HTTPCheckResult `json:"results,omitempty"`
+}
+
+// WorkloadStatus is the readiness of one of the Deployments or StatefulSets for an instance.
+type WorkloadStatus struct {
+	// Name of the process, like web or celerybeat.
+	Name string `json:"name"`
+	// Deployment or StatefulSet.
+	Kind string `json:"kind"`
+	// Replicas wanted by the spec.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// Replicas which are available, or ready for a StatefulSet.
+	AvailableReplicas int32 `json:"availableReplicas"`
+	// Replicas running the current pod template.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// Whether all desired replicas are available.
+	Ready bool `json:"ready"`
+	// One of Complete, Progressing, Stuck or Missing.
+	Rollout string `json:"rollout"`
+	// Why the rollout is stuck.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutProblem describes something keeping a workload from becoming available.
+type RolloutProblem struct {
+	// Name of the process, like web or celerybeat.
+	Workload string `json:"workload"`
+	// ProgressDeadlineExceeded for the whole workload, otherwise CrashLoopBackOff, ImagePullBackOff, ErrImagePull
+	// or OOMKilled for one container.
+	Reason string `json:"reason"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// One of the failing pods.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// +optional
+	Container string `json:"container,omitempty"`
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+	// The last lines the container logged before it failed.
+	// +optional
+	LastLogLines string `json:"lastLogLines,omitempty"`
+}
+
 // SummonPlatformStatus defines the observed state of SummonPlatform
 type SummonPlatformStatus struct {
 	// Overall object status
@@ -309,6 +893,43 @@ type SummonPlatformStatus struct {
 	// Status for deployment Waits
 	// +optional
 	Wait WaitStatus `json:"wait,omitempty"`
+	// Status for version rollouts
+	// +optional
+	Rollout RolloutStatus `json:"rollout,omitempty"`
+	// When the next deploy window opens while a new version is waiting, in UnixDate format.
+	// +optional
+	NextDeployWindow string `json:"nextDeployWindow,omitempty"`
+	// Status for automatic rollbacks
+	// +optional
+	AutoRollback AutoRollbackStatus `json:"autoRollback,omitempty"`
+	// Recent deploys, newest first.
+	// +optional
+	DeployHistory []DeployRecord `json:"deployHistory,omitempty"`
+	// Status for the HTTP self checks
+	// +optional
+	SelfCheck SelfCheckStatus `json:"selfCheck,omitempty"`
+	// Readiness of each workload the instance runs.
+	// +optional
+	Workloads []WorkloadStatus `json:"workloads,omitempty"`
+	// Why the current rollout looks stuck.
+	// +optional
+	RolloutProblems []RolloutProblem `json:"rolloutProblems,omitempty"`
+	// Status for queue-depth driven celeryd scaling
+	// +optional
+	CeleryAutoscaling CeleryAutoscalingStatus `json:"celeryAutoscaling,omitempty"`
+	// Status for scheduled scale-to-zero
+	// +optional
+	Hibernation HibernationStatus `json:"hibernation,omitempty"`
+	// Status for cloning from another instance
+	// +optional
+	Clone CloneStatus `json:"clone,omitempty"`
+	// Status for loading the flavor
+	// +optional
+	Flavor FlavorStatus `json:"flavor,omitempty"`
+
+	// Standard status conditions, including Ready.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// SummonPreviewTemplateSpec defines how preview SummonPlatforms get created for feature branches.
+type SummonPreviewTemplateSpec struct {
+	// Regular expression for the branches to create previews for. It is matched against the branch name as it
+	// appears in image tags, so anything other than letters, numbers, _, . and - has been replaced with -.
+	BranchPattern string `json:"branchPattern"`
+	// Start of the generated SummonPlatform names. Defaults to the template name.
+	// +optional
+	NamePrefix string `json:"namePrefix,omitempty"`
+	// Domain for the generated hostnames, a preview named foo gets foo.<domain>. Defaults to the usual hostname
+	// for the region and environment.
+	// +optional
+	HostnameDomain string `json:"hostnameDomain,omitempty"`
+	// How long a preview lives after the last new image for its branch. Defaults to 72 hours.
+	// +optional
+	TTL metav1.Duration `json:"ttl,omitempty"`
+	// Spec for the preview SummonPlatforms. AutoDeploy, Version and Hostname are set for each branch. Changes
+	// only apply to previews created afterwards.
+	Template SummonPlatformSpec `json:"template"`
+}
+
+// PreviewStatus is the state of the preview for one branch.
+type PreviewStatus struct {
+	// Branch the preview tracks.
+	Branch string `json:"branch"`
+	// Name of the SummonPlatform.
+	Name string `json:"name"`
+	// Hostname the preview is served on.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// Latest image seen for the branch.
+	Image string `json:"image"`
+	// When the preview gets deleted unless a new image shows up first.
+	// Real type = time.Time, same workaround as WaitStatus.
+	ExpiresAt string `json:"expiresAt"`
+	// Whether the preview has already been deleted for passing its TTL.
+	// +optional
+	Expired bool `json:"expired,omitempty"`
+	// Why the latest image for the branch couldn't be found, cleared once a lookup works again.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// SummonPreviewTemplateStatus defines the observed state of SummonPreviewTemplate
+type SummonPreviewTemplateStatus struct {
+	// Overall object status
+	Status string `json:"status,omitempty"`
+	// Message related to the current status.
+	Message string `json:"message,omitempty"`
+	// Previews for each matching branch.
+	// +optional
+	Previews []PreviewStatus `json:"previews,omitempty"`
+
+	// Standard status conditions.
+	// +optional
+	Conditions []components.Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SummonPreviewTemplate is the Schema for the summonpreviewtemplates API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+type SummonPreviewTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SummonPreviewTemplateSpec   `json:"spec,omitempty"`
+	Status SummonPreviewTemplateStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SummonPreviewTemplateList contains a list of SummonPreviewTemplate
+type SummonPreviewTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SummonPreviewTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SummonPreviewTemplate{}, &SummonPreviewTemplateList{})
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/test_helpers"
+)
+
+var _ = Describe("SummonPreviewTemplate types", func() {
+	var helpers *test_helpers.PerTestHelpers
+
+	BeforeEach(func() {
+		helpers = testHelpers.SetupTest()
+	})
+
+	AfterEach(func() {
+		helpers.TeardownTest()
+	})
+
+	It("can create a SummonPreviewTemplate object", func() {
+		c := helpers.Client
+		key := types.NamespacedName{
+			Name:      "previews",
+			Namespace: helpers.Namespace,
+		}
+		created := &summonv1beta1.SummonPreviewTemplate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "previews",
+				Namespace: helpers.Namespace,
+			},
+			Spec: summonv1beta1.SummonPreviewTemplateSpec{
+				BranchPattern: "^feature-",
+				TTL:           metav1.Duration{Duration: 24 * time.Hour},
+				Template: summonv1beta1.SummonPlatformSpec{
+					Environment: "dev",
+				},
+			},
+		}
+		fetched := &summonv1beta1.SummonPreviewTemplate{}
+		err := c.Create(context.TODO(), created)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = c.Get(context.TODO(), key, fetched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.Spec).To(Equal(created.Spec))
+
+		err = c.Delete(context.TODO(), fetched)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
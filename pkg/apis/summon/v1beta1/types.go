@@ -17,11 +17,93 @@ limitations under the License.
 package v1beta1
 
 const (
-	StatusInitializing    = "Initializing"
-	StatusMigrating       = "Migrating"
-	StatusCreatingBackup  = "CreatingBackup"
-	StatusDeploying       = "Deploying"
-	StatusReady           = "Ready"
-	StatusError           = "Error"
-	StatusPostMigrateWait = "PostMigrateWait"
+	StatusInitializing     = "Initializing"
+	StatusMigrating        = "Migrating"
+	StatusCreatingBackup   = "CreatingBackup"
+	StatusDeploying        = "Deploying"
+	StatusReady            = "Ready"
+	StatusError            = "Error"
+	StatusPostMigrateWait  = "PostMigrateWait"
+	StatusWaitingForWindow = "WaitingForWindow"
+	StatusRolledBack       = "RolledBack"
+	StatusHibernating      = "Hibernating"
+)
+
+// Status condition types maintained by SummonPlatform components, in addition to the standard Ready, Progressing and Degraded.
+const (
+	ConditionPullSecretReady    = "PullSecretReady"
+	ConditionPostgresReady      = "PostgresReady"
+	ConditionRabbitMQReady      = "RabbitMQReady"
+	ConditionMigrationsComplete = "MigrationsComplete"
+)
+
+// Rollout strategies and canary phases.
+const (
+	RolloutStrategyAllAtOnce = "AllAtOnce"
+	RolloutStrategyCanary    = "Canary"
+
+	RolloutPhaseCanary     = "Canary"
+	RolloutPhasePromoted   = "Promoted"
+	RolloutPhaseRolledBack = "RolledBack"
+)
+
+// Deploy history triggers and outcomes.
+const (
+	DeployTriggerAutoDeploy = "AutoDeploy"
+	DeployTriggerManual     = "Manual"
+
+	DeployOutcomeInProgress = "InProgress"
+	DeployOutcomeSucceeded  = "Succeeded"
+	DeployOutcomeFailed     = "Failed"
+	DeployOutcomeRolledBack = "RolledBack"
+	DeployOutcomeSuperseded = "Superseded"
+)
+
+// Workload rollout states.
+const (
+	WorkloadRolloutComplete    = "Complete"
+	WorkloadRolloutProgressing = "Progressing"
+	WorkloadRolloutStuck       = "Stuck"
+	WorkloadRolloutMissing     = "Missing"
+)
+
+// Hibernation reasons.
+const (
+	HibernationReasonSchedule   = "Schedule"
+	HibernationReasonAnnotation = "Annotation"
+)
+
+// Clone phases, in order.
+const (
+	ClonePhaseCopyingConfig     = "CopyingConfig"
+	ClonePhaseSnapshotting      = "Snapshotting"
+	ClonePhaseRestoringDatabase = "RestoringDatabase"
+	ClonePhaseCopyingBuckets    = "CopyingBuckets"
+	ClonePhaseComplete          = "Complete"
+)
+
+// Snapshot restore phases after an automatic rollback, in order.
+const (
+	RestorePhaseDeletingDatabase = "DeletingDatabase"
+	RestorePhaseRestoring        = "Restoring"
+	RestorePhaseComplete         = "Complete"
+)
+
+// Flavor import states.
+const (
+	FlavorStatusImporting = "Importing"
+	FlavorStatusImported  = "Imported"
+)
+
+// SummonCommand states and concurrency policies.
+const (
+	CommandStatusPending          = "Pending"
+	CommandStatusAwaitingApproval = "AwaitingApproval"
+	CommandStatusRunning          = "Running"
+	CommandStatusSucceeded        = "Succeeded"
+	CommandStatusFailed           = "Failed"
+
+	ConcurrencyPolicyForbid  = "Forbid"
+	ConcurrencyPolicyReplace = "Replace"
+	ConcurrencyPolicyAllow   = "Allow"
 )
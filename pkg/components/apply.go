@@ -0,0 +1,195 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/Ridecell/ridecell-operator/pkg/templates"
+)
+
+// Annotation holding the object as last rendered from its template, so we know which fields the operator owns.
+// Secret data is never stored in it.
+const LastAppliedAnnotation = "ridecell.io/last-applied"
+
+// Apply renders a template and merges it onto the existing object the same way `kubectl apply` does. Fields the
+// template sets are updated, fields it used to set but no longer does (labels, annotations, env vars, etc) are
+// removed, and anything it never set, like replicas managed by an HPA or a human's annotation, is left alone.
+// mutateFn is optional and runs after the merge for any component-specific fixups.
+func (ctx *ComponentContext) Apply(path string, extraData map[string]interface{}, mutateFn func(runtime.Object, runtime.Object) error) (Result, controllerutil.OperationResult, error) {
+	target, err := ctx.GetTemplate(path, extraData)
+	if err == templates.ErrEmptyTemplate {
+		return ctx.pruneEmptyTemplate(path)
+	}
+	if err != nil {
+		return Result{}, controllerutil.OperationResultNone, err
+	}
+	return ctx.ApplyObject(path, target, mutateFn)
+}
+
+// ApplyObject is Apply for an object already rendered from the template at path, for components which need to
+// adjust the rendered object first. Those adjustments are owned by the operator the same as the rest of the
+// template, so they get removed again once they stop being made.
+func (ctx *ComponentContext) ApplyObject(path string, target runtime.Object, mutateFn func(runtime.Object, runtime.Object) error) (Result, controllerutil.OperationResult, error) {
+	setTemplateLabel(target, path)
+	modified, err := renderedJSON(target, false)
+	if err != nil {
+		return Result{}, controllerutil.OperationResultNone, err
+	}
+	lastApplied, err := renderedJSON(target, true)
+	if err != nil {
+		return Result{}, controllerutil.OperationResultNone, err
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx.Context, ctx, target.DeepCopyObject(), func(existing runtime.Object) error {
+		err := threeWayMerge(existing, modified)
+		if err != nil {
+			return err
+		}
+		err = controllerutil.SetControllerReference(ctx.Top.(metav1.Object), existing.(metav1.Object), ctx.Scheme)
+		if err != nil {
+			return err
+		}
+		if mutateFn != nil {
+			err = mutateFn(target, existing)
+			if err != nil {
+				return err
+			}
+		}
+		setLastApplied(existing.(metav1.ObjectMetaAccessor).GetObjectMeta().(*metav1.ObjectMeta), lastApplied)
+		return nil
+	})
+	if err != nil {
+		return Result{Requeue: true}, op, err
+	}
+
+	return Result{}, op, nil
+}
+
+// Serialize a rendered template into the form used for merging and for the last-applied annotation. Nulls are
+// dropped since in a merge patch they mean "delete this field", and status is never ours to apply.
+func renderedJSON(obj runtime.Object, forAnnotation bool) ([]byte, error) {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "error serializing template")
+	}
+	data := map[string]interface{}{}
+	err = json.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error serializing template")
+	}
+	delete(data, "status")
+	if metadata, ok := data["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, LastAppliedAnnotation)
+		}
+	}
+	if _, isSecret := obj.(*corev1.Secret); isSecret && forAnnotation {
+		delete(data, "data")
+		delete(data, "stringData")
+	}
+	return json.Marshal(dropNulls(data))
+}
+
+func dropNulls(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range typed {
+			if inner == nil {
+				delete(typed, key)
+			} else {
+				typed[key] = dropNulls(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range typed {
+			typed[i] = dropNulls(inner)
+		}
+	}
+	return value
+}
+
+// Merge the rendered template onto the existing object in place, using the last-applied annotation as the
+// common ancestor.
+func threeWayMerge(existing runtime.Object, modified []byte) error {
+	existingMeta := existing.(metav1.ObjectMetaAccessor).GetObjectMeta().(*metav1.ObjectMeta)
+	original := []byte(existingMeta.Annotations[LastAppliedAnnotation])
+	current, err := json.Marshal(existing)
+	if err != nil {
+		return errors.Wrap(err, "error serializing existing object")
+	}
+	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(existing)
+	if err != nil {
+		return errors.Wrap(err, "error building patch metadata")
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, patchMeta, true)
+	if err != nil {
+		return errors.Wrap(err, "error creating apply patch")
+	}
+	merged, err := strategicpatch.StrategicMergePatch(current, patch, existing)
+	if err != nil {
+		return errors.Wrap(err, "error applying patch")
+	}
+
+	// Decode into a zeroed object so fields removed by the patch don't survive.
+	val := reflect.ValueOf(existing).Elem()
+	val.Set(reflect.Zero(val.Type()))
+	return errors.Wrap(json.Unmarshal(merged, existing), "error decoding patched object")
+}
+
+// Remove labels and annotations which a previous version of the template set but the current one doesn't. This is
+// used by CreateOrUpdate, which otherwise leaves metadata added by others alone.
+func pruneMeta(target, existing *metav1.ObjectMeta, lastApplied []byte) error {
+	original := existing.Annotations[LastAppliedAnnotation]
+	if original != "" {
+		previous := struct {
+			Metadata metav1.ObjectMeta `json:"metadata"`
+		}{}
+		err := json.Unmarshal([]byte(original), &previous)
+		if err != nil {
+			return errors.Wrapf(err, "error decoding %s annotation", LastAppliedAnnotation)
+		}
+		for key := range previous.Metadata.Labels {
+			if _, ok := target.Labels[key]; !ok {
+				delete(existing.Labels, key)
+			}
+		}
+		for key := range previous.Metadata.Annotations {
+			if _, ok := target.Annotations[key]; !ok {
+				delete(existing.Annotations, key)
+			}
+		}
+	}
+	setLastApplied(existing, lastApplied)
+	return nil
+}
+
+func setLastApplied(meta *metav1.ObjectMeta, lastApplied []byte) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[LastAppliedAnnotation] = string(lastApplied)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Top-level condition types maintained by the reconciler for every object.
+const (
+	ConditionReady       = "Ready"
+	ConditionProgressing = "Progressing"
+	ConditionDegraded    = "Degraded"
+)
+
+// Reasons used by the reconciler when it fills in conditions itself.
+const (
+	ReasonReady          = "Ready"
+	ReasonError          = "Error"
+	ReasonReconciled     = "Reconciled"
+	ReasonWaiting        = "Waiting"
+	ReasonInitializing   = "Initializing"
+	ReasonReconcileError = "ReconcileError"
+)
+
+// Every API group uses the same status strings for these two states.
+const (
+	statusReady = "Ready"
+	statusError = "Error"
+)
+
+// Condition is a standard Kubernetes-style status condition, usable with `kubectl wait --for=condition=...`.
+type Condition struct {
+	// Type of condition in CamelCase, like Ready or PostgresReady.
+	Type string `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// The .metadata.generation that the condition was set based upon.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Machine-readable CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Human-readable message with details about the transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into out. Written by hand since this package isn't run through deepcopy-gen.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy returns a new copy of the Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// Build a condition from a boolean, mostly for components filling in Result.Conditions.
+func NewCondition(conditionType string, ok bool, reason, message string) Condition {
+	status := corev1.ConditionFalse
+	if ok {
+		status = corev1.ConditionTrue
+	}
+	return Condition{Type: conditionType, Status: status, Reason: reason, Message: message}
+}
+
+// Find a condition by type, returns nil if not present.
+func FindCondition(conditions []Condition, conditionType string) *Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// Check if a condition is present and True.
+func IsConditionTrue(conditions []Condition, conditionType string) bool {
+	condition := FindCondition(conditions, conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// Add or update a condition in the list, only bumping LastTransitionTime when the status actually changes.
+func SetCondition(conditions []Condition, newCondition Condition) []Condition {
+	existing := FindCondition(conditions, newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		return append(conditions, newCondition)
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		existing.LastTransitionTime = newCondition.LastTransitionTime
+		if existing.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+	return conditions
+}
+
+// How a status value maps onto the top-level Ready, Progressing, and Degraded conditions.
+type StatusKind string
+
+const (
+	// Still working towards Ready, the default for any status other than Ready or Error.
+	StatusKindProgressing StatusKind = "Progressing"
+	// Done and healthy, like Ready or a command which Succeeded.
+	StatusKindReady StatusKind = "Ready"
+	// Deliberately not doing anything right now, like waiting for a deploy window or hibernating.
+	StatusKindIdle StatusKind = "Idle"
+	// Stopped in a bad state until something changes, like Error or a command which Failed.
+	StatusKindDegraded StatusKind = "Degraded"
+)
+
+// Optional interface for ConditionStatusers with statuses other than Ready and Error which aren't an active
+// rollout. Return an empty string to fall back to the default mapping.
+type StatusKinder interface {
+	StatusKind(status string) StatusKind
+}
+
+// Work out how to treat a status value, asking the object first if it knows better.
+func statusKindOf(obj ConditionStatuser, status string) StatusKind {
+	if kinder, ok := obj.(StatusKinder); ok {
+		if kind := kinder.StatusKind(status); kind != "" {
+			return kind
+		}
+	}
+	switch status {
+	case statusReady:
+		return StatusKindReady
+	case statusError:
+		return StatusKindDegraded
+	}
+	return StatusKindProgressing
+}
+
+// Work out the top-level Ready, Progressing, and Degraded conditions from the usual Status/Message pair.
+func summaryConditions(status, message string, kind StatusKind) []Condition {
+	reason := status
+	if reason == "" {
+		// No status yet, this is probably the first reconcile.
+		reason = ReasonInitializing
+	}
+	switch kind {
+	case StatusKindReady:
+		return []Condition{
+			NewCondition(ConditionReady, true, reason, message),
+			NewCondition(ConditionProgressing, false, reason, ""),
+			NewCondition(ConditionDegraded, false, reason, ""),
+		}
+	case StatusKindDegraded:
+		return []Condition{
+			NewCondition(ConditionReady, false, reason, message),
+			NewCondition(ConditionProgressing, false, reason, ""),
+			NewCondition(ConditionDegraded, true, reason, message),
+		}
+	case StatusKindIdle:
+		return []Condition{
+			NewCondition(ConditionReady, false, reason, message),
+			NewCondition(ConditionProgressing, false, reason, message),
+			NewCondition(ConditionDegraded, false, reason, ""),
+		}
+	}
+	return []Condition{
+		NewCondition(ConditionReady, false, reason, message),
+		NewCondition(ConditionProgressing, true, reason, message),
+		NewCondition(ConditionDegraded, false, reason, ""),
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+var _ = Describe("summary conditions", func() {
+	conditionStatuses := func(conditions []components.Condition) []corev1.ConditionStatus {
+		statuses := []corev1.ConditionStatus{}
+		for _, conditionType := range []string{components.ConditionReady, components.ConditionProgressing, components.ConditionDegraded} {
+			statuses = append(statuses, components.FindCondition(conditions, conditionType).Status)
+		}
+		return statuses
+	}
+
+	t, f := corev1.ConditionTrue, corev1.ConditionFalse
+	cases := []struct {
+		name   string
+		obj    components.ConditionStatuser
+		status string
+		// Expected Ready, Progressing, and Degraded.
+		expected []corev1.ConditionStatus
+	}{
+		{"an unset status", &summonv1beta1.DjangoUser{}, "", []corev1.ConditionStatus{f, t, f}},
+		{"Ready", &summonv1beta1.DjangoUser{}, summonv1beta1.StatusReady, []corev1.ConditionStatus{t, f, f}},
+		{"Error", &summonv1beta1.DjangoUser{}, summonv1beta1.StatusError, []corev1.ConditionStatus{f, f, t}},
+		{"an unknown status", &summonv1beta1.DjangoUser{}, "Creating", []corev1.ConditionStatus{f, t, f}},
+		{"a platform Deploying", &summonv1beta1.SummonPlatform{}, summonv1beta1.StatusDeploying, []corev1.ConditionStatus{f, t, f}},
+		{"a platform RolledBack", &summonv1beta1.SummonPlatform{}, summonv1beta1.StatusRolledBack, []corev1.ConditionStatus{f, f, t}},
+		{"a platform WaitingForWindow", &summonv1beta1.SummonPlatform{}, summonv1beta1.StatusWaitingForWindow, []corev1.ConditionStatus{f, f, f}},
+		{"a platform Hibernating", &summonv1beta1.SummonPlatform{}, summonv1beta1.StatusHibernating, []corev1.ConditionStatus{f, f, f}},
+		{"a command Running", &summonv1beta1.SummonCommand{}, summonv1beta1.CommandStatusRunning, []corev1.ConditionStatus{f, t, f}},
+		{"a command AwaitingApproval", &summonv1beta1.SummonCommand{}, summonv1beta1.CommandStatusAwaitingApproval, []corev1.ConditionStatus{f, f, f}},
+		{"a command which Succeeded", &summonv1beta1.SummonCommand{}, summonv1beta1.CommandStatusSucceeded, []corev1.ConditionStatus{t, f, f}},
+		{"a command which Failed", &summonv1beta1.SummonCommand{}, summonv1beta1.CommandStatusFailed, []corev1.ConditionStatus{f, f, t}},
+	}
+
+	for _, c := range cases {
+		c := c
+		It("maps "+c.name, func() {
+			conditions := components.SummaryConditions(c.status, "message", components.StatusKindOf(c.obj, c.status))
+			Expect(conditionStatuses(conditions)).To(Equal(c.expected))
+		})
+	}
+
+	It("uses the status as the reason", func() {
+		conditions := components.SummaryConditions(summonv1beta1.CommandStatusFailed, "exit code 1", components.StatusKindDegraded)
+		degraded := components.FindCondition(conditions, components.ConditionDegraded)
+		Expect(degraded.Reason).To(Equal(summonv1beta1.CommandStatusFailed))
+		Expect(degraded.Message).To(Equal("exit code 1"))
+	})
+
+	It("reports an unset status as Initializing", func() {
+		conditions := components.SummaryConditions("", "", components.StatusKindProgressing)
+		Expect(components.FindCondition(conditions, components.ConditionProgressing).Reason).To(Equal(components.ReasonInitializing))
+	})
+})
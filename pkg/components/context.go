@@ -17,6 +17,7 @@ limitations under the License.
 package components
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -24,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -44,6 +46,14 @@ func (ctx *ComponentContext) GetTemplate(path string, extraData map[string]inter
 
 func (ctx *ComponentContext) CreateOrUpdate(path string, extraData map[string]interface{}, mutateFn func(runtime.Object, runtime.Object) error) (Result, controllerutil.OperationResult, error) {
 	target, err := ctx.GetTemplate(path, extraData)
+	if err == templates.ErrEmptyTemplate {
+		return ctx.pruneEmptyTemplate(path)
+	}
+	if err != nil {
+		return Result{}, controllerutil.OperationResultNone, err
+	}
+	setTemplateLabel(target, path)
+	lastApplied, err := renderedJSON(target, true)
 	if err != nil {
 		return Result{}, controllerutil.OperationResultNone, err
 	}
@@ -59,10 +69,14 @@ func (ctx *ComponentContext) CreateOrUpdate(path string, extraData map[string]in
 		if err != nil {
 			return err
 		}
-		// Sync the metadata fields.
+		// Sync the metadata fields, removing any the template no longer sets.
 		targetMeta := target.(metav1.ObjectMetaAccessor).GetObjectMeta().(*metav1.ObjectMeta)
 		existingMeta := existing.(metav1.ObjectMetaAccessor).GetObjectMeta().(*metav1.ObjectMeta)
-		return ReconcileMeta(targetMeta, existingMeta)
+		err = ReconcileMeta(targetMeta, existingMeta)
+		if err != nil {
+			return err
+		}
+		return pruneMeta(targetMeta, existingMeta, lastApplied)
 	})
 	if err != nil {
 		return Result{Requeue: true}, op, err
@@ -74,11 +88,14 @@ func (ctx *ComponentContext) CreateOrUpdate(path string, extraData map[string]in
 // Make a copy of a context with new templates. Used mostly for shared components.
 func (ctx *ComponentContext) WithTemplates(templates http.FileSystem) *ComponentContext {
 	return &ComponentContext{
-		Client:    ctx.Client,
-		templates: templates,
-		Context:   ctx.Context,
-		Top:       ctx.Top,
-		Scheme:    ctx.Scheme,
+		Client:     ctx.Client,
+		templates:  templates,
+		Context:    ctx.Context,
+		Top:        ctx.Top,
+		Scheme:     ctx.Scheme,
+		Recorder:   ctx.Recorder,
+		DryRun:     ctx.DryRun,
+		OwnedTypes: ctx.OwnedTypes,
 	}
 }
 
@@ -90,7 +107,36 @@ func NewTestContext(top runtime.Object, templates http.FileSystem) *ComponentCon
 		Client:    fake.NewFakeClient(top),
 		Scheme:    scheme.Scheme,
 		templates: templates,
+		Recorder:  record.NewFakeRecorder(100),
+	}
+}
+
+// Method for creating a context for a dry run. The client should not write to the cluster.
+func NewDryRunContext(top runtime.Object, templates http.FileSystem, c client.Client, s *runtime.Scheme) *ComponentContext {
+	return &ComponentContext{
+		Top:       top,
+		Client:    c,
+		Scheme:    s,
+		templates: templates,
+		Context:   context.Background(),
+		DryRun:    true,
+	}
+}
+
+// Emit a Kubernetes Event on the top object, visible via `kubectl describe`.
+func (ctx *ComponentContext) Event(eventtype, reason, message string) {
+	if ctx.Recorder == nil {
+		return
+	}
+	ctx.Recorder.Event(ctx.Top, eventtype, reason, message)
+}
+
+// Emit a Kubernetes Event on the top object with a formatted message.
+func (ctx *ComponentContext) Eventf(eventtype, reason, messageFmt string, args ...interface{}) {
+	if ctx.Recorder == nil {
+		return
 	}
+	ctx.Recorder.Eventf(ctx.Top, eventtype, reason, messageFmt, args...)
 }
 
 // ComponentContext implements inject.Client.
@@ -0,0 +1,42 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Run a list of components once against a context, outside of any controller. This is used for dry runs, so the
+// components run one at a time in the usual order and the resulting status is left on ctx.Top rather than saved.
+func DryRun(ctx *ComponentContext, components []Component) error {
+	dependencies, err := buildDependencies(components)
+	if err != nil {
+		return errors.Wrap(err, "unable to build component graph")
+	}
+	cr := &componentReconciler{
+		name:                 "dry-run",
+		components:           components,
+		dependencies:         dependencies,
+		statuses:             newStatusTracker("dry-run"),
+		ComponentConcurrency: 1,
+	}
+	if ctx.OwnedTypes == nil {
+		ctx.OwnedTypes = ownedTypes(components)
+	}
+	_, err = cr.reconcileComponents(ctx)
+	return err
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Expose some internals to the components_test package.
+var SummaryConditions = summaryConditions
+
+func StatusKindOf(obj ConditionStatuser, status string) StatusKind {
+	return statusKindOf(obj, status)
+}
+
+var BuildDependencies = buildDependencies
+var PlanWaves = planWaves
+
+// Run components through reconcileComponents without a manager, returning the merged result.
+func ReconcileComponents(ctx *ComponentContext, components []Component, concurrency int) (reconcile.Result, []Condition, error) {
+	dependencies, err := buildDependencies(components)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	cr := &componentReconciler{
+		name:                 "test",
+		components:           components,
+		dependencies:         dependencies,
+		statuses:             newStatusTracker("test"),
+		ComponentConcurrency: concurrency,
+	}
+	res, err := cr.reconcileComponents(ctx)
+	return res.result, res.conditions, err
+}
+
+// Metrics recorded by the reconciler.
+var ComponentReconcileDuration = componentReconcileDuration
+var ComponentErrors = componentErrors
+var ComponentRequeues = componentRequeues
+var ErrorHandlerErrors = errorHandlerErrors
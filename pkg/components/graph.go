@@ -0,0 +1,141 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Default number of components a single reconcile will run at the same time. This stays at 1 for a controller until
+// its components have been checked for dependencies they don't declare, then it can raise ComponentConcurrency.
+const defaultComponentConcurrency = 1
+
+// Work out the indexes of the components each component has to wait for. A component that doesn't implement
+// Dependent waits for everything registered before it, exactly like the old sequential behavior. A Dependent waits
+// for its declared dependencies plus any earlier non-Dependent component. Dependencies always point backwards in
+// the list, so the graph can't contain cycles.
+func buildDependencies(components []Component) ([][]int, error) {
+	deps := make([][]int, len(components))
+	for i, comp := range components {
+		dependent, ok := comp.(Dependent)
+		if !ok {
+			for j := 0; j < i; j++ {
+				deps[i] = append(deps[i], j)
+			}
+			continue
+		}
+
+		seen := map[int]bool{}
+		for j := 0; j < i; j++ {
+			if _, ok := components[j].(Dependent); !ok {
+				seen[j] = true
+			}
+		}
+		for _, dep := range dependent.Dependencies() {
+			depType := reflect.TypeOf(dep)
+			found := false
+			for j := 0; j < i; j++ {
+				if reflect.TypeOf(components[j]) == depType {
+					seen[j] = true
+					found = true
+				}
+			}
+			if !found {
+				return nil, errors.Errorf("component %s depends on %s which is not registered before it", ComponentName(comp), depType)
+			}
+		}
+		for j := range seen {
+			deps[i] = append(deps[i], j)
+		}
+		sort.Ints(deps[i])
+	}
+	return deps, nil
+}
+
+// Group the ready components (given as ascending indexes) into waves. Everything in a wave can run concurrently,
+// and each wave only depends on earlier ones. A dependency which isn't ready this time around doesn't get a wave of
+// its own, but anything it waits for still has to finish first, so ordering through it is kept.
+func planWaves(deps [][]int, ready []int) [][]int {
+	if len(ready) == 0 {
+		return [][]int{}
+	}
+	isReady := map[int]bool{}
+	for _, i := range ready {
+		isReady[i] = true
+	}
+	// The earliest wave each component could run in, worked out for every component up to the last ready one.
+	// Dependencies always point backwards, so a single pass in order is enough.
+	last := ready[len(ready)-1]
+	level := make([]int, last+1)
+	waves := [][]int{}
+	for i := 0; i <= last; i++ {
+		l := 0
+		for _, j := range deps[i] {
+			next := level[j]
+			if isReady[j] {
+				next++
+			}
+			if next > l {
+				l = next
+			}
+		}
+		level[i] = l
+		if !isReady[i] {
+			continue
+		}
+		for len(waves) <= l {
+			waves = append(waves, []int{})
+		}
+		waves[l] = append(waves[l], i)
+	}
+	return waves
+}
+
+// Waves groups components the way a reconcile with ComponentConcurrency above 1 would run them when all of them are
+// ready. Mostly useful for checking the dependencies of a controller's component list.
+func Waves(components []Component) ([][]Component, error) {
+	deps, err := buildDependencies(components)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]int, len(components))
+	for i := range components {
+		all[i] = i
+	}
+	waves := [][]Component{}
+	for _, wave := range planWaves(deps, all) {
+		comps := make([]Component, len(wave))
+		for k, i := range wave {
+			comps[k] = components[i]
+		}
+		waves = append(waves, comps)
+	}
+	return waves, nil
+}
+
+// One wave per ready component, in registration order. This is how the reconciler ran before the dependency
+// graph existed: every component sees the StatusModifiers of the ones before it, and nothing runs after an error.
+func sequentialWaves(ready []int) [][]int {
+	waves := make([][]int, len(ready))
+	for k, i := range ready {
+		waves[k] = []int{i}
+	}
+	return waves
+}
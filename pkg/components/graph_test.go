@@ -0,0 +1,224 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// A fake component which remembers the status message it saw and then appends its own name to it.
+type fakeComponent struct {
+	name     string
+	err      error
+	notReady bool
+	delay    time.Duration
+
+	ran bool
+	saw string
+}
+
+func (f *fakeComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{}
+}
+
+func (f *fakeComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return !f.notReady
+}
+
+func (f *fakeComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	time.Sleep(f.delay)
+	f.ran = true
+	f.saw = ctx.Top.(*summonv1beta1.DjangoUser).Status.Message
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.DjangoUser)
+		instance.Status.Message = strings.TrimSpace(instance.Status.Message + " " + f.name)
+		return nil
+	}}, f.err
+}
+
+// Something which doesn't declare its dependencies.
+type plainComponent struct{ fakeComponent }
+
+type otherPlainComponent struct{ fakeComponent }
+
+// Components which declare their dependencies, each its own type since dependencies are matched by type.
+type fakeDependent struct {
+	fakeComponent
+	deps []components.Component
+}
+
+func (f *fakeDependent) Dependencies() []components.Component {
+	return f.deps
+}
+
+type betaComponent struct{ fakeDependent }
+
+type gammaComponent struct{ fakeDependent }
+
+type deltaComponent struct{ fakeDependent }
+
+var _ = Describe("component graph", func() {
+	Describe("dependencies", func() {
+		cases := []struct {
+			name       string
+			components []components.Component
+			expected   [][]int
+		}{
+			{
+				"makes plain components wait for everything before them",
+				[]components.Component{&plainComponent{}, &betaComponent{}, &otherPlainComponent{}},
+				[][]int{nil, {0}, {0, 1}},
+			},
+			{
+				"makes dependents wait for their declared dependencies and earlier plain components",
+				[]components.Component{
+					&plainComponent{},
+					&betaComponent{},
+					&gammaComponent{fakeDependent{deps: []components.Component{&betaComponent{}}}},
+					&deltaComponent{fakeDependent{deps: []components.Component{&gammaComponent{}}}},
+				},
+				[][]int{nil, {0}, {0, 1}, {0, 2}},
+			},
+			{
+				"lets dependents skip earlier dependents they don't declare",
+				[]components.Component{&betaComponent{}, &gammaComponent{}, &deltaComponent{fakeDependent{deps: []components.Component{&betaComponent{}}}}},
+				[][]int{nil, nil, {0}},
+			},
+		}
+
+		for _, c := range cases {
+			c := c
+			It(c.name, func() {
+				deps, err := components.BuildDependencies(c.components)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deps).To(Equal(c.expected))
+			})
+		}
+
+		It("rejects a dependency which is not registered before it", func() {
+			_, err := components.BuildDependencies([]components.Component{
+				&gammaComponent{fakeDependent{deps: []components.Component{&deltaComponent{}}}},
+				&deltaComponent{},
+			})
+			Expect(err).To(MatchError(ContainSubstring("not registered before it")))
+		})
+	})
+
+	Describe("waves", func() {
+		// 0 is plain, 1 and 2 only need 0, 3 needs 1, and 4 needs 3.
+		deps := [][]int{nil, {0}, {0}, {0, 1}, {0, 3}}
+		cases := []struct {
+			name     string
+			ready    []int
+			expected [][]int
+		}{
+			{"runs everything when all are ready", []int{0, 1, 2, 3, 4}, [][]int{{0}, {1, 2}, {3}, {4}}},
+			{"moves components up when their dependency isn't ready", []int{1, 2, 3, 4}, [][]int{{1, 2}, {3}, {4}}},
+			{"keeps ordering through a dependency which isn't ready", []int{0, 1, 2, 4}, [][]int{{0}, {1, 2}, {4}}},
+			{"handles a single ready component", []int{4}, [][]int{{4}}},
+			{"handles nothing being ready", []int{}, [][]int{}},
+		}
+
+		for _, c := range cases {
+			c := c
+			It(c.name, func() {
+				Expect(components.PlanWaves(deps, c.ready)).To(Equal(c.expected))
+			})
+		}
+	})
+
+	Describe("reconciling", func() {
+		var ctx *components.ComponentContext
+		var plain *plainComponent
+		var beta *betaComponent
+		var gamma *gammaComponent
+		var comps []components.Component
+
+		BeforeEach(func() {
+			ctx = &components.ComponentContext{Top: &summonv1beta1.DjangoUser{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}}
+			plain = &plainComponent{fakeComponent{name: "plain"}}
+			beta = &betaComponent{fakeDependent{fakeComponent: fakeComponent{name: "beta"}}}
+			gamma = &gammaComponent{fakeDependent{fakeComponent: fakeComponent{name: "gamma"}}}
+			comps = []components.Component{plain, beta, gamma}
+		})
+
+		message := func() string {
+			return ctx.Top.(*summonv1beta1.DjangoUser).Status.Message
+		}
+
+		It("runs one at a time by default, like the sequential reconciler", func() {
+			beta.err = fmt.Errorf("beta failed")
+			_, _, err := components.ReconcileComponents(ctx, comps, 1)
+			Expect(err).To(MatchError("beta failed"))
+			Expect(beta.saw).To(Equal("plain"))
+			Expect(gamma.ran).To(BeFalse())
+			Expect(message()).To(Equal("plain beta"))
+		})
+
+		It("lets later components see the status from earlier ones in the same wave when sequential", func() {
+			_, _, err := components.ReconcileComponents(ctx, comps, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gamma.saw).To(Equal("plain beta"))
+		})
+
+		It("runs a wave concurrently without sharing status inside it", func() {
+			_, _, err := components.ReconcileComponents(ctx, comps, 4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(beta.saw).To(Equal("plain"))
+			Expect(gamma.saw).To(Equal("plain"))
+			Expect(message()).To(Equal("plain beta gamma"))
+		})
+
+		It("merges in registration order and returns the first error when a concurrent wave fails", func() {
+			// Make beta finish last so the merge order can't come from completion order.
+			beta.delay = 100 * time.Millisecond
+			beta.err = fmt.Errorf("beta failed")
+			gamma.err = fmt.Errorf("gamma failed")
+			_, _, err := components.ReconcileComponents(ctx, comps, 4)
+			Expect(err).To(MatchError("beta failed"))
+			Expect(gamma.ran).To(BeTrue())
+			Expect(message()).To(Equal("plain beta gamma"))
+		})
+
+		It("doesn't start the next wave after an error", func() {
+			delta := &deltaComponent{fakeDependent{fakeComponent: fakeComponent{name: "delta"}, deps: []components.Component{&betaComponent{}}}}
+			comps = append(comps, delta)
+			beta.err = fmt.Errorf("beta failed")
+			_, _, err := components.ReconcileComponents(ctx, comps, 4)
+			Expect(err).To(HaveOccurred())
+			Expect(delta.ran).To(BeFalse())
+		})
+
+		It("skips components which aren't reconcilable", func() {
+			beta.notReady = true
+			_, _, err := components.ReconcileComponents(ctx, comps, 4)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(beta.ran).To(BeFalse())
+			Expect(message()).To(Equal("plain gamma"))
+		})
+	})
+})
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ridecell_operator_reconcile_duration_seconds",
+		Help:    "Time taken by a full reconcile of a top-level object, including the status update.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"controller"})
+
+	componentReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ridecell_operator_component_reconcile_duration_seconds",
+		Help:    "Time taken by a single component's Reconcile.",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"controller", "component"})
+
+	componentErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ridecell_operator_component_errors_total",
+		Help: "Number of errors returned by a component's Reconcile.",
+	}, []string{"controller", "component"})
+
+	componentRequeues = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ridecell_operator_component_requeues_total",
+		Help: "Number of reconciles where a component asked to be requeued.",
+	}, []string{"controller", "component"})
+
+	errorHandlerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ridecell_operator_error_handler_errors_total",
+		Help: "Number of errors returned by a component's ReconcileError.",
+	}, []string{"controller", "component"})
+
+	statusUpdateConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ridecell_operator_status_update_conflicts_total",
+		Help: "Number of failed status updates which had to be retried against a fresh copy of the object.",
+	}, []string{"controller"})
+
+	objectsByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ridecell_operator_objects",
+		Help: "Number of top-level objects currently in each status.",
+	}, []string{"controller", "status"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		componentReconcileDuration,
+		componentErrors,
+		componentRequeues,
+		errorHandlerErrors,
+		statusUpdateConflicts,
+		objectsByStatus,
+	)
+}
+
+// ComponentName builds a stable name for a component, used for metric labels and dry run output. Most components
+// are parameterized by a template, so include that when present to tell apart things like the various deployments
+// in a SummonPlatform.
+func ComponentName(comp Component) string {
+	val := reflect.ValueOf(comp)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+	name := strings.TrimSuffix(val.Type().Name(), "Component")
+	if val.Kind() == reflect.Struct {
+		templatePath := val.FieldByName("templatePath")
+		if templatePath.IsValid() && templatePath.Kind() == reflect.String && templatePath.String() != "" {
+			name = name + ":" + templatePath.String()
+		}
+	}
+	return name
+}
+
+// Tracks the last seen status of each top-level object so the objectsByStatus gauge can be kept up to date.
+type statusTracker struct {
+	controller string
+	mutex      sync.Mutex
+	statuses   map[types.NamespacedName]string
+}
+
+func newStatusTracker(controller string) *statusTracker {
+	return &statusTracker{controller: controller, statuses: map[types.NamespacedName]string{}}
+}
+
+// Record the current status for an object.
+func (t *statusTracker) set(name types.NamespacedName, status string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	previous, ok := t.statuses[name]
+	if ok && previous == status {
+		return
+	}
+	if ok {
+		objectsByStatus.WithLabelValues(t.controller, previous).Dec()
+	}
+	t.statuses[name] = status
+	objectsByStatus.WithLabelValues(t.controller, status).Inc()
+}
+
+// Forget about an object, generally because it was deleted.
+func (t *statusTracker) forget(name types.NamespacedName) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	previous, ok := t.statuses[name]
+	if !ok {
+		return
+	}
+	delete(t.statuses, name)
+	objectsByStatus.WithLabelValues(t.controller, previous).Dec()
+}
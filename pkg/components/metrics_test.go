@@ -0,0 +1,110 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// Component types only used here, so their metric labels don't pick up counts from other tests.
+type measuredComponent struct{ fakeComponent }
+
+type requeueingComponent struct{ fakeComponent }
+
+func (_ *requeueingComponent) Reconcile(_ *components.ComponentContext) (components.Result, error) {
+	return components.Result{Requeue: true}, nil
+}
+
+type failingComponent struct{ fakeComponent }
+
+// An error handler which fails itself.
+type brokenHandlerComponent struct{ fakeComponent }
+
+func (_ *brokenHandlerComponent) ReconcileError(_ *components.ComponentContext, _ error) (components.Result, error) {
+	return components.Result{}, fmt.Errorf("handler failed")
+}
+
+var _ = Describe("reconciler metrics", func() {
+	var ctx *components.ComponentContext
+
+	BeforeEach(func() {
+		ctx = &components.ComponentContext{Top: &summonv1beta1.DjangoUser{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}}
+	})
+
+	// Number of observations in a histogram, testutil.ToFloat64 only handles counters and gauges.
+	observations := func(labels ...string) uint64 {
+		metric := &dto.Metric{}
+		err := components.ComponentReconcileDuration.WithLabelValues(labels...).(prometheus.Metric).Write(metric)
+		Expect(err).ToNot(HaveOccurred())
+		return metric.GetHistogram().GetSampleCount()
+	}
+
+	counter := func(vec *prometheus.CounterVec, labels ...string) float64 {
+		return testutil.ToFloat64(vec.WithLabelValues(labels...))
+	}
+
+	It("records the duration, requeues and errors of each component", func() {
+		measuredRuns := observations("test", "measured")
+		requeueingRuns := observations("test", "requeueing")
+		failingRuns := observations("test", "failing")
+		requeues := counter(components.ComponentRequeues, "test", "requeueing")
+		measuredRequeues := counter(components.ComponentRequeues, "test", "measured")
+		failures := counter(components.ComponentErrors, "test", "failing")
+		measuredErrors := counter(components.ComponentErrors, "test", "measured")
+
+		comps := []components.Component{
+			&measuredComponent{fakeComponent{name: "measured"}},
+			&requeueingComponent{fakeComponent{name: "requeueing"}},
+			&failingComponent{fakeComponent{name: "failing", err: fmt.Errorf("failed")}},
+		}
+		_, _, err := components.ReconcileComponents(ctx, comps, 1)
+		Expect(err).To(MatchError("failed"))
+
+		Expect(observations("test", "measured")).To(Equal(measuredRuns + 1))
+		Expect(observations("test", "requeueing")).To(Equal(requeueingRuns + 1))
+		Expect(observations("test", "failing")).To(Equal(failingRuns + 1))
+		Expect(counter(components.ComponentRequeues, "test", "requeueing")).To(Equal(requeues + 1))
+		Expect(counter(components.ComponentRequeues, "test", "measured")).To(Equal(measuredRequeues))
+		Expect(counter(components.ComponentErrors, "test", "failing")).To(Equal(failures + 1))
+		Expect(counter(components.ComponentErrors, "test", "measured")).To(Equal(measuredErrors))
+	})
+
+	It("counts errors from error handlers against the handler", func() {
+		handlerErrors := counter(components.ErrorHandlerErrors, "test", "brokenHandler")
+		failingHandlerErrors := counter(components.ErrorHandlerErrors, "test", "failing")
+
+		comps := []components.Component{
+			&brokenHandlerComponent{fakeComponent{name: "brokenHandler"}},
+			&failingComponent{fakeComponent{name: "failing", err: fmt.Errorf("failed")}},
+		}
+		_, _, err := components.ReconcileComponents(ctx, comps, 1)
+		Expect(err).To(MatchError("failed"))
+
+		Expect(counter(components.ErrorHandlerErrors, "test", "brokenHandler")).To(Equal(handlerErrors + 1))
+		Expect(counter(components.ErrorHandlerErrors, "test", "failing")).To(Equal(failingHandlerErrors))
+	})
+})
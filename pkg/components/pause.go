@@ -0,0 +1,145 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotation with a comma-separated list of components to skip, like "migrations,backup". Entries are matched
+// case-insensitively against the component's type name (backup), its template path with or without the
+// .yml.tpl suffix (migrations, web/deployment), the template's directory (dispatch), or the full component name.
+const SkipComponentsAnnotation = "ridecell.io/skip-components"
+
+// Annotation with an RFC3339 timestamp. Until then only PauseExempt components are reconciled, after which the
+// pause expires on its own without anyone having to remember to remove it.
+const PauseUntilAnnotation = "ridecell.io/pause-until"
+
+// Condition reporting whether any components are currently being skipped.
+const (
+	ConditionPaused = "Paused"
+	ReasonPaused    = "Paused"
+	ReasonSkipping  = "ComponentsSkipped"
+	ReasonNotPaused = "NotPaused"
+	ReasonBadPause  = "InvalidPauseAnnotation"
+)
+
+// The pause settings for a single reconcile, parsed from the top object's annotations.
+type pauseState struct {
+	until time.Time
+	skip  []string
+	err   error
+}
+
+func readPauseState(obj metav1.Object, now time.Time) *pauseState {
+	state := &pauseState{}
+	annotations := obj.GetAnnotations()
+	for _, name := range strings.Split(annotations[SkipComponentsAnnotation], ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			state.skip = append(state.skip, name)
+		}
+	}
+	rawUntil, ok := annotations[PauseUntilAnnotation]
+	if ok && rawUntil != "" {
+		until, err := time.Parse(time.RFC3339, rawUntil)
+		if err != nil {
+			state.err = errors.Wrapf(err, "unable to parse %s annotation", PauseUntilAnnotation)
+		} else if until.After(now) {
+			state.until = until
+		}
+	}
+	return state
+}
+
+// Is a time-bounded pause currently active.
+func (p *pauseState) paused() bool {
+	return !p.until.IsZero()
+}
+
+// Check if a component should be left alone this time around.
+func (p *pauseState) skips(comp Component) bool {
+	if p.paused() {
+		exempt, ok := comp.(PauseExempt)
+		if !ok || !exempt.RunWhilePaused() {
+			return true
+		}
+	}
+	if len(p.skip) == 0 {
+		return false
+	}
+	names := componentSkipNames(comp)
+	for _, skip := range p.skip {
+		for _, name := range names {
+			if skip == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// All the names a component can be referred to by in the skip annotation, lower cased.
+func componentSkipNames(comp Component) []string {
+	fullName := ComponentName(comp)
+	parts := strings.SplitN(fullName, ":", 2)
+	names := []string{strings.ToLower(fullName), strings.ToLower(parts[0])}
+	if len(parts) == 2 {
+		templatePath := strings.ToLower(parts[1])
+		names = append(names, templatePath, strings.TrimSuffix(templatePath, ".yml.tpl"))
+		if dir := path.Dir(templatePath); dir != "." {
+			names = append(names, dir)
+		}
+	}
+	return names
+}
+
+// How long until the pause expires, zero if not paused.
+func (p *pauseState) remaining(now time.Time) time.Duration {
+	if !p.paused() {
+		return 0
+	}
+	return p.until.Sub(now)
+}
+
+// Build the Paused condition for the top object.
+func (p *pauseState) condition() Condition {
+	if p.err != nil {
+		return NewCondition(ConditionPaused, false, ReasonBadPause, p.err.Error())
+	}
+	messages := []string{}
+	if p.paused() {
+		messages = append(messages, fmt.Sprintf("Paused until %s", p.until.Format(time.RFC3339)))
+	}
+	if len(p.skip) != 0 {
+		messages = append(messages, fmt.Sprintf("Skipping components: %s", strings.Join(p.skip, ", ")))
+	}
+	switch {
+	case p.paused():
+		return NewCondition(ConditionPaused, true, ReasonPaused, strings.Join(messages, ". "))
+	case len(p.skip) != 0:
+		return NewCondition(ConditionPaused, true, ReasonSkipping, strings.Join(messages, ". "))
+	default:
+		return NewCondition(ConditionPaused, false, ReasonNotPaused, "")
+	}
+}
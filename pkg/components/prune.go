@@ -0,0 +1,212 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Label recording which template an object was rendered from, so objects can be found again once the template
+// stops producing them.
+const TemplateLabel = "ridecell.io/template"
+
+// Annotation which, when set to "true" on an object, stops it from ever being pruned.
+const SkipPruneAnnotation = "ridecell.io/skip-prune"
+
+// Returned by CreateOrUpdate and Apply when the template rendered nothing and existing objects were deleted.
+const OperationResultPruned controllerutil.OperationResult = "pruned"
+
+var invalidLabelChars = regexp.MustCompile(`[^-_.a-zA-Z0-9]`)
+
+// Convert a template path like "dispatch/deployment.yml.tpl" into a valid label value.
+func templateLabelValue(path string) string {
+	value := strings.Replace(strings.TrimSuffix(path, ".tpl"), "/", ".", -1)
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		hash := sha1.Sum([]byte(path))
+		value = value[:54] + "-" + hex.EncodeToString(hash[:])[:8]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+// Tag a rendered object with the template it came from.
+func setTemplateLabel(obj runtime.Object, path string) {
+	objMeta := obj.(metav1.ObjectMetaAccessor).GetObjectMeta().(*metav1.ObjectMeta)
+	if objMeta.Labels == nil {
+		objMeta.Labels = map[string]string{}
+	}
+	objMeta.Labels[TemplateLabel] = templateLabelValue(path)
+}
+
+// Collect the types of objects a set of components own, de-duplicated. These are the same types the reconciler
+// watches via owner references, anything watched with a MapFunc isn't ours to delete.
+func ownedTypes(components []Component) []runtime.Object {
+	seen := map[reflect.Type]bool{}
+	types := []runtime.Object{}
+	for _, comp := range components {
+		if _, ok := comp.(MapFuncWatcher); ok {
+			continue
+		}
+		for _, obj := range comp.WatchTypes() {
+			objType := reflect.TypeOf(obj).Elem()
+			if seen[objType] {
+				continue
+			}
+			seen[objType] = true
+			types = append(types, obj)
+		}
+	}
+	return types
+}
+
+// Prune deletes objects previously rendered from a template that are still controlled by the top object. It's
+// used when a feature is switched off and the template no longer renders anything. If no types are given, every
+// type owned by the reconciler is checked. Objects annotated with ridecell.io/skip-prune: "true" are left alone.
+// Returns the number of objects deleted.
+func (ctx *ComponentContext) Prune(path string, types ...runtime.Object) (int, error) {
+	if len(types) == 0 {
+		types = ctx.OwnedTypes
+	}
+	topMeta := ctx.Top.(metav1.Object)
+	labelValue := templateLabelValue(path)
+	pruned := 0
+	for _, objType := range types {
+		gvk, err := apiutil.GVKForObject(objType, ctx.Scheme)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "prune: unable to find kind for %T", objType)
+		}
+		gvk.Kind = gvk.Kind + "List"
+		list, err := ctx.Scheme.New(gvk)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "prune: unable to create %s", gvk.Kind)
+		}
+		listOptions := &client.ListOptions{
+			Namespace:     topMeta.GetNamespace(),
+			LabelSelector: labels.SelectorFromSet(labels.Set{TemplateLabel: labelValue}),
+		}
+		err = ctx.List(ctx.Context, listOptions, list)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "prune: unable to list %s", gvk.Kind)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "prune: unable to read %s", gvk.Kind)
+		}
+		for _, item := range items {
+			itemMeta := item.(metav1.Object)
+			// Double check the label in case the client ignored the selector.
+			if itemMeta.GetLabels()[TemplateLabel] != labelValue {
+				continue
+			}
+			deleted, err := ctx.pruneItem(path, strings.TrimSuffix(gvk.Kind, "List"), item)
+			if err != nil {
+				return pruned, err
+			}
+			if deleted {
+				pruned++
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// PruneNamed is Prune for a single type, plus a fallback for objects created before the template label existed.
+// Those were never labeled if the template already rendered nothing when the operator was upgraded, so any object
+// with one of the given names which is controlled by the top object and has no template label is deleted as well.
+func (ctx *ComponentContext) PruneNamed(path string, objType runtime.Object, names ...string) (int, error) {
+	pruned, err := ctx.Prune(path, objType)
+	if err != nil {
+		return pruned, err
+	}
+	gvk, err := apiutil.GVKForObject(objType, ctx.Scheme)
+	if err != nil {
+		return pruned, errors.Wrapf(err, "prune: unable to find kind for %T", objType)
+	}
+	namespace := ctx.Top.(metav1.Object).GetNamespace()
+	for _, name := range names {
+		item := objType.DeepCopyObject()
+		err := ctx.Get(ctx.Context, types.NamespacedName{Name: name, Namespace: namespace}, item)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return pruned, errors.Wrapf(err, "prune: unable to get %s %s", gvk.Kind, name)
+		}
+		if _, ok := item.(metav1.Object).GetLabels()[TemplateLabel]; ok {
+			// Labeled objects belong to whichever template the label says, Prune already handled ours.
+			continue
+		}
+		deleted, err := ctx.pruneItem(path, gvk.Kind, item)
+		if err != nil {
+			return pruned, err
+		}
+		if deleted {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// Delete one object found for a disabled template, as long as the top object controls it and it isn't marked to
+// skip pruning. Returns true if it was deleted.
+func (ctx *ComponentContext) pruneItem(path, kind string, item runtime.Object) (bool, error) {
+	topMeta := ctx.Top.(metav1.Object)
+	itemMeta := item.(metav1.Object)
+	owner := metav1.GetControllerOf(itemMeta)
+	if owner == nil || owner.UID != topMeta.GetUID() {
+		return false, nil
+	}
+	if itemMeta.GetAnnotations()[SkipPruneAnnotation] == "true" {
+		glog.V(2).Infof("[%s/%s] prune: skipping %s %s due to %s", topMeta.GetNamespace(), topMeta.GetName(), kind, itemMeta.GetName(), SkipPruneAnnotation)
+		return false, nil
+	}
+	err := ctx.Delete(ctx.Context, item)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "prune: unable to delete %s", itemMeta.GetName())
+	}
+	ctx.Eventf(corev1.EventTypeNormal, "Pruned", "Deleted %s %s, template %s is disabled", kind, itemMeta.GetName(), path)
+	return true, nil
+}
+
+// Handle a template that rendered nothing by pruning whatever it created before.
+func (ctx *ComponentContext) pruneEmptyTemplate(path string) (Result, controllerutil.OperationResult, error) {
+	pruned, err := ctx.Prune(path)
+	if err != nil {
+		return Result{Requeue: true}, controllerutil.OperationResultNone, err
+	}
+	if pruned > 0 {
+		return Result{}, OperationResultPruned, nil
+	}
+	return Result{}, controllerutil.OperationResultNone, nil
+}
@@ -21,9 +21,12 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,12 +41,22 @@ import (
 )
 
 func NewReconciler(name string, mgr manager.Manager, top runtime.Object, templates http.FileSystem, components []Component) (*componentReconciler, error) {
+	dependencies, err := buildDependencies(components)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to build component graph")
+	}
+
 	cr := &componentReconciler{
-		name:       name,
-		top:        top,
-		templates:  templates,
-		components: components,
-		manager:    mgr,
+		name:                 name,
+		top:                  top,
+		templates:            templates,
+		components:           components,
+		dependencies:         dependencies,
+		manager:              mgr,
+		recorder:             mgr.GetRecorder(name),
+		statuses:             newStatusTracker(name),
+		ownedTypes:           ownedTypes(components),
+		ComponentConcurrency: defaultComponentConcurrency,
 	}
 
 	// Create the controller.
@@ -114,9 +127,11 @@ func (cr *componentReconciler) newContext(request reconcile.Request) (*Component
 	}
 
 	ctx := &ComponentContext{
-		templates: cr.templates,
-		Context:   reqCtx,
-		Top:       top,
+		templates:  cr.templates,
+		Context:    reqCtx,
+		Top:        top,
+		Recorder:   cr.recorder,
+		OwnedTypes: cr.ownedTypes,
 	}
 	err = cr.manager.SetFields(ctx)
 	if err != nil {
@@ -127,12 +142,17 @@ func (cr *componentReconciler) newContext(request reconcile.Request) (*Component
 
 func (cr *componentReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	glog.Infof("[%s] %s: Reconciling!", request.NamespacedName, cr.name)
+	start := time.Now()
+	defer func() {
+		reconcileDuration.WithLabelValues(cr.name).Observe(time.Since(start).Seconds())
+	}()
 
 	// Build a reconciler context to pass around.
 	ctx, err := cr.newContext(request)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			// Top object not found, likely already deleted.
+			cr.statuses.forget(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Some other fetch error, try again on the next tick.
@@ -153,14 +173,21 @@ func (cr *componentReconciler) Reconcile(request reconcile.Request) (reconcile.R
 	}
 
 	// Reconcile all the components.
-	// start := time.Now()
 	result, err := cr.reconcileComponents(ctx)
-	// fmt.Printf("$$$ Reconcile took %s\n", time.Since(start))
 	if err != nil {
-		// fmt.Printf("@@@@ Reconcile error %v\n", err)
 		ctx.Top.(Statuser).SetErrorStatus(err.Error())
 	}
 
+	// Update the standard status conditions, if this object supports them.
+	cr.updateConditions(ctx, result)
+
+	// Track how many objects are in each status and announce any transitions.
+	if statuser, ok := ctx.Top.(ConditionStatuser); ok {
+		status, message := statuser.GetStatusSummary()
+		cr.statuses.set(request.NamespacedName, status)
+		cr.recordStatusTransition(ctx, cleanTop.(ConditionStatuser), status, message)
+	}
+
 	// Check if an update to the status subresource is required.
 	if !reflect.DeepEqual(ctx.Top.(Statuser).GetStatus(), cleanTop.(Statuser).GetStatus()) {
 		// Update the top object status.
@@ -183,6 +210,8 @@ type reconcilerResults struct {
 	result reconcile.Result
 	// All the status modifier functions to replay in case of a write collision.
 	statusModifiers []StatusModifier
+	// Status conditions reported by components, in component order.
+	conditions []Condition
 	// The most recent error.
 	err error
 }
@@ -197,6 +226,7 @@ func (r *reconcilerResults) mergeResult(componentResult Result, component Compon
 	if componentResult.RequeueAfter != 0 && (r.result.RequeueAfter == 0 || r.result.RequeueAfter > componentResult.RequeueAfter) {
 		r.result.RequeueAfter = componentResult.RequeueAfter
 	}
+	r.conditions = append(r.conditions, componentResult.Conditions...)
 	if componentResult.StatusModifier != nil {
 		r.statusModifiers = append(r.statusModifiers, componentResult.StatusModifier)
 		statusErr := componentResult.StatusModifier(r.ctx.Top)
@@ -215,23 +245,49 @@ func (r *reconcilerResults) mergeResult(componentResult Result, component Compon
 
 func (cr *componentReconciler) reconcileComponents(ctx *ComponentContext) (*reconcilerResults, error) {
 	instance := ctx.Top.(metav1.Object)
+	now := time.Now()
+	pause := readPauseState(instance, now)
+	if pause.err != nil {
+		ctx.Eventf(corev1.EventTypeWarning, ReasonBadPause, "Ignoring pause: %s", pause.err)
+	}
 	ready := []Component{}
-	for _, component := range cr.components {
+	readyIndexes := []int{}
+	waitingConditions := []Condition{pause.condition()}
+	for i, component := range cr.components {
+		if pause.skips(component) {
+			glog.V(2).Infof("[%s/%s] reconcileComponents: Skipping %s due to pause annotations", instance.GetNamespace(), instance.GetName(), ComponentName(component))
+			continue
+		}
 		glog.V(10).Infof("[%s/%s] reconcileComponents: Checking if %#v is available to reconcile", instance.GetNamespace(), instance.GetName(), component)
 		if component.IsReconcilable(ctx) {
 			glog.V(9).Infof("[%s/%s] reconcileComponents: %#v is available to reconcile", instance.GetNamespace(), instance.GetName(), component)
 			ready = append(ready, component)
+			readyIndexes = append(readyIndexes, i)
+		} else if reporter, ok := component.(ConditionReporter); ok {
+			waitingConditions = append(waitingConditions, NewCondition(reporter.ConditionType(), false, ReasonWaiting, "Waiting for dependencies"))
 		}
 	}
-	res := &reconcilerResults{ctx: ctx}
-	for _, component := range ready {
-		// fmt.Printf("### Reconciling %#v\n", component)
-		// start := time.Now()
-		innerRes, err := component.Reconcile(ctx)
-		// fmt.Printf("### Done reconciling %#v, took %s\n", component, time.Since(start))
-		// Update result. This should be checked before the err!=nil because sometimes
+	res := &reconcilerResults{ctx: ctx, conditions: waitingConditions}
+	// Come back when the pause expires so everything resumes without waiting for some other change.
+	if remaining := pause.remaining(now); remaining > 0 {
+		res.result.RequeueAfter = remaining + time.Second
+	}
+	waves := sequentialWaves(readyIndexes)
+	if cr.ComponentConcurrency > 1 {
+		waves = planWaves(cr.dependencies, readyIndexes)
+	}
+	for _, wave := range waves {
+		outcomes := cr.reconcileWave(ctx, wave)
+		// Merge in registration order, regardless of which component finished first, so the StatusModifiers
+		// always apply in the same order. This should be checked before the err!=nil because sometimes
 		// we want to requeue immediately on error.
-		err = res.mergeResult(innerRes, component, err)
+		var err error
+		for k, i := range wave {
+			mergeErr := res.mergeResult(outcomes[k].result, cr.components[i], outcomes[k].err)
+			if mergeErr != nil && err == nil {
+				err = mergeErr
+			}
+		}
 		if err != nil {
 			for _, errComponent := range ready {
 				errReconciler, ok := errComponent.(ErrorHandler)
@@ -243,7 +299,8 @@ func (cr *componentReconciler) reconcileComponents(ctx *ComponentContext) (*reco
 				// Linting ignored "Error not handled", not an error that needs to be handled.
 				res.mergeResult(innerRes, errComponent, nil) //nolint
 				if errorErr != nil {
-					// Can't really do much more than log it, sigh. Some day this should set a prometheus metric.
+					// Can't really do much more than log it and count it, sigh.
+					errorHandlerErrors.WithLabelValues(cr.name, ComponentName(errComponent)).Inc()
 					glog.Errorf("[%s/%s] Error running error handler %#v: %s", instance.GetNamespace(), instance.GetName(), errComponent, errorErr)
 				}
 			}
@@ -253,6 +310,100 @@ func (cr *componentReconciler) reconcileComponents(ctx *ComponentContext) (*reco
 	return res, nil
 }
 
+// The result of reconciling a single component.
+type componentOutcome struct {
+	result Result
+	err    error
+}
+
+// Reconcile every component in a wave, running up to ComponentConcurrency of them at once. The StatusModifiers are
+// not applied until the whole wave is done, so nothing writes to ctx.Top while the wave is running.
+func (cr *componentReconciler) reconcileWave(ctx *ComponentContext, wave []int) []componentOutcome {
+	outcomes := make([]componentOutcome, len(wave))
+	if len(wave) == 1 || cr.ComponentConcurrency <= 1 {
+		for k, i := range wave {
+			outcomes[k].result, outcomes[k].err = cr.reconcileComponent(ctx, cr.components[i])
+		}
+		return outcomes
+	}
+
+	semaphore := make(chan struct{}, cr.ComponentConcurrency)
+	var wg sync.WaitGroup
+	for k, i := range wave {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(k int, component Component) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			outcomes[k].result, outcomes[k].err = cr.reconcileComponent(ctx, component)
+		}(k, cr.components[i])
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// Reconcile a single component, recording metrics and filling in its default condition.
+func (cr *componentReconciler) reconcileComponent(ctx *ComponentContext, component Component) (Result, error) {
+	name := ComponentName(component)
+	start := time.Now()
+	res, err := component.Reconcile(ctx)
+	componentReconcileDuration.WithLabelValues(cr.name, name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		componentErrors.WithLabelValues(cr.name, name).Inc()
+	}
+	if res.Requeue || res.RequeueAfter != 0 {
+		componentRequeues.WithLabelValues(cr.name, name).Inc()
+	}
+	reporter, ok := component.(ConditionReporter)
+	if ok && err != nil {
+		res.Conditions = append(res.Conditions, NewCondition(reporter.ConditionType(), false, ReasonReconcileError, err.Error()))
+	} else if ok && FindCondition(res.Conditions, reporter.ConditionType()) == nil {
+		res.Conditions = append(res.Conditions, NewCondition(reporter.ConditionType(), true, ReasonReconciled, ""))
+	}
+	return res, err
+}
+
+// Emit an Event when the overall status changes, so `kubectl describe` shows the object's history.
+func (cr *componentReconciler) recordStatusTransition(ctx *ComponentContext, cleanTop ConditionStatuser, status, message string) {
+	oldStatus, _ := cleanTop.GetStatusSummary()
+	if status == oldStatus || status == "" {
+		return
+	}
+	if statusKindOf(cleanTop, status) == StatusKindDegraded {
+		ctx.Eventf(corev1.EventTypeWarning, ReasonError, "Status changed from %q to %q: %s", oldStatus, status, message)
+		return
+	}
+	ctx.Eventf(corev1.EventTypeNormal, "StatusChanged", "Status changed from %q to %q", oldStatus, status)
+}
+
+// Fold the component-reported conditions and the overall status into the standard conditions list. This is
+// done via a StatusModifier so that it gets replayed against a fresh copy if the status update collides.
+func (cr *componentReconciler) updateConditions(ctx *ComponentContext, res *reconcilerResults) {
+	statuser, ok := ctx.Top.(ConditionStatuser)
+	if !ok {
+		return
+	}
+	status, message := statuser.GetStatusSummary()
+	conditions := append(summaryConditions(status, message, statusKindOf(statuser, status)), res.conditions...)
+	generation := ctx.Top.(metav1.Object).GetGeneration()
+	for i := range conditions {
+		conditions[i].ObservedGeneration = generation
+	}
+
+	modifier := func(obj runtime.Object) error {
+		statuser := obj.(ConditionStatuser)
+		existing := statuser.GetConditions()
+		for _, condition := range conditions {
+			existing = SetCondition(existing, condition)
+		}
+		statuser.SetConditions(existing)
+		return nil
+	}
+	// Can't fail, so no need to check the error.
+	modifier(ctx.Top) //nolint
+	res.statusModifiers = append(res.statusModifiers, modifier)
+}
+
 func (cr *componentReconciler) modifyStatus(ctx *ComponentContext, statusModifiers []StatusModifier) error {
 	// Try for the fast path of a single save using the subresource
 	err := ctx.Status().Update(ctx.Context, ctx.Top)
@@ -262,6 +413,7 @@ func (cr *componentReconciler) modifyStatus(ctx *ComponentContext, statusModifie
 	}
 
 	// Something went wrong so we have to do a re-get an apply of the modifiers.
+	statusUpdateConflicts.WithLabelValues(cr.name).Inc()
 	for tries := 0; tries < 5; tries++ {
 		err = cr.updateStatus(ctx, ctx.Top, func(instance runtime.Object) error {
 			for _, mod := range statusModifiers {
@@ -276,6 +428,7 @@ func (cr *componentReconciler) modifyStatus(ctx *ComponentContext, statusModifie
 			// Success!
 			return nil
 		}
+		statusUpdateConflicts.WithLabelValues(cr.name).Inc()
 		// Leave err set so we can wrap the final error below.
 	}
 
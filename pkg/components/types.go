@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -32,13 +33,20 @@ import (
 // // A componentReconciler is the data for a single reconciler. These are our
 // side of the controller.
 type componentReconciler struct {
-	name       string
-	top        runtime.Object
-	templates  http.FileSystem
-	components []Component
-	client     client.Client
-	manager    manager.Manager
-	Controller controller.Controller
+	name         string
+	top          runtime.Object
+	templates    http.FileSystem
+	components   []Component
+	dependencies [][]int
+	client       client.Client
+	manager      manager.Manager
+	recorder     record.EventRecorder
+	Controller   controller.Controller
+	statuses     *statusTracker
+	ownedTypes   []runtime.Object
+	// Maximum number of components to reconcile at the same time, 1 disables concurrency. Above 1, components in the
+	// same wave don't see each other's StatusModifiers and keep running if one of them fails.
+	ComponentConcurrency int
 }
 
 // A ComponentContext is the state for a single reconcile request to the controller.
@@ -48,6 +56,12 @@ type ComponentContext struct {
 	Context   context.Context // This should probably go away
 	Top       runtime.Object
 	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+	// Set when planning changes rather than applying them. Writes go to a recording client, and components
+	// should avoid talking to anything outside of Kubernetes.
+	DryRun bool
+	// Types of objects owned by the reconciler, checked when a template stops rendering anything.
+	OwnedTypes []runtime.Object
 }
 
 // A function which modifies component status.
@@ -61,6 +75,9 @@ type Result struct {
 	RequeueAfter time.Duration
 	// An optional anonymous function to change the object status.
 	StatusModifier StatusModifier
+	// Optional status conditions to record on the top object. A ConditionReporter's own condition defaults to True
+	// after a successful reconcile unless it is overridden here.
+	Conditions []Condition
 }
 
 // A component is a Promise Theory actor inside a controller.
@@ -80,6 +97,25 @@ type MapFuncWatcher interface {
 	WatchMap(handler.MapObject, client.Client) ([]reconcile.Request, error)
 }
 
+// An optional interface for Components which own a status condition on the top object, like PostgresReady.
+type ConditionReporter interface {
+	ConditionType() string
+}
+
+// An optional interface for Components which declare their dependencies explicitly, letting independent components
+// run concurrently. Dependencies are matched by type against components registered earlier in the list, and a
+// Dependent also still waits for every earlier component which isn't a Dependent. Because it may run alongside
+// other components, a Dependent must only change the top object via its StatusModifier.
+type Dependent interface {
+	Dependencies() []Component
+}
+
+// An optional interface for Components which should keep running while the top object is paused via the
+// ridecell.io/pause-until annotation, like secret rotation. They can still be skipped by name.
+type PauseExempt interface {
+	RunWhilePaused() bool
+}
+
 // Opaque type for some kind of status substruct.
 type Status interface{}
 
@@ -89,3 +125,11 @@ type Statuser interface {
 	SetStatus(Status)
 	SetErrorStatus(string)
 }
+
+// Optional interface for top-level objects which expose standard status conditions.
+type ConditionStatuser interface {
+	GetConditions() []Condition
+	SetConditions([]Condition)
+	// Returns the overall Status and Message values, used to derive the Ready, Progressing, and Degraded conditions.
+	GetStatusSummary() (string, string)
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 
 	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/kms"
@@ -36,21 +37,18 @@ import (
 
 type EncryptedSecretComponent struct {
 	kmsAPI kmsiface.KMSAPI
+	// Region kmsAPI was built for, empty when it was injected.
+	kmsRegion string
 }
 
 func (comp *EncryptedSecretComponent) InjectKMSAPI(kmsapi kmsiface.KMSAPI) {
 	comp.kmsAPI = kmsapi
+	comp.kmsRegion = ""
 }
 
 func NewEncryptedSecret() *EncryptedSecretComponent {
-	sess := session.Must(session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-		Config: aws.Config{
-			Region: aws.String("us-west-1"),
-		},
-	}))
-	kmsService := kms.New(sess)
-	return &EncryptedSecretComponent{kmsAPI: kmsService}
+	// The KMS client is built on first use, once the region profile can be loaded.
+	return &EncryptedSecretComponent{}
 }
 
 func (_ *EncryptedSecretComponent) WatchTypes() []runtime.Object {
@@ -75,6 +73,11 @@ func (comp *EncryptedSecretComponent) Reconcile(ctx *components.ComponentContext
 		Data: make(map[string][]byte),
 	}
 
+	kmsAPI, err := comp.kmsClient(ctx)
+	if err != nil {
+		return components.Result{}, err
+	}
+
 	for k, v := range instance.Data {
 		if v == "" {
 			return components.Result{}, errors.Errorf("encryptedsecret: secret[%s] does not have a value", k)
@@ -83,7 +86,7 @@ func (comp *EncryptedSecretComponent) Reconcile(ctx *components.ComponentContext
 		if err != nil {
 			return components.Result{}, errors.Wrapf(err, "encryptedsecret: failed to base64 decode secret")
 		}
-		decryptedValue, err := comp.kmsAPI.Decrypt(&kms.DecryptInput{
+		decryptedValue, err := kmsAPI.Decrypt(&kms.DecryptInput{
 			CiphertextBlob: decodedValue,
 			EncryptionContext: map[string]*string{
 				"RidecellOperator": aws.String("true"),
@@ -100,7 +103,7 @@ func (comp *EncryptedSecretComponent) Reconcile(ctx *components.ComponentContext
 		}
 	}
 
-	_, err := controllerutil.CreateOrUpdate(ctx.Context, ctx, newSecret.DeepCopy(), func(existingObj runtime.Object) error {
+	_, err = controllerutil.CreateOrUpdate(ctx.Context, ctx, newSecret.DeepCopy(), func(existingObj runtime.Object) error {
 		existing := existingObj.(*corev1.Secret)
 		// Sync important fields.
 		err := controllerutil.SetControllerReference(instance, existing, ctx.Scheme)
@@ -124,3 +127,25 @@ func (comp *EncryptedSecretComponent) Reconcile(ctx *components.ComponentContext
 		return nil
 	}}, nil
 }
+
+// Get a KMS client for the region the RegionProfile keeps its keys in.
+func (comp *EncryptedSecretComponent) kmsClient(ctx *components.ComponentContext) (kmsiface.KMSAPI, error) {
+	if comp.kmsAPI != nil && comp.kmsRegion == "" {
+		return comp.kmsAPI, nil
+	}
+	profile, err := utils.GetRegionProfile(ctx.Context, ctx, utils.RegionProfileName(""))
+	if err != nil {
+		return nil, errors.Wrapf(err, "encryptedsecret: unable to load region profile")
+	}
+	if comp.kmsAPI == nil || comp.kmsRegion != profile.KMSRegion {
+		sess := session.Must(session.NewSessionWithOptions(session.Options{
+			SharedConfigState: session.SharedConfigEnable,
+			Config: aws.Config{
+				Region: aws.String(profile.KMSRegion),
+			},
+		}))
+		comp.kmsAPI = kms.New(sess)
+		comp.kmsRegion = profile.KMSRegion
+	}
+	return comp.kmsAPI, nil
+}
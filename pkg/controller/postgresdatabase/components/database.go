@@ -17,8 +17,10 @@ limitations under the License.
 package components
 
 import (
+	"database/sql"
 	"fmt"
 
+	"github.com/golang/glog"
 	"github.com/lib/pq"
 	postgresv1 "github.com/zalando-incubator/postgres-operator/pkg/apis/acid.zalan.do/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -76,6 +78,13 @@ func (comp *databaseComponent) Reconcile(ctx *components.ComponentContext) (comp
 		}
 	}
 
+	if count == 0 && instance.Spec.RestoreFrom != nil {
+		count, err = comp.adoptRestoredDatabase(ctx, db)
+		if err != nil {
+			return components.Result{}, err
+		}
+	}
+
 	if count == 0 {
 		// Time to make the database.
 		_, err = db.Exec(fmt.Sprintf(`CREATE DATABASE %s WITH OWNER = %s`, pq.QuoteIdentifier(instance.Spec.DatabaseName), utils.QuoteLiteral(instance.Spec.Owner)))
@@ -84,6 +93,13 @@ func (comp *databaseComponent) Reconcile(ctx *components.ComponentContext) (comp
 		}
 	}
 
+	if instance.Spec.RestoreFrom != nil {
+		err = comp.reassignRestoredObjects(ctx, db)
+		if err != nil {
+			return components.Result{}, err
+		}
+	}
+
 	dbName := instance.Spec.DatabaseName
 	return components.Result{StatusModifier: func(obj runtime.Object) error {
 		instance := obj.(*dbv1beta1.PostgresDatabase)
@@ -97,3 +113,71 @@ func (comp *databaseComponent) Reconcile(ctx *components.ComponentContext) (comp
 		return nil
 	}}, nil
 }
+
+// A restored instance comes with the source's database, rename it to ours rather than creating an empty one.
+// Returns 1 if there is now a database, following the count query above.
+func (_ *databaseComponent) adoptRestoredDatabase(ctx *components.ComponentContext, db *sql.DB) (int, error) {
+	instance := ctx.Top.(*dbv1beta1.PostgresDatabase)
+	source := instance.Spec.RestoreFrom.DatabaseName
+	if source == "" || source == instance.Spec.DatabaseName {
+		return 0, nil
+	}
+
+	row := db.QueryRow(`SELECT COUNT(*) FROM pg_catalog.pg_database WHERE datname = $1`, source)
+	var count int
+	err := row.Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "database: error running restored db check query")
+	}
+	if count == 0 {
+		glog.Errorf("[%s/%s] database: restored database %s not found, creating an empty one\n", instance.Namespace, instance.Name, source)
+		return 0, nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`ALTER DATABASE %s RENAME TO %s`, pq.QuoteIdentifier(source), pq.QuoteIdentifier(instance.Spec.DatabaseName)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "database: error renaming restored database %s", source)
+	}
+	_, err = db.Exec(fmt.Sprintf(`ALTER DATABASE %s OWNER TO %s`, pq.QuoteIdentifier(instance.Spec.DatabaseName), pq.QuoteIdentifier(instance.Spec.Owner)))
+	if err != nil {
+		return 0, errors.Wrap(err, "database: error changing owner of restored database")
+	}
+	return 1, nil
+}
+
+// Hand everything the source owner had in the restored database over to ours. Runs every time, so a failure part
+// way through gets picked up again, and there is nothing left to move after the first run.
+func (_ *databaseComponent) reassignRestoredObjects(ctx *components.ComponentContext, db *sql.DB) error {
+	instance := ctx.Top.(*dbv1beta1.PostgresDatabase)
+	sourceOwner := instance.Spec.RestoreFrom.Owner
+	if sourceOwner == "" || sourceOwner == instance.Spec.Owner {
+		return nil
+	}
+
+	row := db.QueryRow(`SELECT COUNT(*) FROM pg_catalog.pg_roles WHERE rolname = $1`, sourceOwner)
+	var count int
+	err := row.Scan(&count)
+	if err != nil {
+		return errors.Wrap(err, "database: error checking for restored owner role")
+	}
+	if count == 0 {
+		return nil
+	}
+
+	// REASSIGN OWNED only covers the database it runs in, and the admin user needs both roles to do it on RDS.
+	_, err = db.Exec(fmt.Sprintf(`GRANT %s TO %s`, pq.QuoteIdentifier(sourceOwner), pq.QuoteIdentifier(instance.Status.AdminConnection.Username)))
+	if err != nil {
+		return errors.Wrap(err, "database: error granting restored owner role")
+	}
+	dbInfo := instance.Status.AdminConnection
+	dbInfo.Database = instance.Spec.DatabaseName
+	targetDb, err := postgres.Open(ctx, &dbInfo)
+	if err != nil {
+		return err
+	}
+	_, err = targetDb.Exec(fmt.Sprintf(`REASSIGN OWNED BY %s TO %s`, pq.QuoteIdentifier(sourceOwner), pq.QuoteIdentifier(instance.Spec.Owner)))
+	if err != nil {
+		return errors.Wrapf(err, "database: error reassigning objects owned by %s", sourceOwner)
+	}
+	return nil
+}
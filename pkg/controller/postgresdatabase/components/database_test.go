@@ -93,4 +93,46 @@ var _ = Describe("PostgresDatabase Database Component", func() {
 
 		Expect(instance.Status.Status).To(Equal(dbv1beta1.StatusCreating))
 	})
+	Describe("restoring", func() {
+		var targetMock sqlmock.Sqlmock
+		var targetDb *sql.DB
+
+		BeforeEach(func() {
+			var err error
+			targetDb, targetMock, err = sqlmock.New()
+			Expect(err).NotTo(HaveOccurred())
+			dbpool.Dbs.Store("postgres host=mydb port=5432 dbname=foo_dev user=myuser password='mypassword' sslmode=require", targetDb)
+			instance.Spec.RestoreFrom = &dbv1beta1.PostgresRestoreSpec{DatabaseName: "bar_prod", Owner: "bar"}
+		})
+
+		AfterEach(func() {
+			dbpool.Dbs.Delete("postgres host=mydb port=5432 dbname=foo_dev user=myuser password='mypassword' sslmode=require")
+			targetDb.Close()
+			Expect(targetMock.ExpectationsWereMet()).To(Succeed())
+		})
+
+		It("renames the restored database", func() {
+			dbMock.ExpectQuery(`SELECT COUNT`).WithArgs("foo_dev").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			dbMock.ExpectQuery(`SELECT pg_has_role`).WithArgs("myuser", "foo").WillReturnRows(sqlmock.NewRows([]string{"pg_has_role"}).AddRow(1))
+			dbMock.ExpectQuery(`SELECT COUNT`).WithArgs("bar_prod").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			dbMock.ExpectExec(`ALTER DATABASE "bar_prod" RENAME TO "foo_dev"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			dbMock.ExpectExec(`ALTER DATABASE "foo_dev" OWNER TO "foo"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			dbMock.ExpectQuery(`SELECT COUNT\(\*\) FROM pg_catalog.pg_roles`).WithArgs("bar").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			dbMock.ExpectExec(`GRANT "bar" TO "myuser"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			targetMock.ExpectExec(`REASSIGN OWNED BY "bar" TO "foo"`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(dbv1beta1.StatusCreating))
+		})
+
+		It("creates an empty database if the restored one is missing", func() {
+			instance.Spec.RestoreFrom.Owner = ""
+			dbMock.ExpectQuery(`SELECT COUNT`).WithArgs("foo_dev").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			dbMock.ExpectQuery(`SELECT pg_has_role`).WithArgs("myuser", "foo").WillReturnRows(sqlmock.NewRows([]string{"pg_has_role"}).AddRow(1))
+			dbMock.ExpectQuery(`SELECT COUNT`).WithArgs("bar_prod").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			dbMock.ExpectExec(`CREATE DATABASE "foo_dev" WITH OWNER = 'foo'`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			Expect(comp).To(ReconcileContext(ctx))
+		})
+	})
 })
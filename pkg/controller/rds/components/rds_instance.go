@@ -137,7 +137,12 @@ func (comp *rdsInstanceComponent) Reconcile(ctx *components.ComponentContext) (c
 		databaseNotExist = true
 	}
 
-	if databaseNotExist {
+	if databaseNotExist && instance.Spec.RestoreFrom != nil {
+		database, err = comp.restoreDBInstance(instance)
+		if err != nil {
+			return components.Result{}, err
+		}
+	} else if databaseNotExist {
 		createDBInstanceOutput, err := comp.rdsAPI.CreateDBInstance(&rds.CreateDBInstanceInput{
 			MasterUsername:             aws.String(databaseUsername),
 			DBInstanceIdentifier:       aws.String(instance.Spec.InstanceID),
@@ -155,16 +160,7 @@ func (comp *rdsInstanceComponent) Reconcile(ctx *components.ComponentContext) (c
 			VpcSecurityGroupIds:        []*string{aws.String(instance.Status.SecurityGroupID)},
 			DBSubnetGroupName:          aws.String(instance.Spec.SubnetGroupName),
 			StorageEncrypted:           aws.Bool(true),
-			Tags: []*rds.Tag{
-				&rds.Tag{
-					Key:   aws.String("Ridecell-Operator"),
-					Value: aws.String("true"),
-				},
-				&rds.Tag{
-					Key:   aws.String("tenant"),
-					Value: aws.String(instance.Name),
-				},
-			},
+			Tags:                       instanceTags(instance),
 		})
 		if err != nil {
 			return components.Result{}, errors.Wrapf(err, "rds: unable to create db instance")
@@ -230,6 +226,12 @@ func (comp *rdsInstanceComponent) Reconcile(ctx *components.ComponentContext) (c
 		databaseModifyInput.AllocatedStorage = aws.Int64(instance.Spec.AllocatedStorage)
 	}
 
+	// A restored instance comes up with the source's master password, switch it to ours before anything connects.
+	if instance.Spec.RestoreFrom != nil && instance.Status.Status == dbv1beta1.StatusCreating {
+		databaseModifyInput.MasterUserPassword = aws.String(string(password))
+		needsUpdate = true
+	}
+
 	// attempt a database query to test see if our password is correct.
 	// only attempt this when database is in ready state.
 	if instance.Status.Status == dbv1beta1.StatusReady {
@@ -308,6 +310,73 @@ func (comp *rdsInstanceComponent) Reconcile(ctx *components.ComponentContext) (c
 	}, RequeueAfter: time.Second * 30}, nil
 }
 
+// Create the instance from a snapshot, or from another instance's backups if there is no snapshot. Storage and
+// retention come from the source, the modify checks bring them back in line once it's available.
+func (comp *rdsInstanceComponent) restoreDBInstance(instance *dbv1beta1.RDSInstance) (*rds.DBInstance, error) {
+	restore := instance.Spec.RestoreFrom
+	if restore.SnapshotID != "" {
+		restoreOutput, err := comp.rdsAPI.RestoreDBInstanceFromDBSnapshot(&rds.RestoreDBInstanceFromDBSnapshotInput{
+			DBInstanceIdentifier: aws.String(instance.Spec.InstanceID),
+			DBSnapshotIdentifier: aws.String(restore.SnapshotID),
+			StorageType:          aws.String("gp2"),
+			DBInstanceClass:      aws.String(instance.Spec.InstanceClass),
+			MultiAZ:              instance.Spec.MultiAZ,
+			PubliclyAccessible:   aws.Bool(true),
+			DBParameterGroupName: aws.String(instance.Name),
+			VpcSecurityGroupIds:  []*string{aws.String(instance.Status.SecurityGroupID)},
+			DBSubnetGroupName:    aws.String(instance.Spec.SubnetGroupName),
+			Tags:                 instanceTags(instance),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "rds: unable to restore db instance from snapshot %s", restore.SnapshotID)
+		}
+		return restoreOutput.DBInstance, nil
+	}
+
+	if restore.SourceInstanceID == "" {
+		return nil, errors.New("rds: restoreFrom needs a snapshotID or sourceInstanceID")
+	}
+	restoreInput := &rds.RestoreDBInstanceToPointInTimeInput{
+		SourceDBInstanceIdentifier: aws.String(restore.SourceInstanceID),
+		TargetDBInstanceIdentifier: aws.String(instance.Spec.InstanceID),
+		StorageType:                aws.String("gp2"),
+		DBInstanceClass:            aws.String(instance.Spec.InstanceClass),
+		MultiAZ:                    instance.Spec.MultiAZ,
+		PubliclyAccessible:         aws.Bool(true),
+		DBParameterGroupName:       aws.String(instance.Name),
+		VpcSecurityGroupIds:        []*string{aws.String(instance.Status.SecurityGroupID)},
+		DBSubnetGroupName:          aws.String(instance.Spec.SubnetGroupName),
+		Tags:                       instanceTags(instance),
+	}
+	if restore.RestoreTime == "" {
+		restoreInput.UseLatestRestorableTime = aws.Bool(true)
+	} else {
+		restoreTime, err := time.Parse(time.RFC3339, restore.RestoreTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rds: invalid restoreTime %#v", restore.RestoreTime)
+		}
+		restoreInput.RestoreTime = aws.Time(restoreTime)
+	}
+	restoreOutput, err := comp.rdsAPI.RestoreDBInstanceToPointInTime(restoreInput)
+	if err != nil {
+		return nil, errors.Wrapf(err, "rds: unable to restore db instance from %s", restore.SourceInstanceID)
+	}
+	return restoreOutput.DBInstance, nil
+}
+
+func instanceTags(instance *dbv1beta1.RDSInstance) []*rds.Tag {
+	return []*rds.Tag{
+		&rds.Tag{
+			Key:   aws.String("Ridecell-Operator"),
+			Value: aws.String("true"),
+		},
+		&rds.Tag{
+			Key:   aws.String("tenant"),
+			Value: aws.String(instance.Name),
+		},
+	}
+}
+
 func (comp *rdsInstanceComponent) modifyRDSInstance(modifyInput *rds.ModifyDBInstanceInput) error {
 	_, err := comp.rdsAPI.ModifyDBInstance(modifyInput)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
 	. "github.com/onsi/ginkgo"
@@ -52,6 +53,7 @@ type mockRDSDBClient struct {
 	modifiedDB        bool
 	deletedDBInstance bool
 	addedTags         bool
+	restoredFrom      string
 	has7dayBackup     bool
 	dbStatus          string
 }
@@ -71,6 +73,7 @@ var _ = Describe("rds aws Component", func() {
 		comp.InjectRDSAPI(mockRDS)
 		instance.Spec.InstanceID = "test"
 		instance.Spec.SubnetGroupName = "test"
+		instance.Spec.RestoreFrom = nil
 		instance.Status.Connection = dbv1beta1.PostgresConnection{
 			Host:     "test-database",
 			Port:     int(5432),
@@ -194,6 +197,40 @@ var _ = Describe("rds aws Component", func() {
 		Expect(mockRDS.deletedDBInstance).To(BeFalse())
 	})
 
+	It("restores a database from a snapshot", func() {
+		instance.Spec.RestoreFrom = &dbv1beta1.RestoreSpec{SnapshotID: "source-snapshot"}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(mockRDS.createdDB).To(BeFalse())
+		Expect(mockRDS.restoredFrom).To(Equal("snapshot:source-snapshot"))
+		Expect(instance.Status.Status).To(Equal(dbv1beta1.StatusCreating))
+	})
+
+	It("restores a database to a point in time", func() {
+		instance.Spec.RestoreFrom = &dbv1beta1.RestoreSpec{SourceInstanceID: "source", RestoreTime: "2020-01-06T20:00:00Z"}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(mockRDS.createdDB).To(BeFalse())
+		Expect(mockRDS.restoredFrom).To(Equal("instance:source@2020-01-06T20:00:00Z"))
+	})
+
+	It("rejects a bad restore time", func() {
+		instance.Spec.RestoreFrom = &dbv1beta1.RestoreSpec{SourceInstanceID: "source", RestoreTime: "yesterday"}
+		Expect(comp).ToNot(ReconcileContext(ctx))
+		Expect(mockRDS.restoredFrom).To(BeEmpty())
+	})
+
+	It("resets the password on a restored database", func() {
+		instance.Spec.RestoreFrom = &dbv1beta1.RestoreSpec{SnapshotID: "source-snapshot"}
+		instance.Status.Status = dbv1beta1.StatusCreating
+		mockRDS.dbInstanceExists = true
+		mockRDS.hasTags = true
+		mockRDS.has7dayBackup = true
+		mockRDS.dbStatus = "available"
+
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(mockRDS.modifiedDB).To(BeTrue())
+		Expect(mockRDS.restoredFrom).To(BeEmpty())
+	})
+
 	It("has a database in creating state", func() {
 		mockRDS.dbInstanceExists = true
 		mockRDS.hasTags = true
@@ -275,6 +312,24 @@ func (m *mockRDSDBClient) CreateDBInstance(input *rds.CreateDBInstanceInput) (*r
 	return &rds.CreateDBInstanceOutput{DBInstance: dbInstance}, nil
 }
 
+func (m *mockRDSDBClient) RestoreDBInstanceFromDBSnapshot(input *rds.RestoreDBInstanceFromDBSnapshotInput) (*rds.RestoreDBInstanceFromDBSnapshotOutput, error) {
+	m.restoredFrom = "snapshot:" + aws.StringValue(input.DBSnapshotIdentifier)
+	m.hasTags = true
+	return &rds.RestoreDBInstanceFromDBSnapshotOutput{DBInstance: &rds.DBInstance{
+		MasterUsername:   aws.String("source-user"),
+		DBInstanceStatus: aws.String("creating"),
+	}}, nil
+}
+
+func (m *mockRDSDBClient) RestoreDBInstanceToPointInTime(input *rds.RestoreDBInstanceToPointInTimeInput) (*rds.RestoreDBInstanceToPointInTimeOutput, error) {
+	m.restoredFrom = fmt.Sprintf("instance:%s@%s", aws.StringValue(input.SourceDBInstanceIdentifier), aws.TimeValue(input.RestoreTime).Format(time.RFC3339))
+	m.hasTags = true
+	return &rds.RestoreDBInstanceToPointInTimeOutput{DBInstance: &rds.DBInstance{
+		MasterUsername:   aws.String("source-user"),
+		DBInstanceStatus: aws.String("creating"),
+	}}, nil
+}
+
 func (m *mockRDSDBClient) ModifyDBInstance(input *rds.ModifyDBInstanceInput) (*rds.ModifyDBInstanceOutput, error) {
 	if input.MasterUserPassword != nil && aws.StringValue(input.MasterUserPassword) != string(passwordSecret.Data["password"]) {
 		return nil, errors.New("mock_rds: received incorrect password in modify")
@@ -17,10 +17,12 @@ limitations under the License.
 package components
 
 import (
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	awsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/aws/v1beta1"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
 )
 
 type defaultsComponent struct {
@@ -46,7 +48,11 @@ func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (comp
 		instance.Spec.BucketName = instance.Name
 	}
 	if instance.Spec.Region == "" {
-		instance.Spec.Region = "us-west-2"
+		profile, err := utils.GetRegionProfile(ctx.Context, ctx, utils.RegionProfileName(""))
+		if err != nil {
+			return components.Result{}, errors.Wrap(err, "s3bucket: unable to load region profile")
+		}
+		instance.Spec.Region = profile.Region
 	}
 
 	return components.Result{}, nil
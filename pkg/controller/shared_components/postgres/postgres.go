@@ -113,8 +113,11 @@ func (comp *postgresComponent) Reconcile(ctx *components.ComponentContext) (comp
 		if err != nil {
 			return components.Result{}, errors.Wrapf(err, "postgres: error getting dbconfig %s/%s for PostgresDatabase %s", dbconfigRef.Namespace, dbconfigRef.Name, pqdb.Name)
 		}
-		// Do nothing in shared mode, DB is already provisioned.
-		if dbconfig.Spec.Postgres.Mode == "Shared" {
+		if pqdb.Spec.RestoreFrom != nil && dbconfig.Spec.Postgres.RDS == nil {
+			return components.Result{}, errors.Errorf("postgres: PostgresDatabase %s can only be restored with an RDS dbconfig", pqdb.Name)
+		}
+		// Do nothing in shared mode, DB is already provisioned. Restores need an instance of their own.
+		if dbconfig.Spec.Postgres.Mode == "Shared" && pqdb.Spec.RestoreFrom == nil {
 			return components.Result{StatusModifier: func(obj runtime.Object) error {
 				pqdb := obj.(*dbv1beta1.PostgresDatabase)
 				pqdb.Status.DatabaseClusterStatus = dbconfig.Status.Postgres.Status
@@ -210,6 +213,12 @@ func (comp *postgresComponent) reconcileRDS(ctx *components.ComponentContext, co
 	res, _, err := ctx.WithTemplates(Templates).CreateOrUpdate("rds.yml.tpl", nil, func(_goalObj, existingObj runtime.Object) error {
 		existing = existingObj.(*dbv1beta1.RDSInstance)
 		existing.Spec = *config.Spec.Postgres.RDS
+		if pqdb, ok := ctx.Top.(*dbv1beta1.PostgresDatabase); ok && pqdb.Spec.RestoreFrom != nil {
+			restore := pqdb.Spec.RestoreFrom.RestoreSpec
+			existing.Spec.RestoreFrom = &restore
+			// Never reuse an instance ID from a shared dbconfig, it defaults to the PostgresDatabase name instead.
+			existing.Spec.InstanceID = ""
+		}
 		if migrationOverrides != nil {
 			if migrationOverrides.RDSInstanceID != "" {
 				existing.Spec.InstanceID = migrationOverrides.RDSInstanceID
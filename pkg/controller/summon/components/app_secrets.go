@@ -121,6 +121,23 @@ func (_ *appSecretComponent) IsReconcilable(ctx *components.ComponentContext) bo
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *appSecretComponent) Dependencies() []components.Component {
+	return []components.Component{
+		&postgresComponent{},
+		&rabbitmqVhostComponent{},
+		&secretKeyComponent{},
+		&fernetRotateComponent{},
+		&iamUserComponent{},
+		&newMockCarServerTenantComponent{},
+	}
+}
+
+// RunWhilePaused implements components.PauseExempt, so rotated fernet keys still make it into the app secrets.
+func (_ *appSecretComponent) RunWhilePaused() bool {
+	return true
+}
+
 func (comp *appSecretComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
@@ -334,10 +351,15 @@ func (c *appSecretComponent) inputSecrets(instance *summonv1beta1.SummonPlatform
 }
 
 func (c *appSecretComponent) specSecrets(instance *summonv1beta1.SummonPlatform) []string {
-	if len(instance.Spec.Secrets) == 0 {
-		return []string{instance.Namespace, instance.Name}
+	secrets := instance.Spec.Secrets
+	if len(secrets) == 0 {
+		secrets = []string{instance.Namespace, instance.Name}
+	}
+	if instance.Status.Clone.Secret != "" {
+		// Secrets copied from a clone source go first so the instance's own secrets override them.
+		secrets = append([]string{instance.Status.Clone.Secret}, secrets...)
 	}
-	return instance.Spec.Secrets
+	return secrets
 }
 
 func (_ *appSecretComponent) fetchSecrets(ctx *components.ComponentContext, instance *summonv1beta1.SummonPlatform, secretNames []string, allowMissing bool) ([]*corev1.Secret, error) {
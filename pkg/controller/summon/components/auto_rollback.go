@@ -0,0 +1,134 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// The version to go back to if the one being deployed fails, the same stable version a canary falls back on.
+// Empty if the instance was never deployed, or the version is already the last one deployed and so has nothing
+// older to fall back on.
+func rollbackTarget(instance *summonv1beta1.SummonPlatform) string {
+	stable := instance.Status.Rollout.StableVersion
+	if stable == instance.Spec.Version {
+		return ""
+	}
+	return stable
+}
+
+// Keep a rolled back version from running backups and migrations again. It stays rolled back until a different
+// version is set.
+func holdForRollback(instance *summonv1beta1.SummonPlatform) *components.Result {
+	if instance.Status.AutoRollback.FailedVersion == "" || instance.Status.AutoRollback.FailedVersion != instance.Spec.Version {
+		return nil
+	}
+	return &components.Result{StatusModifier: setStatus(summonv1beta1.StatusRolledBack)}
+}
+
+// Roll the Deployments back to the last good version if automatic rollback is on and there is one. Returns nil
+// if nothing was rolled back, in which case the caller should handle the failure itself.
+func autoRollback(ctx *components.ComponentContext, reason string) (*components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	revertTo := rollbackTarget(instance)
+	if !instance.Spec.AutoRollback.Enabled || revertTo == "" {
+		return nil, nil
+	}
+	failed := instance.Spec.Version
+
+	snapshotID := ""
+	snapshotReady := false
+	if instance.Spec.AutoRollback.PreserveSnapshot || instance.Spec.AutoRollback.RestoreSnapshot {
+		var err error
+		snapshotID, snapshotReady, err = preserveBackupSnapshot(ctx, failed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	message := fmt.Sprintf("Version %s %s, rolled back to %s", failed, reason, revertTo)
+	restore := instance.Status.AutoRollback.Restore
+	if instance.Spec.AutoRollback.RestoreSnapshot && snapshotReady {
+		// The postgres component takes it from here.
+		restore = summonv1beta1.SnapshotRestoreStatus{
+			Phase:      summonv1beta1.RestorePhaseDeletingDatabase,
+			SnapshotID: snapshotID,
+			Database:   instance.Status.PostgresConnection.Database,
+			Owner:      instance.Status.PostgresConnection.Username,
+		}
+		message = fmt.Sprintf("%s. Restoring the database from snapshot %s from before its migrations", message, snapshotID)
+	} else if snapshotID != "" {
+		message = fmt.Sprintf("%s. Snapshot %s from before its migrations was kept for a restore", message, snapshotID)
+	}
+	glog.Errorf("[%s/%s] auto_rollback: %s\n", instance.Namespace, instance.Name, message)
+	ctx.Event(corev1.EventTypeWarning, "RolledBack", message)
+	return &components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Status = summonv1beta1.StatusRolledBack
+		instance.Status.Message = message
+		instance.Status.AutoRollback.FailedVersion = failed
+		instance.Status.AutoRollback.RevertedTo = revertTo
+		instance.Status.AutoRollback.SnapshotID = snapshotID
+		instance.Status.AutoRollback.Restore = restore
+		if restore.Phase == summonv1beta1.RestorePhaseDeletingDatabase {
+			// The restored database is back at the schema from before the failed version's migrations.
+			instance.Status.MigrateVersion = revertTo
+		}
+		instance.Status.AutoRollback.RolloutVersion = ""
+		instance.Status.AutoRollback.RolloutStartTime = ""
+		return nil
+	}}, nil
+}
+
+// Clear the TTL on the RDSSnapshot backupComponent took before a version's migrations so it doesn't expire.
+// Returns the snapshot ID, or an empty string if no snapshot was taken for that version, and whether the snapshot
+// is ready to restore.
+func preserveBackupSnapshot(ctx *components.ComponentContext, version string) (string, bool, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	// Matches the name in db/rdssnapshot.yml.tpl.
+	name := fmt.Sprintf("%s-%s", instance.Name, strings.ToLower(strings.Replace(version, "_", "-", -1)))
+	snapshot := &dbv1beta1.RDSSnapshot{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: name, Namespace: instance.Namespace}, snapshot)
+	if kerrors.IsNotFound(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, errors.Wrapf(err, "auto_rollback: unable to get rds snapshot %s", name)
+	}
+	if snapshot.Spec.TTL.Duration != 0 {
+		snapshot.Spec.TTL.Duration = 0
+		err = ctx.Update(ctx.Context, snapshot)
+		if err != nil {
+			return "", false, errors.Wrapf(err, "auto_rollback: unable to clear ttl on rds snapshot %s", name)
+		}
+	}
+	if snapshot.Status.SnapshotID != "" {
+		return snapshot.Status.SnapshotID, snapshot.Status.Status == dbv1beta1.StatusReady, nil
+	}
+	return snapshot.Name, false, nil
+}
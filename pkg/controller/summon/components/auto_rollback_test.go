@@ -0,0 +1,264 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform automatic rollback", func() {
+	BeforeEach(func() {
+		os.Setenv("AWS_ACCESS_KEY_ID", "garbage")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "garbage")
+		instance.Spec.AutoRollback = summonv1beta1.AutoRollbackSpec{
+			Enabled:         true,
+			RolloutDeadline: metav1.Duration{Duration: 15 * time.Minute},
+		}
+		instance.Status.Rollout.StableVersion = "1.2.2"
+	})
+
+	Context("with a failed migration job", func() {
+		var snapshot *dbv1beta1.RDSSnapshot
+
+		BeforeEach(func() {
+			instance.Status.BackupVersion = "1.2.3"
+			instance.Status.MigrateVersion = "1.2.2"
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-dev-migrations",
+					Namespace: "summon-dev",
+					Labels:    map[string]string{"app.kubernetes.io/version": "1.2.3"},
+				},
+				Status: batchv1.JobStatus{Failed: 1},
+			}
+			snapshot = &dbv1beta1.RDSSnapshot{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-1.2.3", Namespace: "summon-dev"},
+				Spec:       dbv1beta1.RDSSnapshotSpec{RDSInstanceID: "foo-dev", TTL: metav1.Duration{Duration: 72 * time.Hour}},
+				Status:     dbv1beta1.RDSSnapshotStatus{SnapshotID: "foo-dev-1-2-3-snap"},
+			}
+			ctx.Client = fake.NewFakeClient(job, snapshot)
+		})
+
+		It("rolls back to the last deployed version", func() {
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+			Expect(instance.Status.Message).To(Equal("Version 1.2.3 failed migrations, rolled back to 1.2.2"))
+			Expect(instance.Status.AutoRollback.FailedVersion).To(Equal("1.2.3"))
+			Expect(instance.Status.AutoRollback.RevertedTo).To(Equal("1.2.2"))
+			Expect(instance.Status.MigrateVersion).To(Equal("1.2.2"))
+			recorder := ctx.Recorder.(*record.FakeRecorder)
+			Expect(recorder.Events).To(Receive(ContainSubstring("MigrationFailed")))
+			Expect(recorder.Events).To(Receive(ContainSubstring("RolledBack")))
+			// The job is still left for debugging.
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, &batchv1.Job{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("keeps the pre-migration snapshot", func() {
+			instance.Spec.AutoRollback.PreserveSnapshot = true
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.AutoRollback.SnapshotID).To(Equal("foo-dev-1-2-3-snap"))
+			Expect(instance.Status.Message).To(ContainSubstring("Snapshot foo-dev-1-2-3-snap"))
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-1.2.3", Namespace: "summon-dev"}, snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.Spec.TTL.Duration).To(BeZero())
+		})
+
+		It("starts restoring the pre-migration snapshot", func() {
+			instance.Spec.AutoRollback.RestoreSnapshot = true
+			instance.Status.PostgresConnection = dbv1beta1.PostgresConnection{Database: "foo_dev", Username: "foo_dev"}
+			snapshot.Status.Status = dbv1beta1.StatusReady
+			ctx.Client = fake.NewFakeClient(&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-dev-migrations",
+					Namespace: "summon-dev",
+					Labels:    map[string]string{"app.kubernetes.io/version": "1.2.3"},
+				},
+				Status: batchv1.JobStatus{Failed: 1},
+			}, snapshot)
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+			Expect(instance.Status.Message).To(ContainSubstring("Restoring the database from snapshot foo-dev-1-2-3-snap"))
+			Expect(instance.Status.AutoRollback.Restore).To(Equal(summonv1beta1.SnapshotRestoreStatus{
+				Phase:      summonv1beta1.RestorePhaseDeletingDatabase,
+				SnapshotID: "foo-dev-1-2-3-snap",
+				Database:   "foo_dev",
+				Owner:      "foo_dev",
+			}))
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-1.2.3", Namespace: "summon-dev"}, snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.Spec.TTL.Duration).To(BeZero())
+		})
+
+		It("doesn't restore a snapshot which isn't ready", func() {
+			instance.Spec.AutoRollback.RestoreSnapshot = true
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+			Expect(instance.Status.AutoRollback.SnapshotID).To(Equal("foo-dev-1-2-3-snap"))
+			Expect(instance.Status.AutoRollback.Restore.Phase).To(BeEmpty())
+		})
+
+		It("errors without a version to fall back on", func() {
+			instance.Status.Rollout.StableVersion = ""
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).NotTo(ReconcileContext(ctx))
+			Expect(instance.Status.AutoRollback.FailedVersion).To(Equal(""))
+		})
+
+		It("errors when disabled", func() {
+			instance.Spec.AutoRollback.Enabled = false
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).NotTo(ReconcileContext(ctx))
+			Expect(instance.Status.AutoRollback.FailedVersion).To(Equal(""))
+		})
+	})
+
+	Context("with a rolled back version", func() {
+		BeforeEach(func() {
+			instance.Status.Status = summonv1beta1.StatusRolledBack
+			instance.Status.BackupVersion = "1.2.3"
+			instance.Status.MigrateVersion = "1.2.3"
+			instance.Status.AutoRollback.FailedVersion = "1.2.3"
+			instance.Status.AutoRollback.RevertedTo = "1.2.2"
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-config", Namespace: instance.Namespace},
+				Data:       map[string]string{"summon-platform.yml": "{}\n"},
+			}
+			appSecrets := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev.app-secrets", Namespace: instance.Namespace},
+				Data:       map[string][]byte{"filler": []byte("test")},
+			}
+			ctx.Client = fake.NewFakeClient(configMap, appSecrets)
+		})
+
+		It("doesn't migrate again", func() {
+			instance.Status.MigrateVersion = "1.2.2"
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, &batchv1.Job{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("doesn't go back to deploying once migrated", func() {
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+		})
+
+		It("deploys the version it rolled back to", func() {
+			Expect(summoncomponents.NewDeployment("web/deployment.yml.tpl")).To(ReconcileContext(ctx))
+			deployment := &appsv1.Deployment{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("us.gcr.io/ridecell-1/summon:1.2.2"))
+		})
+
+		It("carries on with a new version", func() {
+			instance.Spec.Version = "1.2.4"
+			instance.Status.BackupVersion = "1.2.4"
+			Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusMigrating))
+		})
+	})
+
+	Context("with a rollout", func() {
+		var webDeployment *appsv1.Deployment
+
+		BeforeEach(func() {
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			instance.Status.MigrateVersion = "1.2.3"
+			webDeployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-web", Namespace: "summon-dev"},
+				Spec:       appsv1.DeploymentSpec{Replicas: intp(2)},
+			}
+			ctx.Client = fake.NewFakeClient(webDeployment)
+		})
+
+		It("starts the clock for a new version", func() {
+			Expect(summoncomponents.NewStatus()).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+			Expect(instance.Status.AutoRollback.RolloutVersion).To(Equal("1.2.3"))
+			started, err := time.Parse(time.UnixDate, instance.Status.AutoRollback.RolloutStartTime)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(started).To(BeTemporally("~", time.Now(), 2*time.Second))
+		})
+
+		It("waits until the deadline", func() {
+			instance.Status.AutoRollback.RolloutVersion = "1.2.3"
+			instance.Status.AutoRollback.RolloutStartTime = time.Now().Add(-5 * time.Minute).Format(time.UnixDate)
+			Expect(summoncomponents.NewStatus()).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		})
+
+		It("rolls back after the deadline", func() {
+			instance.Status.AutoRollback.RolloutVersion = "1.2.3"
+			instance.Status.AutoRollback.RolloutStartTime = time.Now().Add(-20 * time.Minute).Format(time.UnixDate)
+			Expect(summoncomponents.NewStatus()).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+			Expect(instance.Status.Message).To(Equal("Version 1.2.3 did not become available within 15m0s, rolled back to 1.2.2"))
+			Expect(instance.Status.AutoRollback.RevertedTo).To(Equal("1.2.2"))
+			Expect(instance.Status.AutoRollback.RolloutVersion).To(Equal(""))
+		})
+
+		It("doesn't roll back a version which was already deployed", func() {
+			instance.Status.Rollout.StableVersion = "1.2.3"
+			instance.Status.AutoRollback.RolloutVersion = "1.2.3"
+			instance.Status.AutoRollback.RolloutStartTime = time.Now().Add(-20 * time.Minute).Format(time.UnixDate)
+			Expect(summoncomponents.NewStatus()).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		})
+	})
+
+	It("makes a version which became ready the one to fall back on", func() {
+		instance.Status.AutoRollback.FailedVersion = "1.2.4"
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		objects := []*appsv1.Deployment{}
+		for _, name := range []string{"web", "daphne", "celeryd", "channelworker", "static", "redis"} {
+			objects = append(objects, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-" + name, Namespace: "summon-dev"},
+				Spec:       appsv1.DeploymentSpec{Replicas: intp(1)},
+				Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+			})
+		}
+		celerybeat := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-celerybeat", Namespace: "summon-dev"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: intp(1)},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		ctx.Client = fake.NewFakeClient(objects[0], objects[1], objects[2], objects[3], objects[4], objects[5], celerybeat)
+
+		Expect(summoncomponents.NewStatus()).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+		Expect(instance.Status.Rollout.StableVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.AutoRollback.FailedVersion).To(Equal(""))
+	})
+})
@@ -61,6 +61,10 @@ func (_ *AutoDeployComponent) IsReconcilable(ctx *components.ComponentContext) b
 
 func (comp *AutoDeployComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if ctx.DryRun {
+		// Don't query GCR during a dry run, just plan against the current version.
+		return components.Result{}, nil
+	}
 	branchRegex, err := gcr.SanitizeBranchName(instance.Spec.AutoDeploy)
 
 	if err != nil {
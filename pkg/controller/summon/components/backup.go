@@ -21,8 +21,10 @@ import (
 
 	"github.com/Ridecell/ridecell-operator/pkg/components"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
@@ -54,9 +56,20 @@ func (_ *backupComponent) IsReconcilable(ctx *components.ComponentContext) bool
 func (comp *backupComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
+	hold, err := holdForDeployWindow(instance, time.Now())
+	if err != nil {
+		return components.Result{}, err
+	}
+	if hold != nil {
+		return *hold, nil
+	}
+	if hold := holdForRollback(instance); hold != nil {
+		return *hold, nil
+	}
+
 	// Grab PostgresDatabase so we can locate relevant dbconfig
 	fetchPostgresDB := &dbv1beta1.PostgresDatabase{}
-	err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, fetchPostgresDB)
+	err = ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, fetchPostgresDB)
 	if err != nil {
 		return components.Result{}, errors.Wrap(err, "backup: failed to get postgresdatabase object")
 	}
@@ -77,7 +90,7 @@ func (comp *backupComponent) Reconcile(ctx *components.ComponentContext) (compon
 	extra["rdsInstanceName"] = fetchPostgresDB.Status.RDSInstanceID
 
 	var existing *dbv1beta1.RDSSnapshot
-	_, _, err = ctx.CreateOrUpdate(templatePath, extra, func(goalObj, existingObj runtime.Object) error {
+	_, op, err := ctx.CreateOrUpdate(templatePath, extra, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*dbv1beta1.RDSSnapshot)
 		existing = existingObj.(*dbv1beta1.RDSSnapshot)
 		// Copy the Spec over.
@@ -87,6 +100,9 @@ func (comp *backupComponent) Reconcile(ctx *components.ComponentContext) (compon
 	if err != nil {
 		return components.Result{}, errors.Wrap(err, "backup: failed to create or update rds snapshot")
 	}
+	if op == controllerutil.OperationResultCreated {
+		ctx.Eventf(corev1.EventTypeNormal, "BackupCreated", "Created RDSSnapshot %s before deploying version %s", existing.Name, instance.Spec.Version)
+	}
 
 	if !*instance.Spec.Backup.WaitUntilReady {
 		return components.Result{StatusModifier: func(obj runtime.Object) error {
@@ -98,6 +114,7 @@ func (comp *backupComponent) Reconcile(ctx *components.ComponentContext) (compon
 	}
 
 	if existing.Status.Status == dbv1beta1.StatusError {
+		ctx.Eventf(corev1.EventTypeWarning, "BackupFailed", "RDSSnapshot %s failed: %s", existing.Name, existing.Status.Message)
 		return components.Result{}, errors.Wrapf(err, "backup: rdssnapshot %s is in an error state", existing.Name)
 	}
 
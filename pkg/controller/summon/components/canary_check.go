@@ -0,0 +1,141 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// CanaryCheck judges a running canary Deployment. It returns false and a reason when the canary should be rolled
+// back, otherwise true and a short progress message. done is set on the last check before the canary is promoted.
+// Errors are treated as transient and retried rather than failing the canary.
+type CanaryCheck func(ctx *components.ComponentContext, canary *appsv1.Deployment, done bool) (bool, string, error)
+
+var canaryChecks = map[string]CanaryCheck{
+	"readiness": readinessCanaryCheck,
+	"errorRate": errorRateCanaryCheck,
+}
+
+// RegisterCanaryCheck makes a check available to spec.rollout.canary.checks under the given name.
+func RegisterCanaryCheck(name string, check CanaryCheck) {
+	canaryChecks[name] = check
+}
+
+func canaryCheckNames() []string {
+	names := []string{}
+	for name := range canaryChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pods get the whole canary duration to come up, but all of them have to be available by the end.
+func readinessCanaryCheck(_ *components.ComponentContext, canary *appsv1.Deployment, done bool) (bool, string, error) {
+	desired := int32(1)
+	if canary.Spec.Replicas != nil {
+		desired = *canary.Spec.Replicas
+	}
+	available := canary.Status.AvailableReplicas
+	if available >= desired {
+		return true, fmt.Sprintf("%d/%d canary pods available", available, desired), nil
+	}
+	if done {
+		return false, fmt.Sprintf("only %d/%d canary pods became available", available, desired), nil
+	}
+	return true, fmt.Sprintf("waiting for canary pods, %d/%d available", available, desired), nil
+}
+
+// Share of canary responses which were 5xx over the last minute, from the django metrics scraped off the web pods.
+// Only has data when spec.metrics.web is enabled.
+const canaryErrorRateQuery = `sum(rate(django_http_responses_total_by_status_total{namespace="%[1]s",pod=~"%[2]s-.*",status=~"5.."}[1m])) / sum(rate(django_http_responses_total_by_status_total{namespace="%[1]s",pod=~"%[2]s-.*"}[1m]))`
+
+type prometheusQueryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+var prometheusClient = &http.Client{Timeout: 10 * time.Second}
+
+// Compare the canary's error rate against spec.rollout.canary.maxErrorRate, using the Prometheus server at
+// $PROMETHEUS_URL. Passes without a server configured or while the canary hasn't served any requests.
+func errorRateCanaryCheck(ctx *components.ComponentContext, canary *appsv1.Deployment, _ bool) (bool, string, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	prometheusURL := os.Getenv("PROMETHEUS_URL")
+	if prometheusURL == "" {
+		return true, "no PROMETHEUS_URL set, error rate not checked", nil
+	}
+	maxErrorRate := float64(5)
+	if instance.Spec.Rollout.Canary.MaxErrorRate != nil {
+		maxErrorRate = float64(*instance.Spec.Rollout.Canary.MaxErrorRate)
+	}
+
+	query := fmt.Sprintf(canaryErrorRateQuery, canary.Namespace, canary.Name)
+	resp, err := prometheusClient.Get(fmt.Sprintf("%s/api/v1/query?query=%s", strings.TrimSuffix(prometheusURL, "/"), url.QueryEscape(query)))
+	if err != nil {
+		return false, "", errors.Wrap(err, "rollout: unable to query prometheus")
+	}
+	defer resp.Body.Close()
+	result := &prometheusQueryResponse{}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "rollout: unable to decode prometheus response (HTTP %d)", resp.StatusCode)
+	}
+	if result.Status != "success" {
+		return false, "", errors.Errorf("rollout: prometheus query failed: %s", result.Error)
+	}
+	if len(result.Data.Result) == 0 || len(result.Data.Result[0].Value) != 2 {
+		return true, "no requests to the canary yet", nil
+	}
+	rawValue, ok := result.Data.Result[0].Value[1].(string)
+	if !ok {
+		return false, "", errors.Errorf("rollout: unexpected prometheus value %#v", result.Data.Result[0].Value[1])
+	}
+	errorRate, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return false, "", errors.Wrapf(err, "rollout: unable to parse prometheus value %s", rawValue)
+	}
+	// 0/0 when nothing was served.
+	if math.IsNaN(errorRate) {
+		return true, "no requests to the canary yet", nil
+	}
+	errorRate = errorRate * 100
+	if errorRate > maxErrorRate {
+		return false, fmt.Sprintf("error rate %.1f%% is above %.0f%%", errorRate, maxErrorRate), nil
+	}
+	return true, fmt.Sprintf("error rate %.1f%%", errorRate), nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
+)
+
+// How often to poll the queue depths.
+const celeryAutoscalerInterval = 30 * time.Second
+
+type celeryAutoscalerComponent struct {
+	ClientFactory utils.RabbitMQClientFactory
+}
+
+func (comp *celeryAutoscalerComponent) InjectClientFactory(factory utils.RabbitMQClientFactory) {
+	comp.ClientFactory = factory
+}
+
+func NewCeleryAutoscaler() *celeryAutoscalerComponent {
+	return &celeryAutoscalerComponent{ClientFactory: utils.RabbitholeClientFactory}
+}
+
+func (_ *celeryAutoscalerComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{}
+}
+
+func (_ *celeryAutoscalerComponent) IsReconcilable(ctx *components.ComponentContext) bool {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	return instance.Status.RabbitMQStatus == dbv1beta1.StatusReady
+}
+
+// Dependencies implements components.Dependent.
+func (_ *celeryAutoscalerComponent) Dependencies() []components.Component {
+	return []components.Component{
+		&rabbitmqVhostComponent{},
+		&deploymentComponent{},
+	}
+}
+
+func (comp *celeryAutoscalerComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	scaling := instance.Spec.Celery.Autoscaling
+	if scaling == nil {
+		// Clear out the old status if autoscaling was turned off.
+		if instance.Status.CeleryAutoscaling != (summonv1beta1.CeleryAutoscalingStatus{}) {
+			return components.Result{StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.CeleryAutoscaling = summonv1beta1.CeleryAutoscalingStatus{}
+				return nil
+			}}, nil
+		}
+		return components.Result{}, nil
+	}
+	if instance.Status.Status == summonv1beta1.StatusHibernating {
+		// Nothing is consuming the queues, don't scale celeryd back up.
+		return components.Result{}, nil
+	}
+	vhost := instance.Status.RabbitMQConnection.Vhost
+	if vhost == "" {
+		return components.Result{}, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s-celeryd", instance.Name), Namespace: instance.Namespace}, deployment)
+	if kerrors.IsNotFound(err) {
+		// Not deployed yet, check again later.
+		return components.Result{RequeueAfter: celeryAutoscalerInterval}, nil
+	} else if err != nil {
+		return components.Result{}, errors.Wrap(err, "celery_autoscaler: unable to get celeryd deployment")
+	}
+
+	// Failing to read the queues shouldn't take the whole instance down, celeryd just stays at its current size.
+	depth, err := comp.queueDepth(ctx, vhost, scaling.Queues)
+	if err != nil {
+		glog.Errorf("[%s/%s] celery_autoscaler: %s\n", instance.Namespace, instance.Name, err)
+		ctx.Eventf(corev1.EventTypeWarning, "CeleryAutoscalingFailed", "Unable to read queue depths: %s", err)
+		message := fmt.Sprintf("Unable to read queue depths: %s", err)
+		return components.Result{
+			StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.CeleryAutoscaling.Message = message
+				return nil
+			},
+			RequeueAfter: celeryAutoscalerInterval,
+		}, nil
+	}
+
+	current := int32(1)
+	if deployment.Spec.Replicas != nil {
+		current = *deployment.Spec.Replicas
+	}
+	desired := desiredCeleryReplicas(depth, scaling)
+
+	now := time.Now()
+	var sinceLastScale time.Duration
+	lastScaleTime := instance.Status.CeleryAutoscaling.LastScaleTime
+	if lastScaleTime != "" {
+		parsedTime, err := time.Parse(time.UnixDate, lastScaleTime)
+		if err != nil {
+			return components.Result{}, errors.Wrap(err, "celery_autoscaler: failed to parse last scale time")
+		}
+		sinceLastScale = now.Sub(parsedTime)
+	}
+	cooledDown := func(cooldown time.Duration) bool {
+		return lastScaleTime == "" || sinceLastScale >= cooldown
+	}
+
+	target := current
+	var message string
+	switch {
+	case desired > current && !cooledDown(scaling.ScaleUpCooldown.Duration):
+		message = fmt.Sprintf("Waiting for scale up cooldown to go from %d to %d pods", current, desired)
+	case desired < current && !cooledDown(scaling.ScaleDownCooldown.Duration):
+		message = fmt.Sprintf("Waiting for scale down cooldown to go from %d to %d pods", current, desired)
+	case desired != current:
+		target = desired
+		message = fmt.Sprintf("Scaled from %d to %d pods for %d queued messages", current, desired, depth)
+	default:
+		message = fmt.Sprintf("Running %d pods for %d queued messages", current, depth)
+	}
+
+	if target != current {
+		deployment.Spec.Replicas = &target
+		err = ctx.Update(ctx.Context, deployment)
+		if err != nil {
+			return components.Result{Requeue: true}, errors.Wrap(err, "celery_autoscaler: unable to scale celeryd deployment")
+		}
+		glog.Infof("[%s/%s] celery_autoscaler: %s\n", instance.Namespace, instance.Name, message)
+		ctx.Eventf(corev1.EventTypeNormal, "CeleryScaled", "Scaled celeryd from %d to %d pods for %d queued messages", current, target, depth)
+		lastScaleTime = now.Format(time.UnixDate)
+	}
+
+	return components.Result{
+		StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.CeleryAutoscaling.QueueDepth = depth
+			instance.Status.CeleryAutoscaling.DesiredReplicas = desired
+			instance.Status.CeleryAutoscaling.CurrentReplicas = target
+			instance.Status.CeleryAutoscaling.LastScaleTime = lastScaleTime
+			instance.Status.CeleryAutoscaling.Message = message
+			return nil
+		},
+		RequeueAfter: celeryAutoscalerInterval,
+	}, nil
+}
+
+// Count the messages waiting to be picked up, from either the given queues or every queue in the vhost.
+func (comp *celeryAutoscalerComponent) queueDepth(ctx *components.ComponentContext, vhost string, queueNames []string) (int, error) {
+	rmqc, err := utils.OpenRabbit(ctx, &dbv1beta1.RabbitmqConnection{}, comp.ClientFactory)
+	if err != nil {
+		return 0, errors.Wrap(err, "error creating rabbitmq client")
+	}
+	queues, err := rmqc.ListQueuesIn(vhost)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error fetching queues for vhost %s", vhost)
+	}
+	wanted := map[string]bool{}
+	for _, name := range queueNames {
+		wanted[name] = true
+	}
+	depth := 0
+	for _, queue := range queues {
+		if len(wanted) == 0 || wanted[queue.Name] {
+			depth += queue.MessagesReady
+		}
+	}
+	return depth, nil
+}
+
+// One pod per MessagesPerReplica waiting messages, rounded up and kept within the configured bounds.
+func desiredCeleryReplicas(depth int, scaling *summonv1beta1.CeleryAutoscalingSpec) int32 {
+	perReplica := int(scaling.MessagesPerReplica)
+	if perReplica <= 0 {
+		perReplica = 100
+	}
+	desired := int32((depth + perReplica - 1) / perReplica)
+	minReplicas := int32(1)
+	if scaling.MinReplicas != nil {
+		minReplicas = *scaling.MinReplicas
+	}
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	if desired > scaling.MaxReplicas {
+		desired = scaling.MaxReplicas
+	}
+	return desired
+}
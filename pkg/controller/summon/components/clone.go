@@ -0,0 +1,507 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	awsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/aws/v1beta1"
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+const cloneSnapshotTemplatePath = "db/clonesnapshot.yml.tpl"
+
+// Annotation on a SummonPlatform listing the other namespaces allowed to clone it, comma separated. Without it an
+// instance can only be cloned from its own namespace, so nobody can pull another namespace's data into theirs.
+const CloneAllowedNamespacesAnnotation = "summon.ridecell.io/allowCloneFrom"
+
+// Most objects copied per reconcile, which is also the most S3 will list at once.
+const cloneCopyPageSize = 1000
+
+// Config and secret keys which belong to the source instance itself, or are generated for every instance anyway.
+var cloneSkippedKeys = map[string]bool{
+	"WEB_URL":                      true,
+	"ASGI_URL":                     true,
+	"CACHE_URL":                    true,
+	"TENANT_ID":                    true,
+	"NEWRELIC_NAME":                true,
+	"AWS_REGION":                   true,
+	"AWS_STORAGE_BUCKET_NAME":      true,
+	"DATA_PIPELINE_SQS_QUEUE_NAME": true,
+	"DISPATCH_BASE_URL":            true,
+	"GATEWAY_BASE_URL":             true,
+	"DATABASE_URL":                 true,
+	"CELERY_BROKER_URL":            true,
+	"AWS_ACCESS_KEY_ID":            true,
+	"AWS_SECRET_ACCESS_KEY":        true,
+	"SECRET_KEY":                   true,
+	"FERNET_KEYS":                  true,
+}
+
+// Anything mentioning one of these talks to the outside world. A clone must never text the source's riders or
+// post to its customers' webhooks, so these are scrubbed.
+var cloneScrubbedIntegrations = []string{
+	"TWILIO",
+	"SENDGRID",
+	"MAILGUN",
+	"SMTP",
+	"EMAIL_HOST",
+	"FIREBASE",
+	"APNS",
+	"FCM",
+	"PUSH",
+	"SMS",
+	"WEBHOOK",
+	"SLACK",
+	"STRIPE",
+	"BRAINTREE",
+	"SEGMENT",
+	"INTERCOM",
+}
+
+// Should a config or secret key be left out when copying from the clone source.
+func cloneScrubbed(key string) bool {
+	if cloneSkippedKeys[key] {
+		return true
+	}
+	upperKey := strings.ToUpper(key)
+	for _, integration := range cloneScrubbedIntegrations {
+		if strings.Contains(upperKey, integration) {
+			return true
+		}
+	}
+	return false
+}
+
+type S3Factory func(region string) (s3iface.S3API, error)
+
+type cloneComponent struct {
+	// Keep an S3API per region.
+	s3Services map[string]s3iface.S3API
+	s3Factory  S3Factory
+}
+
+func realS3Factory(region string) (s3iface.S3API, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(region),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s3.New(sess), nil
+}
+
+func NewClone() *cloneComponent {
+	return &cloneComponent{
+		s3Services: map[string]s3iface.S3API{},
+		s3Factory:  realS3Factory,
+	}
+}
+
+func (comp *cloneComponent) InjectS3Factory(factory S3Factory) {
+	comp.s3Factory = factory
+}
+
+func (_ *cloneComponent) WatchTypes() []runtime.Object {
+	// RDSSnapshots and PostgresDatabases are already watched by the backup and postgres components.
+	return []runtime.Object{}
+}
+
+func (_ *cloneComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return true
+}
+
+func (comp *cloneComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if instance.Spec.CloneFrom == nil {
+		return components.Result{}, nil
+	}
+
+	switch instance.Status.Clone.Phase {
+	case "":
+		// Cloning over an existing database would throw away the instance's own data.
+		existing := &dbv1beta1.PostgresDatabase{}
+		err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, existing)
+		if err == nil {
+			return components.Result{}, errors.New("clone: cloneFrom can only be set when creating an instance")
+		} else if !kerrors.IsNotFound(err) {
+			return components.Result{}, errors.Wrap(err, "clone: unable to get PostgresDatabase")
+		}
+		ctx.Eventf(corev1.EventTypeNormal, "CloneStarted", "Cloning from %s/%s", cloneSourceNamespace(instance), instance.Spec.CloneFrom.Instance)
+		status := summonv1beta1.CloneStatus{Phase: summonv1beta1.ClonePhaseCopyingConfig, Message: "Copying config and secrets"}
+		return components.Result{StatusModifier: setCloneStatus(status), Requeue: true}, nil
+	case summonv1beta1.ClonePhaseCopyingConfig:
+		return comp.copyConfig(ctx)
+	case summonv1beta1.ClonePhaseSnapshotting:
+		return comp.snapshot(ctx)
+	case summonv1beta1.ClonePhaseRestoringDatabase:
+		if instance.Status.PostgresStatus != dbv1beta1.StatusReady {
+			// The postgres component's watch brings us back here once the database is up.
+			return components.Result{}, nil
+		}
+		return comp.planBuckets(ctx)
+	case summonv1beta1.ClonePhaseCopyingBuckets:
+		return comp.copyBuckets(ctx)
+	case summonv1beta1.ClonePhaseComplete:
+		return components.Result{}, nil
+	}
+	return components.Result{}, errors.Errorf("clone: unknown clone phase %s", instance.Status.Clone.Phase)
+}
+
+// Copy the source's config and secrets, minus anything that would make the clone act as the source, and work out
+// where its database lives.
+func (comp *cloneComponent) copyConfig(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	source, err := getCloneSource(ctx)
+	if err != nil {
+		return components.Result{}, err
+	}
+
+	sourceDb := &dbv1beta1.PostgresDatabase{}
+	err = ctx.Get(ctx.Context, types.NamespacedName{Name: source.Name, Namespace: source.Namespace}, sourceDb)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "clone: unable to get PostgresDatabase for %s/%s", source.Namespace, source.Name)
+	}
+	if sourceDb.Status.RDSInstanceID == "" {
+		return components.Result{}, errors.Errorf("clone: %s/%s has no RDS instance to restore from", source.Namespace, source.Name)
+	}
+
+	// Merge the source's input secrets in the same order the app secrets component does.
+	secretNames := source.Spec.Secrets
+	if len(secretNames) == 0 {
+		secretNames = []string{source.Namespace, source.Name}
+	}
+	secretData := map[string][]byte{}
+	for _, secretName := range secretNames {
+		secret := &corev1.Secret{}
+		err = ctx.Get(ctx.Context, types.NamespacedName{Name: secretName, Namespace: source.Namespace}, secret)
+		if err != nil {
+			return components.Result{}, errors.Wrapf(err, "clone: unable to get source secret %s/%s", source.Namespace, secretName)
+		}
+		for key, value := range secret.Data {
+			if !cloneScrubbed(key) {
+				secretData[key] = value
+			}
+		}
+	}
+	cloneSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.clone-secrets", instance.Name), Namespace: instance.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx.Context, ctx, cloneSecret, func(existingObj runtime.Object) error {
+		existing := existingObj.(*corev1.Secret)
+		err := controllerutil.SetControllerReference(instance, existing, ctx.Scheme)
+		if err != nil {
+			return errors.Wrap(err, "clone: unable to set controller reference")
+		}
+		existing.Data = secretData
+		return nil
+	})
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "clone: unable to save copied secrets")
+	}
+
+	// The restored database has fields encrypted with the source's fernet keys. Anything the fernet rotation
+	// already generated for us is kept as the newest key.
+	sourceKeys := &corev1.Secret{}
+	err = ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s.fernet-keys", source.Name), Namespace: source.Namespace}, sourceKeys)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "clone: unable to get fernet keys for %s/%s", source.Namespace, source.Name)
+	}
+	fernetKeys := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.fernet-keys", instance.Name), Namespace: instance.Namespace},
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx.Context, ctx, fernetKeys, func(existingObj runtime.Object) error {
+		existing := existingObj.(*corev1.Secret)
+		err := controllerutil.SetControllerReference(instance, existing, ctx.Scheme)
+		if err != nil {
+			return errors.Wrap(err, "clone: unable to set controller reference")
+		}
+		if existing.Data == nil {
+			existing.Data = map[string][]byte{}
+		}
+		for key, value := range sourceKeys.Data {
+			existing.Data[key] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "clone: unable to copy fernet keys")
+	}
+
+	config := map[string]summonv1beta1.ConfigValue{}
+	for key, value := range source.Spec.Config {
+		if !cloneScrubbed(key) {
+			config[key] = value
+		}
+	}
+
+	cloneFrom := instance.Spec.CloneFrom
+	status := summonv1beta1.CloneStatus{
+		Phase:               summonv1beta1.ClonePhaseSnapshotting,
+		Message:             fmt.Sprintf("Taking a snapshot of %s", sourceDb.Status.RDSInstanceID),
+		Secret:              cloneSecret.Name,
+		Config:              config,
+		SourceRDSInstanceID: sourceDb.Status.RDSInstanceID,
+		SourceDatabase:      sourceDb.Status.Connection.Database,
+		SourceOwner:         sourceDb.Status.Connection.Username,
+	}
+	if cloneFrom.SnapshotID != "" {
+		status.Phase = summonv1beta1.ClonePhaseRestoringDatabase
+		status.Message = fmt.Sprintf("Restoring database from snapshot %s", cloneFrom.SnapshotID)
+		status.SnapshotID = cloneFrom.SnapshotID
+	} else if cloneFrom.PointInTime != "" {
+		status.Phase = summonv1beta1.ClonePhaseRestoringDatabase
+		status.Message = fmt.Sprintf("Restoring database from %s as of %s", sourceDb.Status.RDSInstanceID, cloneFrom.PointInTime)
+		status.RestoreTime = cloneFrom.PointInTime
+	}
+	return components.Result{StatusModifier: setCloneStatus(status), Requeue: true}, nil
+}
+
+// Take a fresh snapshot of the source database to restore from.
+func (comp *cloneComponent) snapshot(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	extra := map[string]interface{}{}
+	extra["rdsInstanceName"] = instance.Status.Clone.SourceRDSInstanceID
+
+	var existing *dbv1beta1.RDSSnapshot
+	_, _, err := ctx.CreateOrUpdate(cloneSnapshotTemplatePath, extra, func(goalObj, existingObj runtime.Object) error {
+		goal := goalObj.(*dbv1beta1.RDSSnapshot)
+		existing = existingObj.(*dbv1beta1.RDSSnapshot)
+		// Copy the Spec over.
+		existing.Spec = goal.Spec
+		return nil
+	})
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "clone: failed to create or update rds snapshot")
+	}
+
+	switch existing.Status.Status {
+	case dbv1beta1.StatusError:
+		return components.Result{}, errors.Errorf("clone: rdssnapshot %s is in an error state: %s", existing.Name, existing.Status.Message)
+	case dbv1beta1.StatusReady:
+		status := *instance.Status.Clone.DeepCopy()
+		status.Phase = summonv1beta1.ClonePhaseRestoringDatabase
+		status.Message = fmt.Sprintf("Restoring database from snapshot %s", existing.Status.SnapshotID)
+		status.SnapshotID = existing.Status.SnapshotID
+		return components.Result{StatusModifier: setCloneStatus(status), Requeue: true}, nil
+	}
+	// When the rdssnapshot is finished it will trigger this component to reconcile.
+	return components.Result{}, nil
+}
+
+// Work out which buckets to copy now that the database is restored.
+func (comp *cloneComponent) planBuckets(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	status := *instance.Status.Clone.DeepCopy()
+	if instance.Spec.CloneFrom.SkipBuckets {
+		return comp.complete(ctx, status), nil
+	}
+	source, err := getCloneSource(ctx)
+	if err != nil {
+		return components.Result{}, err
+	}
+
+	sourceBucket := &awsv1beta1.S3Bucket{}
+	err = ctx.Get(ctx.Context, types.NamespacedName{Name: source.Name, Namespace: source.Namespace}, sourceBucket)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "clone: unable to get static bucket for %s/%s", source.Namespace, source.Name)
+	}
+	status.SourceRegion = sourceBucket.Spec.Region
+	if status.SourceRegion == "" {
+		status.SourceRegion = instance.Spec.AwsRegion
+	}
+
+	bucket, ready, err := ownBucket(ctx, instance.Name)
+	if err != nil || !ready {
+		return components.Result{RequeueAfter: 30 * time.Second}, err
+	}
+	status.Buckets = []summonv1beta1.CloneBucketStatus{{Source: sourceBucket.Spec.BucketName, Destination: bucket}}
+
+	// Never copy into an external MIV bucket, it's shared with something else.
+	if source.Status.MIV.Bucket != "" && instance.Spec.MIV.ExistingBucket == "" {
+		bucket, ready, err := ownBucket(ctx, fmt.Sprintf("%s-miv", instance.Name))
+		if err != nil || !ready {
+			return components.Result{RequeueAfter: 30 * time.Second}, err
+		}
+		status.Buckets = append(status.Buckets, summonv1beta1.CloneBucketStatus{Source: source.Status.MIV.Bucket, Destination: bucket})
+	}
+
+	status.Phase = summonv1beta1.ClonePhaseCopyingBuckets
+	status.Message = fmt.Sprintf("Copying %s", status.Buckets[0].Source)
+	return components.Result{StatusModifier: setCloneStatus(status), Requeue: true}, nil
+}
+
+// Copy the next page of objects, picking up where the last reconcile left off.
+func (comp *cloneComponent) copyBuckets(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if ctx.DryRun {
+		// Don't copy anything during a dry run, the copy just carries on from the same place next time.
+		return components.Result{}, nil
+	}
+	status := *instance.Status.Clone.DeepCopy()
+
+	var bucket *summonv1beta1.CloneBucketStatus
+	for i := range status.Buckets {
+		if !status.Buckets[i].Done {
+			bucket = &status.Buckets[i]
+			break
+		}
+	}
+	if bucket == nil {
+		return comp.complete(ctx, status), nil
+	}
+
+	sourceS3, err := comp.s3For(status.SourceRegion)
+	if err != nil {
+		return components.Result{}, err
+	}
+	destS3, err := comp.s3For(instance.Spec.AwsRegion)
+	if err != nil {
+		return components.Result{}, err
+	}
+
+	listInput := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(bucket.Source),
+		MaxKeys: aws.Int64(cloneCopyPageSize),
+	}
+	if bucket.ContinuationToken != "" {
+		listInput.ContinuationToken = aws.String(bucket.ContinuationToken)
+	}
+	listOutput, err := sourceS3.ListObjectsV2(listInput)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "clone: unable to list objects in %s", bucket.Source)
+	}
+	for _, object := range listOutput.Contents {
+		key := aws.StringValue(object.Key)
+		_, err = destS3.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(bucket.Destination),
+			Key:        aws.String(key),
+			CopySource: aws.String(url.PathEscape(fmt.Sprintf("%s/%s", bucket.Source, key))),
+		})
+		if err != nil {
+			return components.Result{}, errors.Wrapf(err, "clone: unable to copy %s from %s to %s", key, bucket.Source, bucket.Destination)
+		}
+	}
+
+	bucket.CopiedObjects += len(listOutput.Contents)
+	bucket.ContinuationToken = aws.StringValue(listOutput.NextContinuationToken)
+	bucket.Done = !aws.BoolValue(listOutput.IsTruncated)
+	status.Message = fmt.Sprintf("Copied %d objects from %s", bucket.CopiedObjects, bucket.Source)
+	if bucket.Done {
+		glog.Infof("[%s/%s] clone: copied %d objects from %s to %s\n", instance.Namespace, instance.Name, bucket.CopiedObjects, bucket.Source, bucket.Destination)
+	}
+	return components.Result{StatusModifier: setCloneStatus(status), Requeue: true}, nil
+}
+
+func (comp *cloneComponent) complete(ctx *components.ComponentContext, status summonv1beta1.CloneStatus) components.Result {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	ctx.Eventf(corev1.EventTypeNormal, "CloneComplete", "Finished cloning from %s/%s", cloneSourceNamespace(instance), instance.Spec.CloneFrom.Instance)
+	status.Phase = summonv1beta1.ClonePhaseComplete
+	status.Message = fmt.Sprintf("Cloned from %s/%s", cloneSourceNamespace(instance), instance.Spec.CloneFrom.Instance)
+	return components.Result{StatusModifier: setCloneStatus(status)}
+}
+
+func (comp *cloneComponent) s3For(region string) (s3iface.S3API, error) {
+	s3Service, ok := comp.s3Services[region]
+	if !ok {
+		var err error
+		s3Service, err = comp.s3Factory(region)
+		if err != nil {
+			return nil, errors.Wrapf(err, "clone: unable to create S3 client for %s", region)
+		}
+		comp.s3Services[region] = s3Service
+	}
+	return s3Service, nil
+}
+
+func cloneSourceNamespace(instance *summonv1beta1.SummonPlatform) string {
+	if instance.Spec.CloneFrom.Namespace != "" {
+		return instance.Spec.CloneFrom.Namespace
+	}
+	return instance.Namespace
+}
+
+func getCloneSource(ctx *components.ComponentContext) (*summonv1beta1.SummonPlatform, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	source := &summonv1beta1.SummonPlatform{}
+	namespace := cloneSourceNamespace(instance)
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Spec.CloneFrom.Instance, Namespace: namespace}, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "clone: unable to get SummonPlatform %s/%s", namespace, instance.Spec.CloneFrom.Instance)
+	}
+	if !cloneAllowed(source, instance.Namespace) {
+		return nil, errors.Errorf("clone: %s/%s doesn't allow cloning into namespace %s, it needs a %s annotation listing it", source.Namespace, source.Name, instance.Namespace, CloneAllowedNamespacesAnnotation)
+	}
+	return source, nil
+}
+
+// Whether an instance in the given namespace may clone the source.
+func cloneAllowed(source *summonv1beta1.SummonPlatform, namespace string) bool {
+	if source.Namespace == namespace {
+		return true
+	}
+	for _, allowed := range strings.Split(source.Annotations[CloneAllowedNamespacesAnnotation], ",") {
+		if strings.TrimSpace(allowed) == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the bucket name for one of our own S3Buckets, and whether it's ready to copy into yet.
+func ownBucket(ctx *components.ComponentContext, name string) (string, bool, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	bucket := &awsv1beta1.S3Bucket{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: name, Namespace: instance.Namespace}, bucket)
+	if kerrors.IsNotFound(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, errors.Wrapf(err, "clone: unable to get S3Bucket %s", name)
+	}
+	return bucket.Spec.BucketName, bucket.Status.Status == awsv1beta1.StatusReady, nil
+}
+
+func setCloneStatus(status summonv1beta1.CloneStatus) components.StatusModifier {
+	return func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Clone = status
+		if status.Phase == summonv1beta1.ClonePhaseCopyingConfig || status.Phase == summonv1beta1.ClonePhaseSnapshotting {
+			// Nothing else gets far enough to say what's going on until the database exists.
+			instance.Status.Status = summonv1beta1.StatusInitializing
+			instance.Status.Message = status.Message
+		}
+		return nil
+	}
+}
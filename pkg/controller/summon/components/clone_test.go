@@ -0,0 +1,302 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	awsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/aws/v1beta1"
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+type mockCloneS3Client struct {
+	s3iface.S3API
+	copied []string
+}
+
+var _ = Describe("SummonPlatform Clone Component", func() {
+	comp := summoncomponents.NewClone()
+	var mockS3 *mockCloneS3Client
+	var source *summonv1beta1.SummonPlatform
+	var sourceDb *dbv1beta1.PostgresDatabase
+
+	BeforeEach(func() {
+		comp = summoncomponents.NewClone()
+		mockS3 = &mockCloneS3Client{}
+		comp.InjectS3Factory(func(_ string) (s3iface.S3API, error) { return mockS3, nil })
+
+		timeZone := "America/Chicago"
+		twilioNumber := "+15555550100"
+		source = &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "bar-uat",
+				Namespace:   "summon-uat",
+				Annotations: map[string]string{"summon.ridecell.io/allowCloneFrom": "summon-qa, summon-dev"},
+			},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Config: map[string]summonv1beta1.ConfigValue{
+					"TIME_ZONE":          summonv1beta1.ConfigValue{String: &timeZone},
+					"TWILIO_FROM_NUMBER": summonv1beta1.ConfigValue{String: &twilioNumber},
+				},
+			},
+			Status: summonv1beta1.SummonPlatformStatus{
+				MIV: summonv1beta1.MIVStatus{Bucket: "ridecell-bar-uat-miv"},
+			},
+		}
+		sourceDb = &dbv1beta1.PostgresDatabase{
+			ObjectMeta: metav1.ObjectMeta{Name: "bar-uat", Namespace: "summon-uat"},
+			Status: dbv1beta1.PostgresDatabaseStatus{
+				RDSInstanceID: "bar-uat",
+				Connection: dbv1beta1.PostgresConnection{
+					Database: "bar_uat",
+					Username: "bar_uat",
+				},
+			},
+		}
+		instance.Spec.CloneFrom = &summonv1beta1.CloneFromSpec{Instance: "bar-uat", Namespace: "summon-uat"}
+	})
+
+	It("does nothing without cloneFrom", func() {
+		instance.Spec.CloneFrom = nil
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Clone.Phase).To(BeEmpty())
+	})
+
+	It("starts copying config", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseCopyingConfig))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusInitializing))
+	})
+
+	It("refuses to clone over an existing database", func() {
+		ctx.Client = fake.NewFakeClient(&dbv1beta1.PostgresDatabase{ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"}})
+		Expect(comp).ToNot(ReconcileContext(ctx))
+		Expect(instance.Status.Clone.Phase).To(BeEmpty())
+	})
+
+	Describe("copying config", func() {
+		BeforeEach(func() {
+			instance.Status.Clone.Phase = summonv1beta1.ClonePhaseCopyingConfig
+			namespaceSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "summon-uat", Namespace: "summon-uat"},
+				Data: map[string][]byte{
+					"SENTRY_DSN":       []byte("https://sentry"),
+					"SENDGRID_API_KEY": []byte("sendgrid"),
+				},
+			}
+			instanceSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar-uat", Namespace: "summon-uat"},
+				Data: map[string][]byte{
+					"SENTRY_DSN":   []byte("https://sentry/bar"),
+					"DATABASE_URL": []byte("postgis://bar"),
+				},
+			}
+			fernetKeys := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar-uat.fernet-keys", Namespace: "summon-uat"},
+				Data:       map[string][]byte{"2020-01-06T20:00:00Z": []byte("key")},
+			}
+			ctx.Client = fake.NewFakeClient(source, sourceDb, namespaceSecret, instanceSecret, fernetKeys)
+		})
+
+		It("refuses to clone from a namespace which doesn't allow it", func() {
+			source.Annotations = nil
+			ctx.Client = fake.NewFakeClient(source, sourceDb)
+			Expect(comp).ToNot(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseCopyingConfig))
+			secret := &corev1.Secret{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev.clone-secrets", Namespace: "summon-dev"}, secret)
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("copies scrubbed config and secrets", func() {
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseSnapshotting))
+			Expect(instance.Status.Clone.Config).To(HaveKey("TIME_ZONE"))
+			Expect(instance.Status.Clone.Config).ToNot(HaveKey("TWILIO_FROM_NUMBER"))
+			Expect(instance.Status.Clone.SourceRDSInstanceID).To(Equal("bar-uat"))
+			Expect(instance.Status.Clone.SourceDatabase).To(Equal("bar_uat"))
+			Expect(instance.Status.Clone.SourceOwner).To(Equal("bar_uat"))
+			Expect(instance.Status.Clone.Secret).To(Equal("foo-dev.clone-secrets"))
+
+			secret := &corev1.Secret{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev.clone-secrets", Namespace: "summon-dev"}, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret.Data).To(Equal(map[string][]byte{"SENTRY_DSN": []byte("https://sentry/bar")}))
+
+			fernetKeys := &corev1.Secret{}
+			err = ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev.fernet-keys", Namespace: "summon-dev"}, fernetKeys)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fernetKeys.Data).To(HaveKeyWithValue("2020-01-06T20:00:00Z", []byte("key")))
+		})
+
+		It("skips the snapshot when given one", func() {
+			instance.Spec.CloneFrom.SnapshotID = "bar-uat-nightly"
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseRestoringDatabase))
+			Expect(instance.Status.Clone.SnapshotID).To(Equal("bar-uat-nightly"))
+		})
+
+		It("skips the snapshot for a point in time restore", func() {
+			instance.Spec.CloneFrom.PointInTime = "2020-01-06T20:00:00Z"
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseRestoringDatabase))
+			Expect(instance.Status.Clone.RestoreTime).To(Equal("2020-01-06T20:00:00Z"))
+		})
+	})
+
+	Describe("snapshotting", func() {
+		BeforeEach(func() {
+			instance.Status.Clone.Phase = summonv1beta1.ClonePhaseSnapshotting
+			instance.Status.Clone.SourceRDSInstanceID = "bar-uat"
+		})
+
+		It("creates a snapshot of the source", func() {
+			Expect(comp).To(ReconcileContext(ctx))
+			snapshot := &dbv1beta1.RDSSnapshot{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-clone", Namespace: "summon-dev"}, snapshot)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot.Spec.RDSInstanceID).To(Equal("bar-uat"))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseSnapshotting))
+		})
+
+		It("moves on once the snapshot is ready", func() {
+			snapshot := &dbv1beta1.RDSSnapshot{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-clone", Namespace: "summon-dev"},
+				Status:     dbv1beta1.RDSSnapshotStatus{Status: dbv1beta1.StatusReady, SnapshotID: "foo-dev-clone-2020"},
+			}
+			ctx.Client = fake.NewFakeClient(snapshot)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseRestoringDatabase))
+			Expect(instance.Status.Clone.SnapshotID).To(Equal("foo-dev-clone-2020"))
+		})
+	})
+
+	Describe("copying buckets", func() {
+		BeforeEach(func() {
+			instance.Spec.AwsRegion = "us-west-2"
+			instance.Status.Clone.Phase = summonv1beta1.ClonePhaseRestoringDatabase
+			instance.Status.PostgresStatus = dbv1beta1.StatusReady
+			sourceBucket := &awsv1beta1.S3Bucket{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar-uat", Namespace: "summon-uat"},
+				Spec:       awsv1beta1.S3BucketSpec{BucketName: "ridecell-bar-uat-static", Region: "us-west-2"},
+			}
+			staticBucket := &awsv1beta1.S3Bucket{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+				Spec:       awsv1beta1.S3BucketSpec{BucketName: "ridecell-foo-dev-static"},
+				Status:     awsv1beta1.S3BucketStatus{Status: awsv1beta1.StatusReady},
+			}
+			mivBucket := &awsv1beta1.S3Bucket{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-miv", Namespace: "summon-dev"},
+				Spec:       awsv1beta1.S3BucketSpec{BucketName: "ridecell-foo-dev-miv"},
+				Status:     awsv1beta1.S3BucketStatus{Status: awsv1beta1.StatusReady},
+			}
+			ctx.Client = fake.NewFakeClient(source, sourceBucket, staticBucket, mivBucket)
+		})
+
+		It("waits for the database", func() {
+			instance.Status.PostgresStatus = dbv1beta1.StatusCreating
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseRestoringDatabase))
+		})
+
+		It("skips the buckets when asked", func() {
+			instance.Spec.CloneFrom.SkipBuckets = true
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseComplete))
+		})
+
+		It("copies the static and MIV buckets a page at a time", func() {
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseCopyingBuckets))
+			Expect(instance.Status.Clone.Buckets).To(Equal([]summonv1beta1.CloneBucketStatus{
+				{Source: "ridecell-bar-uat-static", Destination: "ridecell-foo-dev-static"},
+				{Source: "ridecell-bar-uat-miv", Destination: "ridecell-foo-dev-miv"},
+			}))
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Buckets[0].CopiedObjects).To(Equal(2))
+			Expect(instance.Status.Clone.Buckets[0].ContinuationToken).To(Equal("page2"))
+			Expect(instance.Status.Clone.Buckets[0].Done).To(BeFalse())
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Buckets[0].CopiedObjects).To(Equal(3))
+			Expect(instance.Status.Clone.Buckets[0].Done).To(BeTrue())
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Buckets[1].Done).To(BeTrue())
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseComplete))
+			Expect(mockS3.copied).To(ContainElement("ridecell-bar-uat-static%2Fstatic%2Fapp.css -> ridecell-foo-dev-static"))
+			Expect(mockS3.copied).To(HaveLen(6))
+		})
+
+		It("doesn't copy anything during a dry run", func() {
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Phase).To(Equal(summonv1beta1.ClonePhaseCopyingBuckets))
+
+			ctx.DryRun = true
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(mockS3.copied).To(BeEmpty())
+			Expect(instance.Status.Clone.Buckets[0].CopiedObjects).To(Equal(0))
+			Expect(instance.Status.Clone.Buckets[0].ContinuationToken).To(Equal(""))
+		})
+
+		It("leaves an external MIV bucket alone", func() {
+			instance.Spec.MIV.ExistingBucket = "shared-miv"
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Clone.Buckets).To(HaveLen(1))
+		})
+	})
+})
+
+// Every bucket has three objects, listed two at a time.
+func (m *mockCloneS3Client) ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	if input.ContinuationToken == nil {
+		return &s3.ListObjectsV2Output{
+			Contents: []*s3.Object{
+				&s3.Object{Key: aws.String("static/app.css")},
+				&s3.Object{Key: aws.String("static/app.js")},
+			},
+			IsTruncated:           aws.Bool(true),
+			NextContinuationToken: aws.String("page2"),
+		}, nil
+	}
+	return &s3.ListObjectsV2Output{
+		Contents:    []*s3.Object{&s3.Object{Key: aws.String("static/logo.png")}},
+		IsTruncated: aws.Bool(false),
+	}, nil
+}
+
+func (m *mockCloneS3Client) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	m.copied = append(m.copied, aws.StringValue(input.CopySource)+" -> "+aws.StringValue(input.Bucket))
+	return &s3.CopyObjectOutput{}, nil
+}
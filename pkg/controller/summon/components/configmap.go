@@ -46,6 +46,11 @@ func (_ *configmapComponent) IsReconcilable(_ *components.ComponentContext) bool
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *configmapComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *configmapComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
@@ -17,16 +17,22 @@ limitations under the License.
 package components
 
 import (
+	"context"
 	"fmt"
-	"os"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
 )
 
 const defaultFernetKeysLifespan = "8760h"
@@ -51,26 +57,25 @@ func (_ *defaultsComponent) IsReconcilable(_ *components.ComponentContext) bool
 	return true
 }
 
+// RunWhilePaused implements components.PauseExempt. Everything else that still runs relies on the defaults.
+func (_ *defaultsComponent) RunWhilePaused() bool {
+	return true
+}
+
 func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
-	// Set error status to prevent further deployments until it is resolved.
-	if instance.Spec.Version == "" && instance.Spec.AutoDeploy == "" {
-		return components.Result{}, errors.New("Spec.Version OR Spec.AutoDeploy must be set. No Version set for deployment.")
-	}
-
-	if instance.Spec.Version != "" && instance.Spec.AutoDeploy != "" {
-		return components.Result{}, errors.New("Spec.Version and Spec.AutoDeploy are both set. Must specify only one.")
+	profile, err := RegionProfileFor(ctx.Context, ctx, instance)
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "defaults: unable to load region profile")
 	}
 
-	// If the persistentVolumeClaim for redis changes this integer should as well.
-	if instance.Spec.Redis.RAM > 10 {
-		return components.Result{}, errors.New("redis memory limit cannot surpass available disk space")
-	}
-
-	// Set redis defaults
-	if instance.Spec.Redis.RAM == 0 {
-		instance.Spec.Redis.RAM = 1
+	// Fill in the defaults which are also saved by the admission webhook, then check everything is valid. Set
+	// error status to prevent further deployments until it is resolved.
+	DefaultSpec(instance, profile)
+	validationErrs := ValidateSpec(instance)
+	if len(validationErrs) != 0 {
+		return components.Result{}, validationErrs.ToAggregate()
 	}
 
 	// Helper method to set a string value if not already set.
@@ -87,89 +92,41 @@ func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (comp
 		instance.Spec.Config = map[string]summonv1beta1.ConfigValue{}
 	}
 
-	// Fill in defaults.
-	if instance.Spec.Environment == "" {
-		x := instance.Namespace
-		x = strings.TrimPrefix(x, "summon-")
-		instance.Spec.Environment = x
-	}
-	if instance.Spec.Hostname == "" {
-		baseHostname := ".ridecell.us"
-		if instance.Spec.Environment == "uat" || instance.Spec.Environment == "prod" {
-			baseHostname = ".ridecell.com"
+	// Config copied from a clone source sits below the instance's own values but above the defaults. Never saved,
+	// so the source's values are only ever in the status.
+	for key, value := range instance.Status.Clone.Config {
+		_, ok := instance.Spec.Config[key]
+		if !ok {
+			instance.Spec.Config[key] = value
 		}
-		instance.Spec.Hostname = instance.Name + baseHostname
 	}
-	err := comp.replicaDefaults(instance)
-	if err != nil {
-		return components.Result{}, errors.Wrap(err, "error setting replica defaults")
+
+	// If no comp-dispatch version is set, override dispatch replicas to 0. This is never saved, so it goes back to
+	// the normal default when a version is set.
+	intp := func(i int32) *int32 { return &i }
+	if instance.Spec.Dispatch.Version == "" {
+		instance.Spec.Replicas.Dispatch = intp(0)
 	}
-	if instance.Spec.PullSecret == "" {
-		instance.Spec.PullSecret = "pull-secret"
+	// Same for comp-buisness-portal.
+	if instance.Spec.BusinessPortal.Version == "" {
+		instance.Spec.Replicas.BusinessPortal = intp(0)
 	}
-	if instance.Spec.FernetKeyLifetime == zeroSeconds {
-		// This is set to rotate fernet keys every year.
-		parsedTimeDuration, _ := time.ParseDuration(defaultFernetKeysLifespan)
-		instance.Spec.FernetKeyLifetime = parsedTimeDuration
+
+	// The default self checks follow which optional services are deployed, so they aren't saved either.
+	if len(instance.Spec.SelfCheck.Checks) == 0 {
+		instance.Spec.SelfCheck.Checks = defaultSelfChecks(instance)
 	}
+
 	if instance.Spec.AwsRegion == "" {
-		instance.Spec.AwsRegion = os.Getenv("AWS_REGION")
-		// If the env var isn't present, assume us-west-2. Mostly for local testing stuff.
-		if instance.Spec.AwsRegion == "" {
-			instance.Spec.AwsRegion = "us-west-2"
-		}
+		instance.Spec.AwsRegion = profile.Region
 	}
 	if instance.Spec.SQSQueue == "" {
-		switch instance.Spec.Environment {
-		case "prod":
-			switch instance.Spec.AwsRegion {
-			case "eu-central-1":
-				instance.Spec.SQSQueue = "eu-prod-data-pipeline"
-			case "ap-south-1":
-				instance.Spec.SQSQueue = "in-prod-data-pipeline"
-			default:
-				instance.Spec.SQSQueue = "prod-data-pipeline"
-			}
-		case "uat":
-			switch instance.Spec.AwsRegion {
-			case "eu-central-1":
-				instance.Spec.SQSQueue = "eu-prod-data-pipeline"
-			case "ap-south-1":
-				instance.Spec.SQSQueue = "in-prod-data-pipeline"
-			default:
-				instance.Spec.SQSQueue = "us-uat-data-pipeline"
-			}
-		case "qa":
-			instance.Spec.SQSQueue = "us-qa-data-pipeline"
-		default:
-			instance.Spec.SQSQueue = "master-data-pipeline"
-		}
-	}
-
-	if instance.Spec.EnableNewRelic == nil && instance.Spec.Environment == "prod" {
-		val := true
-		instance.Spec.EnableNewRelic = &val
-	}
-
-	if instance.Spec.MockTenantHardwareType == "" {
-		instance.Spec.MockTenantHardwareType = "OTAKEYS"
+		instance.Spec.SQSQueue = utils.RegionQueueFor(profile, instance.Spec.Environment)
 	}
-
-	if instance.Spec.Backup.TTL.Duration == 0 {
-		instance.Spec.Backup.TTL.Duration = time.Hour * 720
-		if instance.Spec.Environment == "dev" || instance.Spec.Environment == "qa" {
-			instance.Spec.Backup.TTL.Duration = time.Hour * 72
-		}
+	if instance.Spec.Database.DbConfigRef.Name == "" && profile.DbConfigRef.Name != "" {
+		instance.Spec.Database.DbConfigRef = profile.DbConfigRef
 	}
 
-	if instance.Spec.Backup.WaitUntilReady == nil {
-		prodWaitBool := true
-		instance.Spec.Backup.WaitUntilReady = &prodWaitBool
-		if instance.Spec.Environment == "dev" || instance.Spec.Environment == "qa" {
-			devWaitBool := false
-			instance.Spec.Backup.WaitUntilReady = &devWaitBool
-		}
-	}
 	if instance.Spec.Environment == "uat" || instance.Spec.Environment == "prod" {
 		defVal("FIREBASE_APP", "ridecell")
 
@@ -212,13 +169,6 @@ func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (comp
 	defVal("DATA_PIPELINE_SQS_QUEUE_NAME", "%s", instance.Spec.SQSQueue)
 	defVal("DISPATCH_BASE_URL", "http://%s-dispatch:8000/", instance.Name)
 
-	// Translate our aws region into a usable region
-	untranslatedRegion := strings.Split(os.Getenv("AWS_REGION"), "-")[0]
-	translatedRegion := untranslatedRegion
-	if untranslatedRegion == "ap" {
-		translatedRegion = "in"
-	}
-
 	// Set our gateway environment for GATEWAY_BASE_URL
 	gatewayEnv := "prod"
 
@@ -229,8 +179,7 @@ func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (comp
 
 		gatewayEnv = "master"
 	}
-	// Use our translated region and gateway env to set GATEWAY_BASE_URL
-	defVal("GATEWAY_BASE_URL", "https://global.%s.%s.svc.ridecell.io/", translatedRegion, gatewayEnv)
+	defVal("GATEWAY_BASE_URL", "https://global.%s.%s.svc.ridecell.io/", profile.GatewayRegion, gatewayEnv)
 
 	// Enable NewRelic if requested.
 	if instance.Spec.EnableNewRelic != nil && *instance.Spec.EnableNewRelic {
@@ -241,7 +190,105 @@ func (comp *defaultsComponent) Reconcile(ctx *components.ComponentContext) (comp
 	return components.Result{}, nil
 }
 
-func (comp *defaultsComponent) replicaDefaults(instance *summonv1beta1.SummonPlatform) error {
+// RegionProfileFor loads the RegionProfile for an instance. An explicit awsRegion picks the profile for that region
+// when no profile is named.
+func RegionProfileFor(ctx context.Context, c client.Client, instance *summonv1beta1.SummonPlatform) (*summonv1beta1.RegionProfileSpec, error) {
+	name := instance.Spec.RegionProfile
+	if name == "" {
+		name = instance.Spec.AwsRegion
+	}
+	return utils.GetRegionProfile(ctx, c, utils.RegionProfileName(name))
+}
+
+// DefaultSpec fills in the defaults which are safe to save on the object, used both by the admission webhook and
+// on every reconcile. Anything derived from other fields, like the dispatch replicas override, or from the region
+// profile stays in the defaults component so it can change later. The hostname is the exception: it comes from the
+// region profile but is saved on create, since an instance's hostname must not move if the profile changes.
+func DefaultSpec(instance *summonv1beta1.SummonPlatform, profile *summonv1beta1.RegionProfileSpec) {
+	// Set redis defaults
+	if instance.Spec.Redis.RAM == 0 {
+		instance.Spec.Redis.RAM = 1
+	}
+	if instance.Spec.Environment == "" {
+		x := instance.Namespace
+		x = strings.TrimPrefix(x, "summon-")
+		instance.Spec.Environment = x
+	}
+	if instance.Spec.Hostname == "" {
+		instance.Spec.Hostname = fmt.Sprintf("%s.%s", instance.Name, utils.RegionBaseHostnameFor(profile, instance.Spec.Environment))
+	}
+	replicaDefaults(instance)
+	autoscalingDefaults(instance)
+	if instance.Spec.PullSecret == "" {
+		instance.Spec.PullSecret = "pull-secret"
+	}
+	if instance.Spec.FernetKeyLifetime == zeroSeconds {
+		// This is set to rotate fernet keys every year.
+		parsedTimeDuration, _ := time.ParseDuration(defaultFernetKeysLifespan)
+		instance.Spec.FernetKeyLifetime = parsedTimeDuration
+	}
+	if instance.Spec.EnableNewRelic == nil && instance.Spec.Environment == "prod" {
+		val := true
+		instance.Spec.EnableNewRelic = &val
+	}
+	if instance.Spec.MockTenantHardwareType == "" {
+		instance.Spec.MockTenantHardwareType = "OTAKEYS"
+	}
+	if instance.Spec.Backup.TTL.Duration == 0 {
+		instance.Spec.Backup.TTL.Duration = time.Hour * 720
+		if instance.Spec.Environment == "dev" || instance.Spec.Environment == "qa" {
+			instance.Spec.Backup.TTL.Duration = time.Hour * 72
+		}
+	}
+	if instance.Spec.Backup.WaitUntilReady == nil {
+		prodWaitBool := true
+		instance.Spec.Backup.WaitUntilReady = &prodWaitBool
+		if instance.Spec.Environment == "dev" || instance.Spec.Environment == "qa" {
+			devWaitBool := false
+			instance.Spec.Backup.WaitUntilReady = &devWaitBool
+		}
+	}
+	rolloutDefaults(instance)
+	autoRollbackDefaults(instance)
+}
+
+func rolloutDefaults(instance *summonv1beta1.SummonPlatform) {
+	rollout := &instance.Spec.Rollout
+	if rollout.Strategy == "" {
+		rollout.Strategy = summonv1beta1.RolloutStrategyAllAtOnce
+	}
+	// Only fill in the canary settings when they get used, to keep them out of everyone else's specs.
+	if rollout.Strategy != summonv1beta1.RolloutStrategyCanary {
+		return
+	}
+	canary := &rollout.Canary
+	if canary.Replicas == nil {
+		val := int32(1)
+		canary.Replicas = &val
+	}
+	if canary.Duration.Duration == 0 {
+		canary.Duration.Duration = 5 * time.Minute
+	}
+	if len(canary.Checks) == 0 {
+		canary.Checks = []string{"readiness", "errorRate"}
+	}
+	if canary.MaxErrorRate == nil {
+		val := int32(5)
+		canary.MaxErrorRate = &val
+	}
+}
+
+func autoRollbackDefaults(instance *summonv1beta1.SummonPlatform) {
+	autoRollback := &instance.Spec.AutoRollback
+	if !autoRollback.Enabled {
+		return
+	}
+	if autoRollback.RolloutDeadline.Duration == 0 {
+		autoRollback.RolloutDeadline.Duration = 15 * time.Minute
+	}
+}
+
+func replicaDefaults(instance *summonv1beta1.SummonPlatform) {
 	replicas := &instance.Spec.Replicas
 	intp := func(i int32) *int32 { return &i }
 	defaultsForEnv := func(dev, qa, uat, prod int32) *int32 {
@@ -282,22 +329,290 @@ func (comp *defaultsComponent) replicaDefaults(instance *summonv1beta1.SummonPla
 	if replicas.BusinessPortal == nil {
 		replicas.BusinessPortal = defaultsForEnv(1, 1, 2, 2)
 	}
+}
 
-	// If no comp-dispatch version is set, override dispatch replicas to 0.
-	if instance.Spec.Dispatch.Version == "" {
-		replicas.Dispatch = intp(0)
+func autoscalingDefaults(instance *summonv1beta1.SummonPlatform) {
+	defaultsForProcess := func(autoscaling *summonv1beta1.ProcessAutoscalingSpec, replicas *int32) {
+		if autoscaling == nil {
+			return
+		}
+		if autoscaling.MinReplicas == nil {
+			// An HPA can't go below 1.
+			val := int32(1)
+			if replicas != nil && *replicas > 1 {
+				val = *replicas
+			}
+			autoscaling.MinReplicas = &val
+		}
+		if autoscaling.TargetCPUUtilization == nil && len(autoscaling.Metrics) == 0 {
+			val := int32(70)
+			autoscaling.TargetCPUUtilization = &val
+		}
 	}
-	// Same for comp-buisness-portal.
-	if instance.Spec.BusinessPortal.Version == "" {
-		replicas.BusinessPortal = intp(0)
+	defaultsForProcess(instance.Spec.Autoscaling.Web, instance.Spec.Replicas.Web)
+	defaultsForProcess(instance.Spec.Autoscaling.Celeryd, instance.Spec.Replicas.Celeryd)
+	defaultsForProcess(instance.Spec.Autoscaling.ChannelWorker, instance.Spec.Replicas.ChannelWorker)
+
+	celery := instance.Spec.Celery.Autoscaling
+	if celery != nil {
+		if celery.MinReplicas == nil {
+			val := int32(1)
+			if instance.Spec.Replicas.Celeryd != nil && *instance.Spec.Replicas.Celeryd > 1 {
+				val = *instance.Spec.Replicas.Celeryd
+			}
+			celery.MinReplicas = &val
+		}
+		if celery.MessagesPerReplica == 0 {
+			celery.MessagesPerReplica = 100
+		}
+		if celery.ScaleUpCooldown.Duration == 0 {
+			celery.ScaleUpCooldown.Duration = time.Minute
+		}
+		if celery.ScaleDownCooldown.Duration == 0 {
+			celery.ScaleDownCooldown.Duration = 5 * time.Minute
+		}
 	}
+}
 
-	// Quick error check.
-	if !(*replicas.CeleryBeat == 0 || *replicas.CeleryBeat == 1) {
-		return errors.Errorf("Invalid celerybeat replicas, must be exactly 0 or 1: %v", *replicas.CeleryBeat)
+// ValidateSpec checks for problems defaulting can't fix, used both by the admission webhook and on every
+// reconcile so specs created before the webhook existed are still caught.
+func ValidateSpec(instance *summonv1beta1.SummonPlatform) field.ErrorList {
+	errs := field.ErrorList{}
+	specPath := field.NewPath("spec")
+	if instance.Spec.Version == "" && instance.Spec.AutoDeploy == "" {
+		errs = append(errs, field.Required(specPath.Child("version"), "Spec.Version OR Spec.AutoDeploy must be set. No Version set for deployment."))
+	}
+	if instance.Spec.Version != "" && instance.Spec.AutoDeploy != "" {
+		errs = append(errs, field.Invalid(specPath.Child("autoDeploy"), instance.Spec.AutoDeploy, "Spec.Version and Spec.AutoDeploy are both set. Must specify only one."))
 	}
+	// If the persistentVolumeClaim for redis changes this integer should as well.
+	if instance.Spec.Redis.RAM > 10 {
+		errs = append(errs, field.Invalid(specPath.Child("redis", "ram"), instance.Spec.Redis.RAM, "redis memory limit cannot surpass available disk space"))
+	}
+	celeryBeat := instance.Spec.Replicas.CeleryBeat
+	if celeryBeat != nil && !(*celeryBeat == 0 || *celeryBeat == 1) {
+		errs = append(errs, field.Invalid(specPath.Child("replicas", "celeryBeat"), *celeryBeat, "Invalid celerybeat replicas, must be exactly 0 or 1"))
+	}
+	autoscalingPath := specPath.Child("autoscaling")
+	errs = append(errs, validateAutoscaling(instance.Spec.Autoscaling.Web, autoscalingPath.Child("web"))...)
+	errs = append(errs, validateAutoscaling(instance.Spec.Autoscaling.Celeryd, autoscalingPath.Child("celeryd"))...)
+	errs = append(errs, validateAutoscaling(instance.Spec.Autoscaling.ChannelWorker, autoscalingPath.Child("channelWorker"))...)
+	celery := instance.Spec.Celery.Autoscaling
+	if celery != nil {
+		celeryPath := specPath.Child("celery", "autoscaling")
+		if instance.Spec.Autoscaling.Celeryd != nil {
+			errs = append(errs, field.Forbidden(celeryPath, "cannot be combined with spec.autoscaling.celeryd"))
+		}
+		if celery.MaxReplicas < 1 {
+			errs = append(errs, field.Invalid(celeryPath.Child("maxReplicas"), celery.MaxReplicas, "must be at least 1"))
+		}
+		if celery.MinReplicas != nil && *celery.MinReplicas > celery.MaxReplicas {
+			errs = append(errs, field.Invalid(celeryPath.Child("minReplicas"), *celery.MinReplicas, "cannot be greater than maxReplicas"))
+		}
+		if celery.MinReplicas != nil && *celery.MinReplicas < 0 {
+			errs = append(errs, field.Invalid(celeryPath.Child("minReplicas"), *celery.MinReplicas, "cannot be negative"))
+		}
+		if celery.MessagesPerReplica < 0 {
+			errs = append(errs, field.Invalid(celeryPath.Child("messagesPerReplica"), celery.MessagesPerReplica, "cannot be negative"))
+		}
+	}
+	overrides := instance.Spec.PodOverrides
+	overridesPath := specPath.Child("podOverrides")
+	errs = append(errs, validatePodOverride(overrides.Web, overridesPath.Child("web"))...)
+	errs = append(errs, validatePodOverride(overrides.Daphne, overridesPath.Child("daphne"))...)
+	errs = append(errs, validatePodOverride(overrides.Celeryd, overridesPath.Child("celeryd"))...)
+	errs = append(errs, validatePodOverride(overrides.CeleryBeat, overridesPath.Child("celeryBeat"))...)
+	errs = append(errs, validatePodOverride(overrides.ChannelWorker, overridesPath.Child("channelWorker"))...)
+	errs = append(errs, validatePodOverride(overrides.Static, overridesPath.Child("static"))...)
+	errs = append(errs, validatePodOverride(overrides.Dispatch, overridesPath.Child("dispatch"))...)
+	errs = append(errs, validatePodOverride(overrides.BusinessPortal, overridesPath.Child("businessPortal"))...)
+	for i, window := range instance.Spec.DeployWindows {
+		errs = append(errs, validateDeployWindow(window, specPath.Child("deployWindows").Index(i))...)
+	}
+	schedulesPath := specPath.Child("hibernation", "schedules")
+	for i, schedule := range instance.Spec.Hibernation.Schedules {
+		// Same fields as a deploy window.
+		errs = append(errs, validateDeployWindow(summonv1beta1.DeployWindowSpec(schedule), schedulesPath.Index(i))...)
+	}
+	if len(instance.Spec.Hibernation.Schedules) > 0 && productionEnvironment(instance) {
+		errs = append(errs, field.Forbidden(schedulesPath, "uat and prod instances cannot hibernate"))
+	}
+	if cloneFrom := instance.Spec.CloneFrom; cloneFrom != nil {
+		clonePath := specPath.Child("cloneFrom")
+		if cloneFrom.Instance == "" {
+			errs = append(errs, field.Required(clonePath.Child("instance"), "instance to clone from must be set"))
+		}
+		if cloneFrom.Instance == instance.Name && (cloneFrom.Namespace == "" || cloneFrom.Namespace == instance.Namespace) {
+			errs = append(errs, field.Invalid(clonePath.Child("instance"), cloneFrom.Instance, "cannot clone an instance from itself"))
+		}
+		if cloneFrom.PointInTime != "" {
+			if cloneFrom.SnapshotID != "" {
+				errs = append(errs, field.Forbidden(clonePath.Child("pointInTime"), "cannot be combined with snapshotID"))
+			}
+			_, err := time.Parse(time.RFC3339, cloneFrom.PointInTime)
+			if err != nil {
+				errs = append(errs, field.Invalid(clonePath.Child("pointInTime"), cloneFrom.PointInTime, "must be an RFC3339 time"))
+			}
+		}
+	}
+	canary := instance.Spec.Rollout.Canary
+	canaryPath := specPath.Child("rollout", "canary")
+	if canary.Replicas != nil && *canary.Replicas < 1 {
+		errs = append(errs, field.Invalid(canaryPath.Child("replicas"), *canary.Replicas, "canary needs at least 1 replica"))
+	}
+	if canary.MaxErrorRate != nil && (*canary.MaxErrorRate < 0 || *canary.MaxErrorRate > 100) {
+		errs = append(errs, field.Invalid(canaryPath.Child("maxErrorRate"), *canary.MaxErrorRate, "must be a percentage between 0 and 100"))
+	}
+	for i, check := range canary.Checks {
+		if _, ok := canaryChecks[check]; !ok {
+			errs = append(errs, field.NotSupported(canaryPath.Child("checks").Index(i), check, canaryCheckNames()))
+		}
+	}
+	for i, check := range instance.Spec.SelfCheck.Checks {
+		errs = append(errs, validateHTTPCheck(check, specPath.Child("selfCheck", "checks").Index(i))...)
+	}
+	autoRollback := instance.Spec.AutoRollback
+	autoRollbackPath := specPath.Child("autoRollback")
+	if autoRollback.HistoryLimit < 0 {
+		errs = append(errs, field.Invalid(autoRollbackPath.Child("historyLimit"), autoRollback.HistoryLimit, "must not be negative"))
+	}
+	if autoRollback.RolloutDeadline.Duration < 0 {
+		errs = append(errs, field.Invalid(autoRollbackPath.Child("rolloutDeadline"), autoRollback.RolloutDeadline.Duration.String(), "must not be negative"))
+	}
+	return errs
+}
 
-	return nil
+// ValidateSpecUpdate checks for changes which are only allowed when creating an instance. Only the admission
+// webhook sees the old object, so this can't be caught on reconcile.
+func ValidateSpecUpdate(old, instance *summonv1beta1.SummonPlatform) field.ErrorList {
+	errs := field.ErrorList{}
+	cloneFromPath := field.NewPath("spec", "cloneFrom")
+	// Removing cloneFrom is fine, it's only read while the clone runs.
+	if instance.Spec.CloneFrom != nil && !reflect.DeepEqual(old.Spec.CloneFrom, instance.Spec.CloneFrom) {
+		if old.Spec.CloneFrom == nil {
+			errs = append(errs, field.Forbidden(cloneFromPath, "cloneFrom can only be set when creating an instance"))
+		} else {
+			errs = append(errs, field.Forbidden(cloneFromPath, "cloneFrom can't be changed"))
+		}
+	}
+	return errs
+}
+
+func validateAutoscaling(autoscaling *summonv1beta1.ProcessAutoscalingSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if autoscaling == nil {
+		return errs
+	}
+	if autoscaling.MaxReplicas < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxReplicas"), autoscaling.MaxReplicas, "must be at least 1"))
+	}
+	if autoscaling.MinReplicas != nil {
+		if *autoscaling.MinReplicas < 1 {
+			errs = append(errs, field.Invalid(fldPath.Child("minReplicas"), *autoscaling.MinReplicas, "must be at least 1"))
+		} else if *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+			errs = append(errs, field.Invalid(fldPath.Child("minReplicas"), *autoscaling.MinReplicas, "cannot be greater than maxReplicas"))
+		}
+	}
+	if autoscaling.TargetCPUUtilization != nil && *autoscaling.TargetCPUUtilization < 1 {
+		errs = append(errs, field.Invalid(fldPath.Child("targetCPUUtilization"), *autoscaling.TargetCPUUtilization, "must be at least 1"))
+	}
+	for i, metric := range autoscaling.Metrics {
+		metricPath := fldPath.Child("metrics").Index(i)
+		if metric.Name == "" {
+			errs = append(errs, field.Required(metricPath.Child("name"), "metric name is required"))
+		}
+		_, err := resource.ParseQuantity(metric.TargetAverageValue)
+		if err != nil {
+			errs = append(errs, field.Invalid(metricPath.Child("targetAverageValue"), metric.TargetAverageValue, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validateHTTPCheck(check summonv1beta1.HTTPCheckSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	validService := false
+	for _, service := range selfCheckServiceNames() {
+		validService = validService || check.Service == service
+	}
+	if !validService {
+		errs = append(errs, field.NotSupported(fldPath.Child("service"), check.Service, selfCheckServiceNames()))
+	}
+	if check.Path != "" && !strings.HasPrefix(check.Path, "/") {
+		errs = append(errs, field.Invalid(fldPath.Child("path"), check.Path, "must start with /"))
+	}
+	for i, status := range check.ExpectedStatus {
+		if status < 100 || status > 599 {
+			errs = append(errs, field.Invalid(fldPath.Child("expectedStatus").Index(i), status, "must be an HTTP status code"))
+		}
+	}
+	if check.Timeout.Duration < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("timeout"), check.Timeout.Duration.String(), "must not be negative"))
+	}
+	return errs
+}
+
+func validateDeployWindow(window summonv1beta1.DeployWindowSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, day := range window.Days {
+		if _, ok := deployWindowDays[day]; !ok {
+			errs = append(errs, field.NotSupported(fldPath.Child("days").Index(i), day, deployWindowDayNames()))
+		}
+	}
+	_, err := time.Parse("15:04", window.Start)
+	if err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("start"), window.Start, "must be a 24-hour time like 22:30"))
+	}
+	if window.Duration.Duration <= 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("duration"), window.Duration.Duration.String(), "must be positive"))
+	}
+	_, err = time.LoadLocation(window.Timezone)
+	if err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("timezone"), window.Timezone, err.Error()))
+	}
+	return errs
+}
+
+func validatePodOverride(override *summonv1beta1.PodOverrideSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if override == nil {
+		return errs
+	}
+	for name, request := range override.Resources.Requests {
+		limit, ok := override.Resources.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("resources", "requests").Key(string(name)), request.String(), fmt.Sprintf("cannot be greater than the limit of %s", limit.String())))
+		}
+	}
+	for i, toleration := range override.Tolerations {
+		tolerationPath := fldPath.Child("tolerations").Index(i)
+		switch toleration.Operator {
+		case "", corev1.TolerationOpEqual:
+		case corev1.TolerationOpExists:
+			if toleration.Value != "" {
+				errs = append(errs, field.Invalid(tolerationPath.Child("value"), toleration.Value, "must be empty when operator is Exists"))
+			}
+		default:
+			errs = append(errs, field.NotSupported(tolerationPath.Child("operator"), toleration.Operator, []string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, field.NotSupported(tolerationPath.Child("effect"), toleration.Effect, []string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}))
+		}
+	}
+	for i, envVar := range override.Env {
+		if envVar.Name == "" {
+			errs = append(errs, field.Required(fldPath.Child("env").Index(i).Child("name"), "env var name is required"))
+		}
+	}
+	// The config and secret hashes live under summon.ridecell.io, overriding them would break restarts on changes.
+	for key := range override.Annotations {
+		if strings.HasPrefix(key, "summon.ridecell.io/") {
+			errs = append(errs, field.Forbidden(fldPath.Child("annotations").Key(key), "summon.ridecell.io annotations are reserved for the operator"))
+		}
+	}
+	return errs
 }
 
 func defConfig(key string, value interface{}) {
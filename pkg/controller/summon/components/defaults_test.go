@@ -17,9 +17,15 @@ limitations under the License.
 package components_test
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
@@ -207,6 +213,123 @@ var _ = Describe("SummonPlatform Defaults Component", func() {
 		Expect(instance.Spec.Config["FIREBASE_APP"].String).To(PointTo(Equal("foo")))
 	})
 
+	It("defaults autoscaling settings from the replicas", func() {
+		instance.Spec.Replicas.Web = intp(4)
+		instance.Spec.Autoscaling.Web = &summonv1beta1.ProcessAutoscalingSpec{MaxReplicas: 20}
+		instance.Spec.Autoscaling.Celeryd = &summonv1beta1.ProcessAutoscalingSpec{
+			MaxReplicas: 10,
+			Metrics:     []summonv1beta1.AutoscalingMetricSpec{{Name: "celery_tasks_active", TargetAverageValue: "2"}},
+		}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Spec.Autoscaling.Web.MinReplicas).To(PointTo(BeEquivalentTo(4)))
+		Expect(instance.Spec.Autoscaling.Web.TargetCPUUtilization).To(PointTo(BeEquivalentTo(70)))
+		Expect(instance.Spec.Autoscaling.Celeryd.MinReplicas).To(PointTo(BeEquivalentTo(1)))
+		Expect(instance.Spec.Autoscaling.Celeryd.TargetCPUUtilization).To(BeNil())
+		Expect(instance.Spec.Autoscaling.ChannelWorker).To(BeNil())
+	})
+
+	It("errors when autoscaling minReplicas is above maxReplicas", func() {
+		instance.Spec.Autoscaling.Web = &summonv1beta1.ProcessAutoscalingSpec{MinReplicas: intp(5), MaxReplicas: 2}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.autoscaling.web.minReplicas")))
+	})
+
+	It("errors on a bad autoscaling metric target", func() {
+		instance.Spec.Autoscaling.Celeryd = &summonv1beta1.ProcessAutoscalingSpec{
+			MaxReplicas: 10,
+			Metrics:     []summonv1beta1.AutoscalingMetricSpec{{Name: "celery_tasks_active", TargetAverageValue: "lots"}},
+		}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.autoscaling.celeryd.metrics[0].targetAverageValue")))
+	})
+
+	It("errors on invalid pod overrides", func() {
+		instance.Spec.PodOverrides.Web = &summonv1beta1.PodOverrideSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4G")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2G")},
+			},
+		}
+		instance.Spec.PodOverrides.Celeryd = &summonv1beta1.PodOverrideSpec{
+			Annotations: map[string]string{"summon.ridecell.io/configHash": "nope"},
+		}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.podOverrides.web.resources.requests[memory]")))
+		Expect(err).To(MatchError(ContainSubstring("spec.podOverrides.celeryd.annotations[summon.ridecell.io/configHash]")))
+	})
+
+	It("errors on invalid deploy windows", func() {
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{{
+			Days:     []string{"Mon", "Funday"},
+			Start:    "25:00",
+			Timezone: "Mars/Olympus_Mons",
+		}}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.deployWindows[0].days[1]")))
+		Expect(err).To(MatchError(ContainSubstring("spec.deployWindows[0].start")))
+		Expect(err).To(MatchError(ContainSubstring("spec.deployWindows[0].duration")))
+		Expect(err).To(MatchError(ContainSubstring("spec.deployWindows[0].timezone")))
+	})
+
+	It("errors on hibernation schedules for prod", func() {
+		instance.Spec.Environment = "prod"
+		instance.Spec.Hibernation.Schedules = []summonv1beta1.HibernationScheduleSpec{{
+			Start:    "20:00",
+			Duration: metav1.Duration{Duration: 12 * time.Hour},
+		}}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.hibernation.schedules: Forbidden")))
+	})
+
+	It("errors on a bad clone point in time", func() {
+		instance.Spec.CloneFrom = &summonv1beta1.CloneFromSpec{Instance: "bar-uat", PointInTime: "yesterday"}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.cloneFrom.pointInTime")))
+	})
+
+	It("fills in config copied from a clone source below the instance's own", func() {
+		on := "on"
+		chicago := "America/Chicago"
+		utc := "UTC"
+		instance.Spec.Config = map[string]summonv1beta1.ConfigValue{"TIME_ZONE": summonv1beta1.ConfigValue{String: &utc}}
+		instance.Status.Clone.Config = map[string]summonv1beta1.ConfigValue{
+			"FEATURE_X": summonv1beta1.ConfigValue{String: &on},
+			"TIME_ZONE": summonv1beta1.ConfigValue{String: &chicago},
+		}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Spec.Config["FEATURE_X"].String).To(PointTo(Equal("on")))
+		Expect(instance.Spec.Config["TIME_ZONE"].String).To(PointTo(Equal("UTC")))
+	})
+
+	It("errors when celery queue autoscaling is combined with a celeryd HPA", func() {
+		instance.Spec.Celery.Autoscaling = &summonv1beta1.CeleryAutoscalingSpec{MaxReplicas: 10}
+		instance.Spec.Autoscaling.Celeryd = &summonv1beta1.ProcessAutoscalingSpec{MaxReplicas: 10}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.celery.autoscaling")))
+	})
+
+	It("defaults to the AllAtOnce rollout strategy", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Spec.Rollout.Strategy).To(Equal(summonv1beta1.RolloutStrategyAllAtOnce))
+		Expect(instance.Spec.Rollout.Canary.Replicas).To(BeNil())
+	})
+
+	It("sets canary defaults for the Canary rollout strategy", func() {
+		instance.Spec.Rollout.Strategy = summonv1beta1.RolloutStrategyCanary
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Spec.Rollout.Canary.Replicas).To(PointTo(BeEquivalentTo(1)))
+		Expect(instance.Spec.Rollout.Canary.Duration.Duration).To(Equal(5 * time.Minute))
+		Expect(instance.Spec.Rollout.Canary.Checks).To(Equal([]string{"readiness", "errorRate"}))
+		Expect(instance.Spec.Rollout.Canary.MaxErrorRate).To(PointTo(BeEquivalentTo(5)))
+	})
+
+	It("errors on an unknown canary check", func() {
+		instance.Spec.Rollout.Strategy = summonv1beta1.RolloutStrategyCanary
+		instance.Spec.Rollout.Canary.Checks = []string{"readiness", "vibes"}
+		_, err := comp.Reconcile(ctx)
+		Expect(err).To(MatchError(ContainSubstring("spec.rollout.canary.checks[1]")))
+	})
+
 	It("errors if Spec.Version and Spec.Autodeploy are both set", func() {
 		instance.Spec.AutoDeploy = "test-branch"
 		_, err := comp.Reconcile(ctx)
@@ -237,4 +360,56 @@ var _ = Describe("SummonPlatform Defaults Component", func() {
 			Expect(instance.Spec.Replicas.CeleryBeat).To(PointTo(BeEquivalentTo(1)))
 		})
 	})
+
+	Context("with region profiles", func() {
+		It("uses the built-in settings for a known region", func() {
+			instance.ObjectMeta.Name = "foo-prod"
+			instance.ObjectMeta.Namespace = "summon-prod"
+			instance.Spec = summonv1beta1.SummonPlatformSpec{Version: "1.2.3", AwsRegion: "eu-central-1"}
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Spec.SQSQueue).To(Equal("eu-prod-data-pipeline"))
+			Expect(instance.Spec.Hostname).To(Equal("foo-prod.ridecell.com"))
+			Expect(instance.Spec.Config["GATEWAY_BASE_URL"].String).To(PointTo(Equal("https://global.eu.prod.svc.ridecell.io/")))
+		})
+
+		It("reads everything from a RegionProfile object", func() {
+			profile := &summonv1beta1.RegionProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "mars"},
+				Spec: summonv1beta1.RegionProfileSpec{
+					Region:        "mars-north-1",
+					GatewayRegion: "mars",
+					SQSQueues:     map[string]string{"default": "mars-data-pipeline"},
+					BaseHostnames: map[string]string{"default": "ridecell.mars"},
+					DbConfigRef:   corev1.ObjectReference{Name: "mars-db", Namespace: "dbconfigs"},
+				},
+			}
+			ctx.Client = fake.NewFakeClient(instance, profile)
+			instance.Spec = summonv1beta1.SummonPlatformSpec{Version: "1.2.3", RegionProfile: "mars"}
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Spec.AwsRegion).To(Equal("mars-north-1"))
+			Expect(instance.Spec.SQSQueue).To(Equal("mars-data-pipeline"))
+			Expect(instance.Spec.Hostname).To(Equal("foo-dev.ridecell.mars"))
+			Expect(instance.Spec.Database.DbConfigRef.Name).To(Equal("mars-db"))
+			Expect(instance.Spec.Config["AWS_REGION"].String).To(PointTo(Equal("mars-north-1")))
+			Expect(instance.Spec.Config["GATEWAY_BASE_URL"].String).To(PointTo(Equal("https://global.mars.master.svc.ridecell.io/")))
+		})
+
+		It("keeps an explicit dbConfigRef", func() {
+			profile := &summonv1beta1.RegionProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "mars"},
+				Spec: summonv1beta1.RegionProfileSpec{
+					Region:      "mars-north-1",
+					DbConfigRef: corev1.ObjectReference{Name: "mars-db"},
+				},
+			}
+			ctx.Client = fake.NewFakeClient(instance, profile)
+			instance.Spec.RegionProfile = "mars"
+			instance.Spec.Database.DbConfigRef.Name = "other-db"
+
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Spec.Database.DbConfigRef.Name).To(Equal("other-db"))
+		})
+	})
 })
@@ -0,0 +1,225 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/errors"
+)
+
+// Number of deploys kept in status.deployHistory when spec.autoRollback.historyLimit isn't set.
+const defaultDeployHistoryLimit = 10
+
+func deployHistoryLimit(instance *summonv1beta1.SummonPlatform) int {
+	if instance.Spec.AutoRollback.HistoryLimit > 0 {
+		return instance.Spec.AutoRollback.HistoryLimit
+	}
+	return defaultDeployHistoryLimit
+}
+
+// Deploy phases, named after the PhaseDurations fields.
+const (
+	deployPhaseWaitingForWindow = "waitingForWindow"
+	deployPhaseBackup           = "backup"
+	deployPhaseMigrate          = "migrate"
+	deployPhasePostMigrateWait  = "postMigrateWait"
+	deployPhaseRollout          = "rollout"
+)
+
+type deployHistoryComponent struct{}
+
+func NewDeployHistory() *deployHistoryComponent {
+	return &deployHistoryComponent{}
+}
+
+func (_ *deployHistoryComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{}
+}
+
+func (_ *deployHistoryComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return true
+}
+
+// Reconcile looks at where the other components left the status and updates the latest deploy record to match,
+// so it has to be registered after all of them.
+func (_ *deployHistoryComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	now := time.Now()
+	if !deployHistoryChanged(instance, now) {
+		return components.Result{}, nil
+	}
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		updateDeployHistory(instance, now)
+		return nil
+	}}, nil
+}
+
+// ReconcileError implements components.ErrorHandler.
+func (_ *deployHistoryComponent) ReconcileError(ctx *components.ComponentContext, err error) (components.Result, error) {
+	if !errors.ShouldNotify(err) {
+		return components.Result{}, nil
+	}
+	message := err.Error()
+	now := time.Now()
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		history := instance.Status.DeployHistory
+		if len(history) > 0 && history[0].ToVersion == instance.Spec.Version && history[0].EndTime == "" {
+			finishDeploy(&history[0], summonv1beta1.DeployOutcomeFailed, message, now)
+		}
+		return nil
+	}}, nil
+}
+
+// Check against a copy, to avoid a status write on every reconcile.
+func deployHistoryChanged(instance *summonv1beta1.SummonPlatform, now time.Time) bool {
+	updated := instance.DeepCopy()
+	updateDeployHistory(updated, now)
+	if len(updated.Status.DeployHistory) != len(instance.Status.DeployHistory) {
+		return true
+	}
+	for i := range updated.Status.DeployHistory {
+		if updated.Status.DeployHistory[i] != instance.Status.DeployHistory[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func deployPhaseFor(status string) string {
+	switch status {
+	case summonv1beta1.StatusWaitingForWindow:
+		return deployPhaseWaitingForWindow
+	case summonv1beta1.StatusCreatingBackup:
+		return deployPhaseBackup
+	case summonv1beta1.StatusMigrating:
+		return deployPhaseMigrate
+	case summonv1beta1.StatusPostMigrateWait:
+		return deployPhasePostMigrateWait
+	case summonv1beta1.StatusDeploying:
+		return deployPhaseRollout
+	default:
+		return ""
+	}
+}
+
+// Add the time since the current phase started to its total, and start the next one.
+func switchDeployPhase(record *summonv1beta1.DeployRecord, phase string, now time.Time) {
+	if record.Phase == phase {
+		return
+	}
+	if record.Phase != "" {
+		started, err := time.Parse(time.UnixDate, record.PhaseStartTime)
+		if err != nil {
+			// Not worth failing the reconcile over, just lose the time for this phase.
+			glog.Errorf("deploy_history: unable to parse phase start time %#v: %s\n", record.PhaseStartTime, err)
+		} else {
+			var total *time.Duration
+			switch record.Phase {
+			case deployPhaseWaitingForWindow:
+				total = &record.PhaseDurations.WaitingForWindow.Duration
+			case deployPhaseBackup:
+				total = &record.PhaseDurations.Backup.Duration
+			case deployPhaseMigrate:
+				total = &record.PhaseDurations.Migrate.Duration
+			case deployPhasePostMigrateWait:
+				total = &record.PhaseDurations.PostMigrateWait.Duration
+			case deployPhaseRollout:
+				total = &record.PhaseDurations.Rollout.Duration
+			}
+			if total != nil {
+				*total += now.Sub(started).Round(time.Second)
+			}
+		}
+	}
+	record.Phase = phase
+	record.PhaseStartTime = ""
+	if phase != "" {
+		record.PhaseStartTime = now.Format(time.UnixDate)
+	}
+}
+
+func finishDeploy(record *summonv1beta1.DeployRecord, outcome string, message string, now time.Time) {
+	switchDeployPhase(record, "", now)
+	record.Outcome = outcome
+	record.Message = message
+	record.EndTime = now.Format(time.UnixDate)
+}
+
+func updateDeployHistory(instance *summonv1beta1.SummonPlatform, now time.Time) {
+	version := instance.Spec.Version
+	status := instance.Status.Status
+	history := instance.Status.DeployHistory
+	if version == "" {
+		return
+	}
+
+	if len(history) == 0 || history[0].ToVersion != version {
+		if status == summonv1beta1.StatusReady || status == summonv1beta1.StatusError || status == summonv1beta1.StatusHibernating || status == "" {
+			// Only a version which is actually moving through the deploy phases counts, so instances which
+			// were deployed before the history existed don't get a made up entry.
+			return
+		}
+		if len(history) > 0 && history[0].EndTime == "" {
+			finishDeploy(&history[0], summonv1beta1.DeployOutcomeSuperseded, "", now)
+		}
+		trigger := summonv1beta1.DeployTriggerManual
+		if instance.Spec.AutoDeploy != "" {
+			trigger = summonv1beta1.DeployTriggerAutoDeploy
+		}
+		record := summonv1beta1.DeployRecord{
+			FromVersion: instance.Status.Rollout.StableVersion,
+			ToVersion:   version,
+			Trigger:     trigger,
+			StartTime:   now.Format(time.UnixDate),
+			Outcome:     summonv1beta1.DeployOutcomeInProgress,
+		}
+		history = append([]summonv1beta1.DeployRecord{record}, history...)
+		if limit := deployHistoryLimit(instance); len(history) > limit {
+			history = history[:limit]
+		}
+		instance.Status.DeployHistory = history
+	}
+
+	record := &history[0]
+	switch {
+	case record.Outcome == summonv1beta1.DeployOutcomeSucceeded || record.Outcome == summonv1beta1.DeployOutcomeRolledBack || record.Outcome == summonv1beta1.DeployOutcomeSuperseded:
+		// Already finished. Later blips on the same version, like a Deployment becoming unavailable, aren't part
+		// of the deploy.
+	case status == summonv1beta1.StatusReady:
+		finishDeploy(record, summonv1beta1.DeployOutcomeSucceeded, "", now)
+	case status == summonv1beta1.StatusRolledBack:
+		finishDeploy(record, summonv1beta1.DeployOutcomeRolledBack, instance.Status.Message, now)
+	case status == summonv1beta1.StatusError:
+		if record.Outcome != summonv1beta1.DeployOutcomeFailed {
+			finishDeploy(record, summonv1beta1.DeployOutcomeFailed, instance.Status.Message, now)
+		}
+	default:
+		// Still going, or going again after an error cleared up.
+		record.Outcome = summonv1beta1.DeployOutcomeInProgress
+		record.Message = ""
+		record.EndTime = ""
+		switchDeployPhase(record, deployPhaseFor(status), now)
+	}
+}
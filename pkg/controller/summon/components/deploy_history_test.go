@@ -0,0 +1,172 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform deploy history", func() {
+	comp := summoncomponents.NewDeployHistory()
+
+	ago := func(d time.Duration) string {
+		return time.Now().Add(-d).Format(time.UnixDate)
+	}
+
+	BeforeEach(func() {
+		comp = summoncomponents.NewDeployHistory()
+		instance.Status.Rollout.StableVersion = "1.2.2"
+	})
+
+	It("doesn't make up a deploy for an instance which is already ready", func() {
+		instance.Status.Status = summonv1beta1.StatusReady
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory).To(BeEmpty())
+	})
+
+	It("starts a record for a new version", func() {
+		instance.Status.Status = summonv1beta1.StatusCreatingBackup
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory).To(HaveLen(1))
+		record := instance.Status.DeployHistory[0]
+		Expect(record.FromVersion).To(Equal("1.2.2"))
+		Expect(record.ToVersion).To(Equal("1.2.3"))
+		Expect(record.Trigger).To(Equal(summonv1beta1.DeployTriggerManual))
+		Expect(record.Outcome).To(Equal(summonv1beta1.DeployOutcomeInProgress))
+		Expect(record.Phase).To(Equal("backup"))
+	})
+
+	It("marks autodeploys", func() {
+		instance.Spec.AutoDeploy = "master"
+		instance.Status.Status = summonv1beta1.StatusMigrating
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory[0].Trigger).To(Equal(summonv1beta1.DeployTriggerAutoDeploy))
+	})
+
+	It("adds up the time spent in each phase", func() {
+		instance.Status.DeployHistory = []summonv1beta1.DeployRecord{{
+			ToVersion:      "1.2.3",
+			StartTime:      ago(10 * time.Minute),
+			Outcome:        summonv1beta1.DeployOutcomeInProgress,
+			Phase:          "migrate",
+			PhaseStartTime: ago(3 * time.Minute),
+		}}
+		instance.Status.DeployHistory[0].PhaseDurations.Migrate.Duration = 2 * time.Minute
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		Expect(comp).To(ReconcileContext(ctx))
+		record := instance.Status.DeployHistory[0]
+		Expect(record.PhaseDurations.Migrate.Duration).To(BeNumerically("~", 5*time.Minute, 2*time.Second))
+		Expect(record.Phase).To(Equal("rollout"))
+
+		instance.Status.DeployHistory[0].PhaseStartTime = ago(time.Minute)
+		instance.Status.Status = summonv1beta1.StatusReady
+		Expect(comp).To(ReconcileContext(ctx))
+		record = instance.Status.DeployHistory[0]
+		Expect(record.Outcome).To(Equal(summonv1beta1.DeployOutcomeSucceeded))
+		Expect(record.EndTime).ToNot(BeEmpty())
+		Expect(record.Phase).To(BeEmpty())
+		Expect(record.PhaseDurations.Rollout.Duration).To(BeNumerically("~", time.Minute, 2*time.Second))
+	})
+
+	It("marks an unfinished deploy as superseded", func() {
+		instance.Status.DeployHistory = []summonv1beta1.DeployRecord{{
+			ToVersion:      "1.2.3",
+			StartTime:      ago(10 * time.Minute),
+			Outcome:        summonv1beta1.DeployOutcomeInProgress,
+			Phase:          "migrate",
+			PhaseStartTime: ago(time.Minute),
+		}}
+		instance.Spec.Version = "1.2.4"
+		instance.Status.Status = summonv1beta1.StatusCreatingBackup
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory).To(HaveLen(2))
+		Expect(instance.Status.DeployHistory[0].ToVersion).To(Equal("1.2.4"))
+		Expect(instance.Status.DeployHistory[1].Outcome).To(Equal(summonv1beta1.DeployOutcomeSuperseded))
+	})
+
+	It("records failures and picks back up after them", func() {
+		instance.Status.DeployHistory = []summonv1beta1.DeployRecord{{
+			ToVersion:      "1.2.3",
+			StartTime:      ago(10 * time.Minute),
+			Outcome:        summonv1beta1.DeployOutcomeInProgress,
+			Phase:          "rollout",
+			PhaseStartTime: ago(time.Minute),
+		}}
+		instance.Status.Status = summonv1beta1.StatusError
+		instance.Status.Message = "Canary for version 1.2.3 failed"
+		Expect(comp).To(ReconcileContext(ctx))
+		record := instance.Status.DeployHistory[0]
+		Expect(record.Outcome).To(Equal(summonv1beta1.DeployOutcomeFailed))
+		Expect(record.Message).To(Equal("Canary for version 1.2.3 failed"))
+
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		Expect(comp).To(ReconcileContext(ctx))
+		record = instance.Status.DeployHistory[0]
+		Expect(record.Outcome).To(Equal(summonv1beta1.DeployOutcomeInProgress))
+		Expect(record.EndTime).To(BeEmpty())
+	})
+
+	It("records rollbacks", func() {
+		instance.Status.DeployHistory = []summonv1beta1.DeployRecord{{
+			ToVersion: "1.2.3",
+			StartTime: ago(time.Minute),
+			Outcome:   summonv1beta1.DeployOutcomeInProgress,
+		}}
+		instance.Status.Status = summonv1beta1.StatusRolledBack
+		instance.Status.Message = "Version 1.2.3 failed migrations, rolled back to 1.2.2"
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory[0].Outcome).To(Equal(summonv1beta1.DeployOutcomeRolledBack))
+		Expect(instance.Status.DeployHistory[0].Message).To(ContainSubstring("rolled back"))
+	})
+
+	It("keeps a bounded history", func() {
+		for i := 0; i < 10; i++ {
+			instance.Status.DeployHistory = append(instance.Status.DeployHistory, summonv1beta1.DeployRecord{
+				ToVersion: fmt.Sprintf("1.1.%d", 9-i),
+				Outcome:   summonv1beta1.DeployOutcomeSucceeded,
+			})
+		}
+		instance.Status.Status = summonv1beta1.StatusMigrating
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory).To(HaveLen(10))
+		Expect(instance.Status.DeployHistory[0].ToVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.DeployHistory[9].ToVersion).To(Equal("1.1.1"))
+	})
+
+	It("keeps the configured number of deploys", func() {
+		instance.Spec.AutoRollback.HistoryLimit = 3
+		for i := 0; i < 5; i++ {
+			instance.Status.DeployHistory = append(instance.Status.DeployHistory, summonv1beta1.DeployRecord{
+				ToVersion: fmt.Sprintf("1.1.%d", 9-i),
+				Outcome:   summonv1beta1.DeployOutcomeSucceeded,
+			})
+		}
+		instance.Status.Status = summonv1beta1.StatusMigrating
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.DeployHistory).To(HaveLen(3))
+		Expect(instance.Status.DeployHistory[0].ToVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.DeployHistory[2].ToVersion).To(Equal("1.1.8"))
+	})
+})
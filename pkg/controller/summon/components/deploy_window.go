@@ -0,0 +1,170 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// Longest we sleep before checking a closed window again, so spec changes and clock drift get picked up.
+const deployWindowRecheck = 10 * time.Minute
+
+var deployWindowDays = map[string]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+func deployWindowDayNames() []string {
+	return []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+}
+
+type parsedDeployWindow struct {
+	days     map[time.Weekday]bool
+	hour     int
+	minute   int
+	duration time.Duration
+	location *time.Location
+}
+
+func parseDeployWindow(window summonv1beta1.DeployWindowSpec) (*parsedDeployWindow, error) {
+	parsed := &parsedDeployWindow{days: map[time.Weekday]bool{}, duration: window.Duration.Duration}
+	for _, day := range window.Days {
+		weekday, ok := deployWindowDays[day]
+		if !ok {
+			return nil, errors.Errorf("unknown day %s", day)
+		}
+		parsed.days[weekday] = true
+	}
+	start, err := time.Parse("15:04", window.Start)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid start time %s", window.Start)
+	}
+	parsed.hour, parsed.minute = start.Hour(), start.Minute()
+	parsed.location, err = time.LoadLocation(window.Timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid timezone %s", window.Timezone)
+	}
+	return parsed, nil
+}
+
+// Find whether any window is open at now, and otherwise when the next one opens.
+func deployWindowState(windows []summonv1beta1.DeployWindowSpec, now time.Time) (bool, time.Time, error) {
+	end, next, err := windowState(windows, now)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if !end.IsZero() {
+		return true, time.Time{}, nil
+	}
+	return false, next, nil
+}
+
+// Find when the windows open at now close, zero if none are open, and when the next one opens.
+func windowState(windows []summonv1beta1.DeployWindowSpec, now time.Time) (time.Time, time.Time, error) {
+	var end, next time.Time
+	for _, window := range windows {
+		parsed, err := parseDeployWindow(window)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		local := now.In(parsed.location)
+		// Look back far enough to catch a window that opened on an earlier day and is still going, and forward a
+		// full week for the next opening.
+		lookback := int(parsed.duration/(24*time.Hour)) + 1
+		for offset := -lookback; offset <= 7; offset++ {
+			day := local.AddDate(0, 0, offset)
+			if len(parsed.days) > 0 && !parsed.days[day.Weekday()] {
+				continue
+			}
+			start := time.Date(day.Year(), day.Month(), day.Day(), parsed.hour, parsed.minute, 0, 0, parsed.location)
+			if !start.After(now) && now.Before(start.Add(parsed.duration)) && start.Add(parsed.duration).After(end) {
+				end = start.Add(parsed.duration)
+			}
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return end, next, nil
+}
+
+// Check if a new version has to wait for the next deploy window before backups and migrations start. Instances
+// which have never been deployed, and versions already part way through deploying, go ahead regardless.
+func holdForDeployWindow(instance *summonv1beta1.SummonPlatform, now time.Time) (*components.Result, error) {
+	if len(instance.Spec.DeployWindows) == 0 || instance.Status.MigrateVersion == "" || instance.Status.BackupVersion == instance.Spec.Version {
+		return nil, nil
+	}
+	open, next, err := deployWindowState(instance.Spec.DeployWindows, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "deploy_window: unable to check deploy windows")
+	}
+	if open {
+		return nil, nil
+	}
+
+	requeue := deployWindowRecheck
+	if until := next.Sub(now); until < requeue {
+		requeue = until
+	}
+	// Saved in UTC, zone abbreviations like PST don't parse back reliably.
+	nextWindow := next.UTC().Format(time.UnixDate)
+	message := fmt.Sprintf("Version %s is waiting for the deploy window opening at %s", instance.Spec.Version, nextWindow)
+	return &components.Result{
+		StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.Status = summonv1beta1.StatusWaitingForWindow
+			instance.Status.Message = message
+			instance.Status.NextDeployWindow = nextWindow
+			return nil
+		},
+		RequeueAfter: requeue,
+	}, nil
+}
+
+// While a new version waits for its window, or after it was rolled back, keep deploying config and secret changes
+// at the version which should be running. Returns a context whose instance has that version, or the given one if
+// neither applies.
+func deployedVersionContext(ctx *components.ComponentContext) *components.ComponentContext {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	var version string
+	switch instance.Status.Status {
+	case summonv1beta1.StatusWaitingForWindow:
+		version = instance.Status.MigrateVersion
+	case summonv1beta1.StatusRolledBack:
+		version = instance.Status.AutoRollback.RevertedTo
+	}
+	if version == "" {
+		return ctx
+	}
+	deployed := instance.DeepCopy()
+	deployed.Spec.Version = version
+	deployedCtx := *ctx
+	deployedCtx.Top = deployed
+	return &deployedCtx
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform deploy windows", func() {
+	closedWindow := func() summonv1beta1.DeployWindowSpec {
+		return summonv1beta1.DeployWindowSpec{
+			Start:    time.Now().UTC().Add(2 * time.Hour).Format("15:04"),
+			Duration: metav1.Duration{Duration: time.Hour},
+		}
+	}
+	openWindow := func() summonv1beta1.DeployWindowSpec {
+		return summonv1beta1.DeployWindowSpec{
+			Start:    time.Now().UTC().Add(-1 * time.Hour).Format("15:04"),
+			Duration: metav1.Duration{Duration: 3 * time.Hour},
+		}
+	}
+
+	BeforeEach(func() {
+		trueBool := true
+		instance.Spec.Backup.WaitUntilReady = &trueBool
+		instance.Status.MigrateVersion = "1.2.2"
+		instance.Status.BackupVersion = "1.2.2"
+		postgresDatabase := &dbv1beta1.PostgresDatabase{
+			ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace},
+			Status:     dbv1beta1.PostgresDatabaseStatus{RDSInstanceID: "test-rds-instance"},
+		}
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-config", Namespace: instance.Namespace},
+			Data:       map[string]string{"summon-platform.yml": "{}\n"},
+		}
+		appSecrets := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev.app-secrets", Namespace: instance.Namespace},
+			Data:       map[string][]byte{"filler": []byte("test")},
+		}
+		ctx.Client = fake.NewFakeClient(postgresDatabase, configMap, appSecrets)
+	})
+
+	getSnapshot := func() error {
+		return ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-1.2.3", Namespace: instance.Namespace}, &dbv1beta1.RDSSnapshot{})
+	}
+
+	It("holds the backup for a new version outside the window", func() {
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{closedWindow()}
+		Expect(summoncomponents.NewBackup()).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusWaitingForWindow))
+		Expect(instance.Status.Message).To(ContainSubstring("Version 1.2.3 is waiting"))
+		next, err := time.Parse(time.UnixDate, instance.Status.NextDeployWindow)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(next).To(BeTemporally("~", time.Now().Add(2*time.Hour), 2*time.Minute))
+		Expect(k8serrors.IsNotFound(getSnapshot())).To(BeTrue())
+	})
+
+	It("starts the backup inside the window", func() {
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{closedWindow(), openWindow()}
+		Expect(summoncomponents.NewBackup()).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).ToNot(Equal(summonv1beta1.StatusWaitingForWindow))
+		Expect(getSnapshot()).To(Succeed())
+	})
+
+	It("doesn't hold the first deploy", func() {
+		instance.Status.MigrateVersion = ""
+		instance.Status.BackupVersion = ""
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{closedWindow()}
+		Expect(summoncomponents.NewBackup()).To(ReconcileContext(ctx))
+		Expect(getSnapshot()).To(Succeed())
+	})
+
+	It("finds the next window on the right day in its timezone", func() {
+		location, err := time.LoadLocation("America/Los_Angeles")
+		Expect(err).ToNot(HaveOccurred())
+		tomorrow := time.Now().In(location).AddDate(0, 0, 1)
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{{
+			Days:     []string{tomorrow.Format("Mon")},
+			Start:    "10:00",
+			Duration: metav1.Duration{Duration: time.Hour},
+			Timezone: "America/Los_Angeles",
+		}}
+		Expect(summoncomponents.NewBackup()).To(ReconcileContext(ctx))
+		next, err := time.Parse(time.UnixDate, instance.Status.NextDeployWindow)
+		Expect(err).ToNot(HaveOccurred())
+		expected := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, location)
+		Expect(next.Equal(expected)).To(BeTrue())
+	})
+
+	It("holds migrations outside the window", func() {
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{closedWindow()}
+		Expect(summoncomponents.NewMigrations("migrations.yml.tpl")).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusWaitingForWindow))
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: instance.Namespace}, &batchv1.Job{})
+		Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("keeps deploying the running version while waiting", func() {
+		instance.Spec.DeployWindows = []summonv1beta1.DeployWindowSpec{closedWindow()}
+		instance.Status.Status = summonv1beta1.StatusWaitingForWindow
+		Expect(summoncomponents.NewDeployment("web/deployment.yml.tpl")).To(ReconcileContext(ctx))
+		deployment := &appsv1.Deployment{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("us.gcr.io/ridecell-1/summon:1.2.2"))
+		Expect(deployment.Spec.Template.Annotations).To(HaveKey("summon.ridecell.io/configHash"))
+		Expect(instance.Spec.Version).To(Equal("1.2.3"))
+	})
+})
@@ -21,10 +21,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"path"
 
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -59,73 +61,123 @@ func (comp *deploymentComponent) IsReconcilable(ctx *components.ComponentContext
 	if instance.Status.PostgresStatus != dbv1beta1.StatusReady {
 		return false
 	}
-	if instance.Status.Status == summonv1beta1.StatusReady {
+	switch instance.Status.Status {
+	case summonv1beta1.StatusReady, summonv1beta1.StatusDeploying, summonv1beta1.StatusWaitingForWindow, summonv1beta1.StatusRolledBack, summonv1beta1.StatusHibernating:
 		return true
-	}
-	if instance.Status.Status != summonv1beta1.StatusDeploying {
+	default:
 		return false
 	}
-	return true
+}
+
+// Dependencies implements components.Dependent.
+func (_ *deploymentComponent) Dependencies() []components.Component {
+	return []components.Component{
+		&appSecretComponent{},
+		&configmapComponent{},
+		&migrationComponent{},
+		&rolloutComponent{},
+	}
 }
 
 func (comp *deploymentComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
-	// If we're not in deploying state do nothing and exit early.
-	if instance.Status.Status != summonv1beta1.StatusDeploying {
+	switch instance.Status.Status {
+	case summonv1beta1.StatusDeploying:
+		// Hold the stable Deployments at the old version while a canary runs.
+		if rolloutHolding(instance) {
+			return components.Result{}, nil
+		}
+	case summonv1beta1.StatusWaitingForWindow, summonv1beta1.StatusRolledBack:
+		// Keep config changes flowing to the running version until the new one gets its window, or after a
+		// failed version was rolled back.
+		ctx = deployedVersionContext(ctx)
+		instance = ctx.Top.(*summonv1beta1.SummonPlatform)
+	case summonv1beta1.StatusHibernating:
+		// Scale everything down to zero, autoscalers included.
+		ctx = hibernatingContext(ctx)
+		instance = ctx.Top.(*summonv1beta1.SummonPlatform)
+	default:
+		// If we're not in deploying state do nothing and exit early.
 		return components.Result{}, nil
 	}
 
+	extra, err := deploymentHashes(ctx)
+	if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+
+	// Once an autoscaler controls the Deployment, keep whatever replica count it picked.
+	var mutateFn func(runtime.Object, runtime.Object) error
+	if scaledExternally(instance, comp.templatePath) && instance.Status.Status != summonv1beta1.StatusHibernating {
+		current := &appsv1.Deployment{}
+		err = ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s-%s", instance.Name, path.Dir(comp.templatePath)), Namespace: instance.Namespace}, current)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return components.Result{Requeue: true}, errors.Wrapf(err, "deployment: unable to get existing deployment for %s", comp.templatePath)
+		}
+		// Coming out of hibernation the autoscaler won't scale up from zero on its own, so start from the configured
+		// count instead.
+		if err == nil && current.Spec.Replicas != nil && *current.Spec.Replicas > 0 {
+			replicas := *current.Spec.Replicas
+			mutateFn = func(_, existingObj runtime.Object) error {
+				existingObj.(*appsv1.Deployment).Spec.Replicas = &replicas
+				return nil
+			}
+		}
+	}
+
+	// Apply rather than copying the whole spec, so env vars dropped from the template get removed while fields
+	// managed by anyone else are left alone.
+	res, err := applyProcessTemplate(ctx, comp.templatePath, extra, mutateFn)
+	if err != nil {
+		return res, errors.Wrapf(err, "deployment: failed to update template %s", comp.templatePath)
+	}
+	return components.Result{}, nil
+}
+
+// Is the replica count for a template's Deployment managed by an HPA or the celery queue autoscaler.
+func scaledExternally(instance *summonv1beta1.SummonPlatform, templatePath string) bool {
+	if processAutoscaling(instance, templatePath) != nil {
+		return true
+	}
+	return path.Dir(templatePath) == "celeryd" && instance.Spec.Celery.Autoscaling != nil
+}
+
+// Hash the app secrets and config so pods get restarted when either changes. Returns the values to pass to
+// the deployment templates.
+func deploymentHashes(ctx *components.ComponentContext) (map[string]interface{}, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+
 	// TODO 2020-01-06 After cm+secret merges to just secret, support varying the input names in the component config so comp-dispatch can get just the hash of its config.
 	rawAppSecret := &corev1.Secret{}
 	err := ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s.app-secrets", instance.Name), Namespace: instance.Namespace}, rawAppSecret)
 	if err != nil {
-		return components.Result{Requeue: true}, errors.Wrapf(err, "deployment: Failed to get appsecrets")
+		return nil, errors.Wrapf(err, "deployment: Failed to get appsecrets")
 	}
 
 	config := &corev1.ConfigMap{}
 	err = ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s-config", instance.Name), Namespace: instance.Namespace}, config)
 	if err != nil {
-		return components.Result{Requeue: true}, errors.Wrapf(err, "deployment: unable to get configmap")
+		return nil, errors.Wrapf(err, "deployment: unable to get configmap")
 	}
 
 	appSecretsBytes, err := json.Marshal(rawAppSecret.Data)
 	if err != nil {
-		return components.Result{}, errors.Wrapf(err, "deployment: unable to serialize appsecrets")
+		return nil, errors.Wrapf(err, "deployment: unable to serialize appsecrets")
 	}
 	configBytes, err := json.Marshal(config.Data)
 	if err != nil {
-		return components.Result{}, errors.Wrapf(err, "deployment: unable to serialize config")
+		return nil, errors.Wrapf(err, "deployment: unable to serialize config")
 	}
 
-	appSecretsHash := comp.hashItem(appSecretsBytes)
-	configMapHash := comp.hashItem(configBytes)
-
 	// Data to be copied over to template
 	extra := map[string]interface{}{}
-	extra["configHash"] = string(configMapHash)
-	extra["appSecretsHash"] = string(appSecretsHash)
-
-	res, _, err := ctx.CreateOrUpdate(comp.templatePath, extra, func(goalObj, existingObj runtime.Object) error {
-		goalDeployment, ok := goalObj.(*appsv1.Deployment)
-		if ok {
-			existing := existingObj.(*appsv1.Deployment)
-			existing.Spec = goalDeployment.Spec
-			return nil
-		}
-
-		goalStatefulSet := goalObj.(*appsv1.StatefulSet)
-		existing := existingObj.(*appsv1.StatefulSet)
-		existing.Spec = goalStatefulSet.Spec
-		return nil
-	})
-	if err != nil {
-		return res, errors.Wrapf(err, "deployment: failed to update template %s", comp.templatePath)
-	}
-	return components.Result{}, nil
+	extra["configHash"] = hashItem(configBytes)
+	extra["appSecretsHash"] = hashItem(appSecretsBytes)
+	return extra, nil
 }
 
-func (_ *deploymentComponent) hashItem(data []byte) string {
+func hashItem(data []byte) string {
 	hash := sha1.Sum(data)
 	encodedHash := hex.EncodeToString(hash[:])
 	return encodedHash
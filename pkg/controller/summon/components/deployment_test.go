@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/Ridecell/ridecell-operator/pkg/components"
@@ -163,6 +164,44 @@ var _ = Describe("deployment Component", func() {
 
 	})
 
+	It("prunes env vars removed from the template but keeps changes made by others", func() {
+		comp := summoncomponents.NewDeployment("web/deployment.yml.tpl")
+		instance.Spec.GCPProject = "test-project"
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-config", instance.Name), Namespace: instance.Namespace},
+			Data:       map[string]string{"summon-platform.yml": "{}\n"},
+		}
+		appSecrets := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.app-secrets", instance.Name), Namespace: instance.Namespace},
+			Data:       map[string][]byte{"filler": []byte("test")},
+		}
+		ctx.Client = fake.NewFakeClient(appSecrets, configMap)
+		Expect(comp).To(ReconcileContext(ctx))
+
+		deployment := &appsv1.Deployment{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Annotations).To(HaveKey(components.LastAppliedAnnotation))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Env).To(ContainElement(MatchFields(IgnoreExtras, Fields{"Name": Equal("GOOGLE_APPLICATION_CREDENTIALS")})))
+
+		// Someone else adds an annotation and an env var.
+		deployment.Annotations["example.com/owner"] = "someone"
+		deployment.Spec.Template.Spec.Containers[0].Env = append(deployment.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "DEBUG", Value: "true"})
+		err = ctx.Client.Update(context.TODO(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance.Spec.GCPProject = ""
+		Expect(comp).To(ReconcileContext(ctx))
+
+		deployment = &appsv1.Deployment{}
+		err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Annotations).To(HaveKeyWithValue("example.com/owner", "someone"))
+		env := deployment.Spec.Template.Spec.Containers[0].Env
+		Expect(env).ToNot(ContainElement(MatchFields(IgnoreExtras, Fields{"Name": Equal("GOOGLE_APPLICATION_CREDENTIALS")})))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "DEBUG", Value: "true"}))
+	})
+
 	It("updates existing hashes for statefulsets", func() {
 		comp := summoncomponents.NewDeployment("celerybeat/statefulset.yml.tpl")
 
@@ -416,4 +455,136 @@ var _ = Describe("deployment Component", func() {
 			Expect(deployment.Spec.Template.ObjectMeta.Labels["metrics-enabled"]).To(Equal("false"))
 		})
 	})
+
+	It("leaves replicas alone when an HPA controls the deployment", func() {
+		comp := summoncomponents.NewDeployment("celeryd/deployment.yml.tpl")
+		instance.Spec.Replicas.Celeryd = intp(2)
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-config", instance.Name), Namespace: instance.Namespace},
+			Data:       map[string]string{"summon-platform.yml": "{}\n"},
+		}
+		appSecrets := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.app-secrets", instance.Name), Namespace: instance.Namespace},
+			Data:       map[string][]byte{"filler": []byte("test")},
+		}
+		ctx.Client = fake.NewFakeClient(appSecrets, configMap)
+		Expect(comp).To(ReconcileContext(ctx))
+
+		// The HPA scales it up.
+		deployment := &appsv1.Deployment{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-celeryd", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Replicas).To(PointTo(BeEquivalentTo(2)))
+		deployment.Spec.Replicas = intp(7)
+		err = ctx.Client.Update(context.TODO(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance.Spec.Autoscaling.Celeryd = &summonv1beta1.ProcessAutoscalingSpec{MinReplicas: intp(2), MaxReplicas: 10}
+		Expect(comp).To(ReconcileContext(ctx))
+		err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-celeryd", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Replicas).To(PointTo(BeEquivalentTo(7)))
+
+		// Without the HPA the fixed count wins again.
+		instance.Spec.Autoscaling.Celeryd = nil
+		Expect(comp).To(ReconcileContext(ctx))
+		err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-celeryd", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Replicas).To(PointTo(BeEquivalentTo(2)))
+	})
+
+	Context("with pod overrides", func() {
+		BeforeEach(func() {
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-config", instance.Name), Namespace: instance.Namespace},
+				Data:       map[string]string{"summon-platform.yml": "{}\n"},
+			}
+			appSecrets := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s.app-secrets", instance.Name), Namespace: instance.Namespace},
+				Data:       map[string][]byte{"filler": []byte("test")},
+			}
+			ctx.Client = fake.NewFakeClient(appSecrets, configMap)
+		})
+
+		It("merges the overrides into the web deployment", func() {
+			comp = summoncomponents.NewDeployment("web/deployment.yml.tpl")
+			instance.Spec.PodOverrides.Web = &summonv1beta1.PodOverrideSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4G")},
+				},
+				NodeSelector:      map[string]string{"pool": "web"},
+				Tolerations:       []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "web", Effect: corev1.TaintEffectNoSchedule}},
+				PriorityClassName: "high",
+				Env:               []corev1.EnvVar{{Name: "EXTRA", Value: "1"}},
+				Annotations:       map[string]string{"example.com/team": "core"},
+			}
+			Expect(comp).To(ReconcileContext(ctx))
+
+			deployment := &appsv1.Deployment{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			podSpec := deployment.Spec.Template.Spec
+			container := podSpec.Containers[0]
+			Expect(container.Resources.Requests.Cpu().String()).To(Equal("2"))
+			Expect(container.Resources.Requests.Memory().String()).To(Equal("512M"))
+			Expect(container.Resources.Limits.Memory().String()).To(Equal("4G"))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "EXTRA", Value: "1"}))
+			Expect(podSpec.NodeSelector).To(Equal(map[string]string{"pool": "web"}))
+			Expect(podSpec.Tolerations).To(HaveLen(1))
+			Expect(podSpec.PriorityClassName).To(Equal("high"))
+			Expect(podSpec.Affinity.PodAntiAffinity).ToNot(BeNil())
+			Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue("example.com/team", "core"))
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("summon.ridecell.io/configHash"))
+
+			// Dropping the overrides puts the defaults back.
+			instance.Spec.PodOverrides.Web = nil
+			Expect(comp).To(ReconcileContext(ctx))
+			err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			podSpec = deployment.Spec.Template.Spec
+			Expect(podSpec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("500m"))
+			Expect(podSpec.Containers[0].Resources.Limits.Memory().String()).To(Equal("2G"))
+			Expect(podSpec.Containers[0].Env).ToNot(ContainElement(corev1.EnvVar{Name: "EXTRA", Value: "1"}))
+			Expect(podSpec.NodeSelector).To(BeEmpty())
+			Expect(podSpec.Tolerations).To(BeEmpty())
+			Expect(podSpec.PriorityClassName).To(Equal(""))
+			Expect(deployment.Spec.Template.Annotations).ToNot(HaveKey("example.com/team"))
+		})
+
+		It("replaces the default anti-affinity", func() {
+			comp = summoncomponents.NewDeployment("celeryd/deployment.yml.tpl")
+			instance.Spec.PodOverrides.Celeryd = &summonv1beta1.PodOverrideSpec{
+				Affinity: &corev1.Affinity{
+					PodAntiAffinity: &corev1.PodAntiAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{TopologyKey: "kubernetes.io/hostname"}},
+					},
+				},
+			}
+			Expect(comp).To(ReconcileContext(ctx))
+
+			deployment := &appsv1.Deployment{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-celeryd", Namespace: instance.Namespace}, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			antiAffinity := deployment.Spec.Template.Spec.Affinity.PodAntiAffinity
+			Expect(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+			Expect(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		})
+
+		It("overrides the celerybeat statefulset but not its init container", func() {
+			comp = summoncomponents.NewDeployment("celerybeat/statefulset.yml.tpl")
+			instance.Spec.PodOverrides.CeleryBeat = &summonv1beta1.PodOverrideSpec{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}
+			Expect(comp).To(ReconcileContext(ctx))
+
+			statefulset := &appsv1.StatefulSet{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-celerybeat", Namespace: instance.Namespace}, statefulset)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statefulset.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String()).To(Equal("1"))
+			Expect(statefulset.Spec.Template.Spec.InitContainers[0].Resources.Limits).To(BeEmpty())
+		})
+	})
 })
@@ -46,6 +46,11 @@ func (_ *podDisruptionBudgetComponent) IsReconcilable(ctx *components.ComponentC
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *podDisruptionBudgetComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *podDisruptionBudgetComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	requeue := false
 
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// Where flavor volumes get mounted in the migration job.
+const flavorMountPath = "/flavors"
+
+// flavorSource finds flavor files in one kind of storage.
+type flavorSource interface {
+	// Location returns the URL or path to hand to loadflavor.
+	Location(flavor string) (string, error)
+	// Volume returns the volume to mount at /flavors for Location to work, or nil for URLs.
+	Volume() *corev1.Volume
+}
+
+func flavorFilename(flavor string) string {
+	return fmt.Sprintf("%s.json.bz2", flavor)
+}
+
+type s3FlavorSource struct {
+	bucket      string
+	region      string
+	endpoint    string
+	prefix      string
+	credentials *credentials.Credentials
+	dryRun      bool
+}
+
+func (src *s3FlavorSource) Location(flavor string) (string, error) {
+	key := path.Join(src.prefix, flavorFilename(flavor))
+	if src.dryRun {
+		// Presigning needs AWS credentials, so just show the unsigned URL in a dry run.
+		if src.endpoint != "" {
+			return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(src.endpoint, "/"), src.bucket, key), nil
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", src.bucket, src.region, key), nil
+	}
+	config := &aws.Config{
+		Region:      aws.String(src.region),
+		Credentials: src.credentials,
+	}
+	if src.endpoint != "" {
+		config.Endpoint = aws.String(src.endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+	svc := s3.New(session.Must(session.NewSession(config)))
+	req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(src.bucket),
+		Key:    aws.String(key),
+	})
+	urlStr, err := req.Presign(15 * time.Minute)
+	if err != nil {
+		return "", errors.Wrapf(err, "flavor_sources: failed to presign s3 url")
+	}
+	return urlStr, nil
+}
+
+func (_ *s3FlavorSource) Volume() *corev1.Volume {
+	return nil
+}
+
+type httpFlavorSource struct {
+	baseURL string
+}
+
+func (src *httpFlavorSource) Location(flavor string) (string, error) {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(src.baseURL, "/"), flavorFilename(flavor)), nil
+}
+
+func (_ *httpFlavorSource) Volume() *corev1.Volume {
+	return nil
+}
+
+type configMapFlavorSource struct {
+	name string
+}
+
+func (_ *configMapFlavorSource) Location(flavor string) (string, error) {
+	return path.Join(flavorMountPath, flavorFilename(flavor)), nil
+}
+
+func (src *configMapFlavorSource) Volume() *corev1.Volume {
+	return &corev1.Volume{
+		Name: "flavors",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: src.name},
+			},
+		},
+	}
+}
+
+type pvcFlavorSource struct {
+	claimName string
+	subPath   string
+}
+
+func (src *pvcFlavorSource) Location(flavor string) (string, error) {
+	return path.Join(flavorMountPath, src.subPath, flavorFilename(flavor)), nil
+}
+
+func (src *pvcFlavorSource) Volume() *corev1.Volume {
+	return &corev1.Volume{
+		Name: "flavors",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: src.claimName,
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// flavorSourceFor finds the source to load the instance's flavor from, either the FlavorSource named in the spec
+// or the flavor bucket from the region profile.
+func flavorSourceFor(ctx *components.ComponentContext, instance *summonv1beta1.SummonPlatform) (flavorSource, error) {
+	if instance.Spec.FlavorSource == "" {
+		profile, err := RegionProfileFor(ctx.Context, ctx, instance)
+		if err != nil {
+			return nil, errors.Wrapf(err, "flavor_sources: unable to load region profile")
+		}
+		return &s3FlavorSource{bucket: profile.FlavorBucket, region: profile.FlavorBucketRegion, dryRun: ctx.DryRun}, nil
+	}
+
+	source := &summonv1beta1.FlavorSource{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Spec.FlavorSource, Namespace: instance.Namespace}, source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "flavor_sources: unable to get FlavorSource %s/%s", instance.Namespace, instance.Spec.FlavorSource)
+	}
+	spec := source.Spec
+
+	found := 0
+	for _, set := range []bool{spec.S3 != nil, spec.HTTP != nil, spec.ConfigMap != nil, spec.PersistentVolumeClaim != nil} {
+		if set {
+			found++
+		}
+	}
+	if found != 1 {
+		return nil, errors.Errorf("flavor_sources: FlavorSource %s/%s must set exactly one of s3, http, configMap or persistentVolumeClaim", source.Namespace, source.Name)
+	}
+
+	switch {
+	case spec.S3 != nil:
+		src := &s3FlavorSource{
+			bucket:   spec.S3.Bucket,
+			region:   spec.S3.Region,
+			endpoint: spec.S3.Endpoint,
+			prefix:   spec.S3.Prefix,
+			dryRun:   ctx.DryRun,
+		}
+		if src.region == "" {
+			profile, err := RegionProfileFor(ctx.Context, ctx, instance)
+			if err != nil {
+				return nil, errors.Wrapf(err, "flavor_sources: unable to load region profile")
+			}
+			src.region = profile.FlavorBucketRegion
+		}
+		if spec.S3.CredentialsSecret != "" {
+			secret := &corev1.Secret{}
+			err := ctx.Get(ctx.Context, types.NamespacedName{Name: spec.S3.CredentialsSecret, Namespace: source.Namespace}, secret)
+			if err != nil {
+				return nil, errors.Wrapf(err, "flavor_sources: unable to get credentials secret %s/%s", source.Namespace, spec.S3.CredentialsSecret)
+			}
+			src.credentials = credentials.NewStaticCredentials(string(secret.Data["AWS_ACCESS_KEY_ID"]), string(secret.Data["AWS_SECRET_ACCESS_KEY"]), "")
+		}
+		return src, nil
+	case spec.HTTP != nil:
+		return &httpFlavorSource{baseURL: spec.HTTP.BaseURL}, nil
+	case spec.ConfigMap != nil:
+		return &configMapFlavorSource{name: spec.ConfigMap.Name}, nil
+	default:
+		return &pvcFlavorSource{claimName: spec.PersistentVolumeClaim.ClaimName, subPath: spec.PersistentVolumeClaim.SubPath}, nil
+	}
+}
+
+// flavorToImport returns the flavor the next migration job should load, or "" if there is nothing to load.
+func flavorToImport(instance *summonv1beta1.SummonPlatform) string {
+	flavor := instance.Spec.Flavor
+	if flavor == "" {
+		return ""
+	}
+	status := instance.Status.Flavor
+	if status.Flavor == flavor && status.Status == summonv1beta1.FlavorStatusImported {
+		return ""
+	}
+	if status.Flavor == "" && instance.Status.MigrateVersion != "" {
+		// Migrated before flavor imports were tracked, when every migration loaded the flavor.
+		return ""
+	}
+	return flavor
+}
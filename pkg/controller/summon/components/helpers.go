@@ -31,3 +31,11 @@ func setStatus(status string) components.StatusModifier {
 		return nil
 	}
 }
+
+// Helper function to turn a child object's status into a condition reason, since a fresh object has no status yet.
+func conditionReason(status string) string {
+	if status == "" {
+		return components.ReasonInitializing
+	}
+	return status
+}
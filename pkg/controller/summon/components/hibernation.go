@@ -0,0 +1,196 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// Annotation to hibernate an instance right away ("true"), or keep it awake through its schedules ("false").
+const HibernateAnnotation = "ridecell.io/hibernate"
+
+// Longest we sleep before checking the schedules again, so spec changes and clock drift get picked up.
+const hibernationRecheck = 10 * time.Minute
+
+type hibernationComponent struct{}
+
+func NewHibernation() *hibernationComponent {
+	return &hibernationComponent{}
+}
+
+func (_ *hibernationComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{}
+}
+
+func (_ *hibernationComponent) IsReconcilable(ctx *components.ComponentContext) bool {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	// Same as the Deployments, nothing to scale down until the database is there.
+	return instance.Status.PostgresStatus == dbv1beta1.StatusReady
+}
+
+func (comp *hibernationComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	now := time.Now()
+
+	reason, wakeAt, requeue, err := comp.hibernationState(ctx, now)
+	if err != nil {
+		return components.Result{}, err
+	}
+
+	if reason == "" {
+		if instance.Status.Hibernation.Reason == "" {
+			return components.Result{RequeueAfter: requeue}, nil
+		}
+		ctx.Eventf(corev1.EventTypeNormal, "Waking", "Waking up from hibernation")
+		// The Deployments put the configured replicas back as the instance goes through Deploying again.
+		return components.Result{
+			StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.Hibernation = summonv1beta1.HibernationStatus{}
+				return nil
+			},
+			RequeueAfter: requeue,
+		}, nil
+	}
+
+	switch instance.Status.Status {
+	case summonv1beta1.StatusDeploying, summonv1beta1.StatusReady, summonv1beta1.StatusWaitingForWindow, summonv1beta1.StatusRolledBack, summonv1beta1.StatusHibernating:
+	default:
+		// Let backups and migrations finish first, or the new version would be half deployed.
+		return components.Result{RequeueAfter: requeue}, nil
+	}
+	rollout := instance.Status.Rollout
+	if rollout.Phase == summonv1beta1.RolloutPhaseCanary && rollout.CanaryVersion == instance.Spec.Version {
+		// Scaling down part way through would throw off the canary checks, wait for the verdict.
+		return components.Result{RequeueAfter: requeue}, nil
+	}
+
+	since := instance.Status.Hibernation.Since
+	if instance.Status.Hibernation.Reason == "" {
+		since = now.Format(time.UnixDate)
+		ctx.Eventf(corev1.EventTypeNormal, "Hibernating", "Scaling down to zero for hibernation (%s)", reason)
+	}
+	message := "Hibernating"
+	if !wakeAt.IsZero() {
+		message = fmt.Sprintf("Hibernating until %s", wakeAt.UTC().Format(time.UnixDate))
+	}
+	status := summonv1beta1.HibernationStatus{Reason: reason, Since: since}
+	if !wakeAt.IsZero() {
+		// Saved in UTC, zone abbreviations like PST don't parse back reliably.
+		status.WakeAt = wakeAt.UTC().Format(time.UnixDate)
+	}
+	return components.Result{
+		StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.Status = summonv1beta1.StatusHibernating
+			instance.Status.Message = message
+			instance.Status.Hibernation = status
+			return nil
+		},
+		RequeueAfter: requeue,
+	}, nil
+}
+
+// Work out if the instance should be hibernating right now. Returns why, empty if it should be awake, when the
+// current schedule ends, and how long until things should be checked again.
+func (_ *hibernationComponent) hibernationState(ctx *components.ComponentContext, now time.Time) (string, time.Time, time.Duration, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+
+	override, hasOverride := instance.Annotations[HibernateAnnotation]
+	if hasOverride && productionEnvironment(instance) {
+		// Scaling prod down to nothing is never what anyone wanted.
+		glog.Errorf("[%s/%s] hibernation: ignoring %s annotation on a %s instance\n", instance.Namespace, instance.Name, HibernateAnnotation, instance.Spec.Environment)
+		ctx.Eventf(corev1.EventTypeWarning, "HibernationRefused", "Ignoring %s annotation, %s instances cannot hibernate", HibernateAnnotation, instance.Spec.Environment)
+		return "", time.Time{}, hibernationRecheck, nil
+	}
+	if hasOverride {
+		hibernate, err := strconv.ParseBool(override)
+		if err != nil {
+			return "", time.Time{}, 0, errors.Wrapf(err, "hibernation: invalid value %#v for annotation %s", override, HibernateAnnotation)
+		}
+		if hibernate {
+			return summonv1beta1.HibernationReasonAnnotation, time.Time{}, hibernationRecheck, nil
+		}
+		return "", time.Time{}, hibernationRecheck, nil
+	}
+
+	schedules := instance.Spec.Hibernation.Schedules
+	if len(schedules) == 0 || productionEnvironment(instance) {
+		return "", time.Time{}, hibernationRecheck, nil
+	}
+	windows := make([]summonv1beta1.DeployWindowSpec, len(schedules))
+	for i, schedule := range schedules {
+		// Same fields as a deploy window.
+		windows[i] = summonv1beta1.DeployWindowSpec(schedule)
+	}
+	end, next, err := windowState(windows, now)
+	if err != nil {
+		return "", time.Time{}, 0, errors.Wrap(err, "hibernation: unable to check hibernation schedules")
+	}
+
+	requeue := hibernationRecheck
+	if !end.IsZero() {
+		if until := end.Sub(now); until < requeue {
+			requeue = until
+		}
+		return summonv1beta1.HibernationReasonSchedule, end, requeue, nil
+	}
+	if until := next.Sub(now); !next.IsZero() && until < requeue {
+		requeue = until
+	}
+	return "", time.Time{}, requeue, nil
+}
+
+// Is this a uat or prod instance, which must never hibernate.
+func productionEnvironment(instance *summonv1beta1.SummonPlatform) bool {
+	return instance.Spec.Environment == "uat" || instance.Spec.Environment == "prod"
+}
+
+// While hibernating, render the workloads at the last migrated version with every replica count at zero. The
+// last-applied annotation then records the zeros too, so the configured counts get merged back in on wake.
+func hibernatingContext(ctx *components.ComponentContext) *components.ComponentContext {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	hibernating := instance.DeepCopy()
+	if instance.Status.MigrateVersion != "" {
+		hibernating.Spec.Version = instance.Status.MigrateVersion
+	}
+	zero := int32(0)
+	hibernating.Spec.Replicas = summonv1beta1.ReplicasSpec{
+		Web:            &zero,
+		Daphne:         &zero,
+		Celeryd:        &zero,
+		CeleryBeat:     &zero,
+		ChannelWorker:  &zero,
+		Static:         &zero,
+		Dispatch:       &zero,
+		BusinessPortal: &zero,
+	}
+	hibernatingCtx := *ctx
+	hibernatingCtx.Top = hibernating
+	return &hibernatingCtx
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform Hibernation Component", func() {
+	comp := summoncomponents.NewHibernation()
+
+	BeforeEach(func() {
+		comp = summoncomponents.NewHibernation()
+		instance.Spec.Environment = "dev"
+		instance.Status.Status = summonv1beta1.StatusDeploying
+	})
+
+	// A schedule which started an hour ago and runs for another hour, so it's open whatever the time of day.
+	openSchedule := func() summonv1beta1.HibernationScheduleSpec {
+		start := time.Now().UTC().Add(-time.Hour)
+		return summonv1beta1.HibernationScheduleSpec{
+			Start:    start.Format("15:04"),
+			Duration: metav1.Duration{Duration: 2 * time.Hour},
+		}
+	}
+
+	It("does nothing without schedules", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Hibernation.Reason).To(BeEmpty())
+	})
+
+	It("hibernates during a schedule", func() {
+		instance.Spec.Hibernation.Schedules = []summonv1beta1.HibernationScheduleSpec{openSchedule()}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusHibernating))
+		Expect(instance.Status.Message).To(HavePrefix("Hibernating until "))
+		Expect(instance.Status.Hibernation.Reason).To(Equal(summonv1beta1.HibernationReasonSchedule))
+		Expect(instance.Status.Hibernation.Since).ToNot(BeEmpty())
+		wakeAt, err := time.Parse(time.UnixDate, instance.Status.Hibernation.WakeAt)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(wakeAt).To(BeTemporally("~", time.Now().Add(time.Hour), 2*time.Minute))
+	})
+
+	It("keeps the time it went into hibernation", func() {
+		instance.Spec.Hibernation.Schedules = []summonv1beta1.HibernationScheduleSpec{openSchedule()}
+		instance.Status.Hibernation.Reason = summonv1beta1.HibernationReasonSchedule
+		instance.Status.Hibernation.Since = "Mon Jan  6 20:00:00 UTC 2020"
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Hibernation.Since).To(Equal("Mon Jan  6 20:00:00 UTC 2020"))
+	})
+
+	It("hibernates on the annotation", func() {
+		instance.Annotations = map[string]string{summoncomponents.HibernateAnnotation: "true"}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusHibernating))
+		Expect(instance.Status.Message).To(Equal("Hibernating"))
+		Expect(instance.Status.Hibernation.Reason).To(Equal(summonv1beta1.HibernationReasonAnnotation))
+		Expect(instance.Status.Hibernation.WakeAt).To(BeEmpty())
+	})
+
+	It("stays awake through a schedule when the annotation says so", func() {
+		instance.Spec.Hibernation.Schedules = []summonv1beta1.HibernationScheduleSpec{openSchedule()}
+		instance.Annotations = map[string]string{summoncomponents.HibernateAnnotation: "false"}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+	})
+
+	It("errors on a bad annotation", func() {
+		instance.Annotations = map[string]string{summoncomponents.HibernateAnnotation: "sleepy"}
+		Expect(comp).ToNot(ReconcileContext(ctx))
+	})
+
+	It("never hibernates prod", func() {
+		instance.Spec.Environment = "prod"
+		instance.Annotations = map[string]string{summoncomponents.HibernateAnnotation: "true"}
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+	})
+
+	It("lets migrations finish first", func() {
+		instance.Spec.Hibernation.Schedules = []summonv1beta1.HibernationScheduleSpec{openSchedule()}
+		instance.Status.Status = summonv1beta1.StatusMigrating
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusMigrating))
+	})
+
+	It("wakes up once the schedule ends", func() {
+		instance.Status.Hibernation.Reason = summonv1beta1.HibernationReasonSchedule
+		instance.Status.Hibernation.Since = "Mon Jan  6 20:00:00 UTC 2020"
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Hibernation).To(Equal(summonv1beta1.HibernationStatus{}))
+	})
+
+	Describe("scaling down", func() {
+		var configMap *corev1.ConfigMap
+		var appSecrets *corev1.Secret
+
+		BeforeEach(func() {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-config", Namespace: "summon-dev"},
+				Data:       map[string]string{"summon-platform.yml": "{}\n"},
+			}
+			appSecrets = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev.app-secrets", Namespace: "summon-dev"},
+				Data:       map[string][]byte{"filler": []byte("test")},
+			}
+			ctx.Client = fake.NewFakeClient(appSecrets, configMap)
+			instance.Spec.Replicas.Web = intp(2)
+		})
+
+		getDeployment := func(name string) *appsv1.Deployment {
+			deployment := &appsv1.Deployment{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "summon-dev"}, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			return deployment
+		}
+
+		It("scales web to zero and back", func() {
+			web := summoncomponents.NewDeployment("web/deployment.yml.tpl")
+			Expect(web).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-web").Spec.Replicas).To(PointTo(BeEquivalentTo(2)))
+
+			instance.Status.Status = summonv1beta1.StatusHibernating
+			Expect(web).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-web").Spec.Replicas).To(PointTo(BeEquivalentTo(0)))
+
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			Expect(web).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-web").Spec.Replicas).To(PointTo(BeEquivalentTo(2)))
+		})
+
+		It("scales an autoscaled process back up from zero", func() {
+			instance.Spec.Autoscaling.Web = &summonv1beta1.ProcessAutoscalingSpec{MaxReplicas: 10}
+			instance.Status.Status = summonv1beta1.StatusHibernating
+			web := summoncomponents.NewDeployment("web/deployment.yml.tpl")
+			Expect(web).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-web").Spec.Replicas).To(PointTo(BeEquivalentTo(0)))
+
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			Expect(web).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-web").Spec.Replicas).To(PointTo(BeEquivalentTo(2)))
+		})
+
+		It("scales redis to zero", func() {
+			redis := summoncomponents.NewRedisDeployment("redis/deployment.yml.tpl")
+			instance.Status.Status = summonv1beta1.StatusHibernating
+			Expect(redis).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-redis").Spec.Replicas).To(PointTo(BeEquivalentTo(0)))
+
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			Expect(redis).To(ReconcileContext(ctx))
+			Expect(getDeployment("foo-dev-redis").Spec.Replicas).To(PointTo(BeEquivalentTo(1)))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+type hpaComponent struct {
+	templatePath string
+}
+
+func NewHPA(templatePath string) *hpaComponent {
+	return &hpaComponent{templatePath: templatePath}
+}
+
+func (_ *hpaComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{
+		&autoscalingv2beta2.HorizontalPodAutoscaler{},
+	}
+}
+
+func (_ *hpaComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return true
+}
+
+// Dependencies implements components.Dependent.
+func (_ *hpaComponent) Dependencies() []components.Component {
+	// The HPA targets a Deployment, so don't create it before there is one to scale.
+	return []components.Component{
+		&deploymentComponent{},
+	}
+}
+
+func (comp *hpaComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	// The template renders nothing when autoscaling is off for this process, which prunes any existing HPA.
+	res, _, err := ctx.Apply(comp.templatePath, nil, nil)
+	if err != nil {
+		return res, errors.Wrapf(err, "hpa: failed to update template %s", comp.templatePath)
+	}
+	return res, nil
+}
+
+// Find the autoscaling settings for the process a template belongs to, nil if it isn't autoscaled.
+func processAutoscaling(instance *summonv1beta1.SummonPlatform, templatePath string) *summonv1beta1.ProcessAutoscalingSpec {
+	switch path.Dir(templatePath) {
+	case "web":
+		return instance.Spec.Autoscaling.Web
+	case "celeryd":
+		return instance.Spec.Autoscaling.Celeryd
+	case "channelworker":
+		return instance.Spec.Autoscaling.ChannelWorker
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform HPA Component", func() {
+	getHPA := func(name string) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+		hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "summon-dev"}, hpa)
+		return hpa, err
+	}
+
+	BeforeEach(func() {
+		ctx.OwnedTypes = []runtime.Object{&autoscalingv2beta2.HorizontalPodAutoscaler{}}
+	})
+
+	It("does nothing without autoscaling settings", func() {
+		comp := summoncomponents.NewHPA("web/hpa.yml.tpl")
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err := getHPA("foo-dev-web")
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("creates a CPU based HPA for web", func() {
+		instance.Spec.Autoscaling.Web = &summonv1beta1.ProcessAutoscalingSpec{
+			MinReplicas:          intp(4),
+			MaxReplicas:          20,
+			TargetCPUUtilization: intp(60),
+		}
+		comp := summoncomponents.NewHPA("web/hpa.yml.tpl")
+		Expect(comp).To(ReconcileContext(ctx))
+
+		hpa, err := getHPA("foo-dev-web")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hpa.Spec.ScaleTargetRef.Kind).To(Equal("Deployment"))
+		Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal("foo-dev-web"))
+		Expect(hpa.Spec.MinReplicas).To(PointTo(BeEquivalentTo(4)))
+		Expect(hpa.Spec.MaxReplicas).To(BeEquivalentTo(20))
+		Expect(hpa.Spec.Metrics).To(HaveLen(1))
+		Expect(hpa.Spec.Metrics[0].Resource.Name).To(BeEquivalentTo("cpu"))
+		Expect(hpa.Spec.Metrics[0].Resource.Target.AverageUtilization).To(PointTo(BeEquivalentTo(60)))
+	})
+
+	It("creates a custom metric HPA for celeryd", func() {
+		instance.Spec.Autoscaling.Celeryd = &summonv1beta1.ProcessAutoscalingSpec{
+			MinReplicas: intp(1),
+			MaxReplicas: 8,
+			Metrics: []summonv1beta1.AutoscalingMetricSpec{
+				{Name: "celery_tasks_active", TargetAverageValue: "500m"},
+			},
+		}
+		comp := summoncomponents.NewHPA("celeryd/hpa.yml.tpl")
+		Expect(comp).To(ReconcileContext(ctx))
+
+		hpa, err := getHPA("foo-dev-celeryd")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal("foo-dev-celeryd"))
+		Expect(hpa.Spec.Metrics).To(HaveLen(1))
+		Expect(hpa.Spec.Metrics[0].Pods.Metric.Name).To(Equal("celery_tasks_active"))
+		Expect(hpa.Spec.Metrics[0].Pods.Target.AverageValue.String()).To(Equal("500m"))
+	})
+
+	It("removes the HPA when autoscaling is turned off", func() {
+		instance.Spec.Autoscaling.ChannelWorker = &summonv1beta1.ProcessAutoscalingSpec{
+			MinReplicas:          intp(2),
+			MaxReplicas:          10,
+			TargetCPUUtilization: intp(70),
+		}
+		comp := summoncomponents.NewHPA("channelworker/hpa.yml.tpl")
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err := getHPA("foo-dev-channelworker")
+		Expect(err).ToNot(HaveOccurred())
+
+		instance.Spec.Autoscaling.ChannelWorker = nil
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err = getHPA("foo-dev-channelworker")
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -48,6 +48,11 @@ func (_ *iamUserComponent) IsReconcilable(_ *components.ComponentContext) bool {
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *iamUserComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *iamUserComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
@@ -61,10 +66,16 @@ func (comp *iamUserComponent) Reconcile(ctx *components.ComponentContext) (compo
 	}
 	accountID := match[1]
 
+	profile, err := RegionProfileFor(ctx.Context, ctx, instance)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "iamuser: unable to load region profile")
+	}
+
 	// Data to be copied over to template
 	extra := map[string]interface{}{}
 	extra["permissionsBoundaryArn"] = permissionsBoundaryArn
 	extra["accountId"] = accountID
+	extra["sqsRegions"] = profile.SQSRegions
 	extra["mivBucket"] = fmt.Sprintf("ridecell-%s-miv", instance.Name)
 	if instance.Spec.MIV.ExistingBucket != "" {
 		extra["mivBucket"] = instance.Spec.MIV.ExistingBucket
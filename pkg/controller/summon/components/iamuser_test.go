@@ -24,9 +24,12 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	awsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/aws/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
 	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
 )
@@ -66,4 +69,38 @@ var _ = Describe("SummonPlatform iamuser Component", func() {
 			Expect(target.Spec.InlinePolicies["allow_s3_miv"]).To(ContainOrderedJSON(`{"Statement": [{"Resource": "arn:aws:s3:::asdf"}]}`))
 		})
 	})
+
+	Context("SQS policy", func() {
+		It("allows the queue in every built-in region", func() {
+			instance.Spec.AwsRegion = "eu-central-1"
+			comp := summoncomponents.NewIAMUser("aws/iamuser.yml.tpl")
+			Expect(comp).To(ReconcileContext(ctx))
+			target := &awsv1beta1.IAMUser{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Spec.InlinePolicies["allow_sqs"]).To(MatchUnorderedJSON(`{"Version": "2012-10-17", "Statement": {"Sid": "", "Effect": "Allow", "Action": ["sqs:SendMessageBatch", "sqs:SendMessage", "sqs:CreateQueue"], "Resource": [
+				"arn:aws:sqs:us-west-2:123456789:test-sqs-queue",
+				"arn:aws:sqs:eu-central-1:123456789:test-sqs-queue",
+				"arn:aws:sqs:ap-south-1:123456789:test-sqs-queue"
+			]}}`))
+		})
+
+		It("uses the regions from the RegionProfile", func() {
+			profile := &summonv1beta1.RegionProfile{
+				ObjectMeta: metav1.ObjectMeta{Name: "mars"},
+				Spec: summonv1beta1.RegionProfileSpec{
+					Region: "mars-north-1",
+				},
+			}
+			ctx.Client = fake.NewFakeClient(instance, profile)
+			instance.Spec.RegionProfile = "mars"
+			comp := summoncomponents.NewIAMUser("aws/iamuser.yml.tpl")
+			Expect(comp).To(ReconcileContext(ctx))
+			target := &awsv1beta1.IAMUser{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, target)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Spec.InlinePolicies["allow_sqs"]).To(ContainOrderedJSON(`{"Statement": {"Resource": ["arn:aws:sqs:mars-north-1:123456789:test-sqs-queue"]}}`))
+			Expect(target.Spec.InlinePolicies["allow_sqs"]).ToNot(ContainSubstring("us-west-2"))
+		})
+	})
 })
@@ -41,6 +41,11 @@ func (_ *ingressComponent) IsReconcilable(_ *components.ComponentContext) bool {
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *ingressComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *ingressComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*extv1beta1.Ingress)
@@ -20,9 +20,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -39,7 +37,8 @@ import (
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 )
 
-const flavorBucket = "ridecell-flavors"
+// Annotation on migration jobs which load a flavor, holding the flavor name.
+const flavorAnnotation = "summon.ridecell.io/flavor"
 
 type migrationComponent struct {
 	templatePath string
@@ -68,13 +67,36 @@ func (_ *migrationComponent) IsReconcilable(ctx *components.ComponentContext) bo
 	return true
 }
 
+// ConditionType implements components.ConditionReporter.
+func (_ *migrationComponent) ConditionType() string {
+	return summonv1beta1.ConditionMigrationsComplete
+}
+
 func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	notMigrated := func(reason string) []components.Condition {
+		return []components.Condition{components.NewCondition(summonv1beta1.ConditionMigrationsComplete, false, reason, fmt.Sprintf("Migrations pending for version %s", instance.Spec.Version))}
+	}
+
+	hold, err := holdForDeployWindow(instance, time.Now())
+	if err != nil {
+		return components.Result{}, err
+	}
+	if hold != nil {
+		hold.Conditions = notMigrated(summonv1beta1.StatusWaitingForWindow)
+		return *hold, nil
+	}
+	if hold := holdForRollback(instance); hold != nil {
+		if instance.Status.MigrateVersion != instance.Spec.Version {
+			hold.Conditions = notMigrated(summonv1beta1.StatusRolledBack)
+		}
+		return *hold, nil
+	}
 
 	// Originally a check done in IsReconcilable, but because of autodeploy setting Spec.Version during
 	// Reconcile stage, check has to be done here to see if Spec.Version value was set by autodeploy.
 	if instance.Status.BackupVersion != instance.Spec.Version {
-		return components.Result{}, nil
+		return components.Result{Conditions: notMigrated(summonv1beta1.StatusCreatingBackup)}, nil
 	}
 
 	if instance.Spec.Version == instance.Status.MigrateVersion {
@@ -82,27 +104,7 @@ func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (com
 		return components.Result{StatusModifier: setStatus(summonv1beta1.StatusDeploying)}, nil
 	}
 
-	var urlStr string
-	if instance.Spec.Flavor != "" {
-		svc := s3.New(session.Must(session.NewSession(&aws.Config{
-			Region: aws.String("us-west-2"),
-		})))
-		req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
-			Bucket: aws.String(flavorBucket),
-			Key:    aws.String(fmt.Sprintf("%s.json.bz2", instance.Spec.Flavor)),
-		})
-
-		var err error
-		urlStr, err = req.Presign(15 * time.Minute)
-		if err != nil {
-			return components.Result{}, errors.Wrapf(err, "migrations: failed to presign s3 url")
-		}
-	}
-
-	extra := map[string]interface{}{}
-	extra["presignedUrl"] = urlStr
-
-	obj, err := ctx.GetTemplate(comp.templatePath, extra)
+	obj, err := ctx.GetTemplate(comp.templatePath, nil)
 	if err != nil {
 		return components.Result{}, err
 	}
@@ -111,6 +113,28 @@ func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (com
 	existing := &batchv1.Job{}
 	err = ctx.Get(ctx.Context, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
 	if err != nil && kerrors.IsNotFound(err) {
+		// Only work out where the flavor comes from when a job is needed, presigned URLs expire.
+		flavor := flavorToImport(instance)
+		if flavor != "" {
+			source, err := flavorSourceFor(ctx, instance)
+			if err != nil {
+				return components.Result{}, errors.Wrapf(err, "migrations: unable to find flavor source")
+			}
+			location, err := source.Location(flavor)
+			if err != nil {
+				return components.Result{}, errors.Wrapf(err, "migrations: unable to locate flavor %s", flavor)
+			}
+			extra := map[string]interface{}{"flavorLocation": location}
+			if volume := source.Volume(); volume != nil {
+				extra["flavorVolume"] = volume
+			}
+			obj, err := ctx.GetTemplate(comp.templatePath, extra)
+			if err != nil {
+				return components.Result{}, err
+			}
+			job = obj.(*batchv1.Job)
+		}
+
 		glog.Infof("Creating migration Job %s/%s\n", job.Namespace, job.Name)
 		err = controllerutil.SetControllerReference(instance, job, ctx.Scheme)
 		if err != nil {
@@ -122,8 +146,23 @@ func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (com
 			// If this fails, someone else might have started a migraton job between the Get and here, so just try again.
 			return components.Result{Requeue: true}, errors.Wrapf(err, "migrations: error creation migration job %s/%s, might have lost the race condition", job.Namespace, job.Name)
 		}
+		ctx.Eventf(corev1.EventTypeNormal, "MigrationStarted", "Created migration job %s for version %s", job.Name, instance.Spec.Version)
 		// Job is started, so we're done for now.
-		return components.Result{StatusModifier: setStatus(summonv1beta1.StatusMigrating)}, nil
+		if flavor == "" {
+			return components.Result{StatusModifier: setStatus(summonv1beta1.StatusMigrating), Conditions: notMigrated(summonv1beta1.StatusMigrating)}, nil
+		}
+		flavorStatus := summonv1beta1.FlavorStatus{
+			Flavor:  flavor,
+			Source:  instance.Spec.FlavorSource,
+			Status:  summonv1beta1.FlavorStatusImporting,
+			Version: instance.Spec.Version,
+		}
+		return components.Result{StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.Status = summonv1beta1.StatusMigrating
+			instance.Status.Flavor = flavorStatus
+			return nil
+		}, Conditions: notMigrated(summonv1beta1.StatusMigrating)}, nil
 	} else if err != nil {
 		// Some other real error, bail.
 		return components.Result{}, err
@@ -149,13 +188,26 @@ func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (com
 		}
 
 		glog.Infof("[%s/%s] migrations: Migration job succeeded, updating MigrateVersion from %s to %s\n", instance.Namespace, instance.Name, instance.Status.MigrateVersion, instance.Spec.Version)
+		ctx.Eventf(corev1.EventTypeNormal, "MigrationSucceeded", "Migrations for version %s completed", instance.Spec.Version)
 		// Store migrate version in the closure to avoid concurrent edits to Spec.Version resulting in incorrectly advancing MigrateVersion.
 		migrateVersion := instance.Spec.Version
+		// The flavor only ever gets loaded once, so remember that this job did it.
+		importedFlavor := existing.Annotations[flavorAnnotation]
+		if importedFlavor != "" {
+			ctx.Eventf(corev1.EventTypeNormal, "FlavorImported", "Loaded flavor %s", importedFlavor)
+		}
+		importedAt := time.Now().Format(time.UnixDate)
 		// Onward to deploying!
 		return components.Result{StatusModifier: func(obj runtime.Object) error {
 			instance := obj.(*summonv1beta1.SummonPlatform)
 			instance.Status.Status = summonv1beta1.StatusPostMigrateWait
 			instance.Status.MigrateVersion = migrateVersion
+			if importedFlavor != "" {
+				instance.Status.Flavor.Flavor = importedFlavor
+				instance.Status.Flavor.Status = summonv1beta1.FlavorStatusImported
+				instance.Status.Flavor.Version = migrateVersion
+				instance.Status.Flavor.ImportedAt = importedAt
+			}
 			return nil
 		}}, nil
 	}
@@ -164,9 +216,18 @@ func (comp *migrationComponent) Reconcile(ctx *components.ComponentContext) (com
 	if existing.Status.Failed > 0 {
 		// If it was an outdated job, we would have already deleted it, so this means it's a failed migration for the current version.
 		glog.Errorf("[%s/%s] Migration job failed, leaving job %s/%s for debugging purposes\n", instance.Namespace, instance.Name, existing.Namespace, existing.Name)
+		ctx.Eventf(corev1.EventTypeWarning, "MigrationFailed", "Migration job %s for version %s failed", existing.Name, instance.Spec.Version)
+		rollback, err := autoRollback(ctx, "failed migrations")
+		if err != nil {
+			return components.Result{}, err
+		}
+		if rollback != nil {
+			rollback.Conditions = notMigrated(summonv1beta1.StatusRolledBack)
+			return *rollback, nil
+		}
 		return components.Result{}, errors.Errorf("migrations: migration job %s/%s failed", existing.Namespace, existing.Name)
 	}
 
 	// Job is still running, will get reconciled when it finishes.
-	return components.Result{StatusModifier: setStatus(summonv1beta1.StatusMigrating)}, nil
+	return components.Result{StatusModifier: setStatus(summonv1beta1.StatusMigrating), Conditions: notMigrated(summonv1beta1.StatusMigrating)}, nil
 }
@@ -24,13 +24,16 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
 	secretsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/secrets/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
 	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
 )
@@ -111,6 +114,12 @@ var _ = Describe("SummonPlatform Migrations Component", func() {
 				Expect(instance.Status.MigrateVersion).To(Equal(""))
 			})
 
+			It("records a MigrationStarted event", func() {
+				Expect(comp).To(ReconcileContext(ctx))
+				recorder := ctx.Recorder.(*record.FakeRecorder)
+				Expect(recorder.Events).To(Receive(ContainSubstring("MigrationStarted")))
+			})
+
 			It("checks template info for a presigned url", func() {
 				instance.Spec.Flavor = "test-flavor"
 				Expect(comp).To(ReconcileContext(ctx))
@@ -129,6 +138,166 @@ var _ = Describe("SummonPlatform Migrations Component", func() {
 			})
 		})
 
+		Context("with a FlavorSource", func() {
+			BeforeEach(func() {
+				instance.Spec.Flavor = "test-flavor"
+				instance.Spec.FlavorSource = "fixtures"
+			})
+
+			getJob := func() *batchv1.Job {
+				job := &batchv1.Job{}
+				err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, job)
+				Expect(err).NotTo(HaveOccurred())
+				return job
+			}
+
+			It("loads the flavor from an HTTP URL", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						HTTP: &summonv1beta1.HTTPFlavorSourceSpec{BaseURL: "https://fixtures.example.com/flavors/"},
+					},
+				})
+				Expect(comp).To(ReconcileContext(ctx))
+				job := getJob()
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(Equal("python manage.py migrate -v3 && python manage.py loadflavor 'https://fixtures.example.com/flavors/test-flavor.json.bz2' --silent"))
+				Expect(job.Annotations).To(HaveKeyWithValue("summon.ridecell.io/flavor", "test-flavor"))
+				Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(2))
+				Expect(instance.Status.Flavor.Flavor).To(Equal("test-flavor"))
+				Expect(instance.Status.Flavor.Source).To(Equal("fixtures"))
+				Expect(instance.Status.Flavor.Status).To(Equal(summonv1beta1.FlavorStatusImporting))
+			})
+
+			It("presigns against an S3 compatible endpoint", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						S3: &summonv1beta1.S3FlavorSourceSpec{
+							Bucket:            "flavors",
+							Endpoint:          "http://minio:9000",
+							Prefix:            "seed",
+							CredentialsSecret: "minio",
+						},
+					},
+				}, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "minio", Namespace: "summon-dev"},
+					Data: map[string][]byte{
+						"AWS_ACCESS_KEY_ID":     []byte("minioadmin"),
+						"AWS_SECRET_ACCESS_KEY": []byte("minioadmin"),
+					},
+				})
+				Expect(comp).To(ReconcileContext(ctx))
+				job := getJob()
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("http://minio:9000/flavors/seed/test-flavor.json.bz2?"))
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("minioadmin"))
+			})
+
+			It("defaults the bucket region to the RegionProfile flavor bucket region", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						S3: &summonv1beta1.S3FlavorSourceSpec{Bucket: "flavors"},
+					},
+				}, &summonv1beta1.RegionProfile{
+					ObjectMeta: metav1.ObjectMeta{Name: "ireland"},
+					Spec: summonv1beta1.RegionProfileSpec{
+						Region:             "eu-west-1",
+						FlavorBucketRegion: "eu-west-1",
+					},
+				})
+				instance.Spec.RegionProfile = "ireland"
+				Expect(comp).To(ReconcileContext(ctx))
+				job := getJob()
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("https://flavors.s3.eu-west-1.amazonaws.com/test-flavor.json.bz2?"))
+			})
+
+			It("mounts a ConfigMap", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						ConfigMap: &summonv1beta1.ConfigMapFlavorSourceSpec{Name: "test-flavors"},
+					},
+				})
+				Expect(comp).To(ReconcileContext(ctx))
+				job := getJob()
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("loadflavor '/flavors/test-flavor.json.bz2'"))
+				Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "flavors", MountPath: "/flavors", ReadOnly: true}))
+				Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(3))
+				Expect(job.Spec.Template.Spec.Volumes[2].Name).To(Equal("flavors"))
+				Expect(job.Spec.Template.Spec.Volumes[2].ConfigMap).ToNot(BeNil())
+				Expect(job.Spec.Template.Spec.Volumes[2].ConfigMap.Name).To(Equal("test-flavors"))
+			})
+
+			It("mounts a PersistentVolumeClaim", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						PersistentVolumeClaim: &summonv1beta1.PVCFlavorSourceSpec{ClaimName: "flavors", SubPath: "v2"},
+					},
+				})
+				Expect(comp).To(ReconcileContext(ctx))
+				job := getJob()
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("loadflavor '/flavors/v2/test-flavor.json.bz2'"))
+				Expect(job.Spec.Template.Spec.Volumes).To(HaveLen(3))
+				Expect(job.Spec.Template.Spec.Volumes[2].PersistentVolumeClaim).ToNot(BeNil())
+				Expect(job.Spec.Template.Spec.Volumes[2].PersistentVolumeClaim.ClaimName).To(Equal("flavors"))
+				Expect(job.Spec.Template.Spec.Volumes[2].PersistentVolumeClaim.ReadOnly).To(BeTrue())
+			})
+
+			It("rejects a FlavorSource with more than one source", func() {
+				ctx.Client = fake.NewFakeClient(&summonv1beta1.FlavorSource{
+					ObjectMeta: metav1.ObjectMeta{Name: "fixtures", Namespace: "summon-dev"},
+					Spec: summonv1beta1.FlavorSourceSpec{
+						HTTP:      &summonv1beta1.HTTPFlavorSourceSpec{BaseURL: "https://fixtures.example.com"},
+						ConfigMap: &summonv1beta1.ConfigMapFlavorSourceSpec{Name: "test-flavors"},
+					},
+				})
+				Expect(comp).NotTo(ReconcileContext(ctx))
+			})
+
+			It("returns an error when the FlavorSource is missing", func() {
+				Expect(comp).NotTo(ReconcileContext(ctx))
+			})
+		})
+
+		Context("with the flavor already imported", func() {
+			BeforeEach(func() {
+				instance.Spec.Flavor = "test-flavor"
+				instance.Status.Flavor = summonv1beta1.FlavorStatus{Flavor: "test-flavor", Status: summonv1beta1.FlavorStatusImported}
+			})
+
+			It("does not load it again", func() {
+				Expect(comp).To(ReconcileContext(ctx))
+				job := &batchv1.Job{}
+				err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, job)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(Equal("python manage.py migrate -v3"))
+				Expect(job.Annotations).NotTo(HaveKey("summon.ridecell.io/flavor"))
+			})
+
+			It("loads a different flavor", func() {
+				instance.Spec.Flavor = "other-flavor"
+				Expect(comp).To(ReconcileContext(ctx))
+				job := &batchv1.Job{}
+				err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, job)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(ContainSubstring("other-flavor.json.bz2"))
+			})
+		})
+
+		Context("with an instance migrated before flavor imports were tracked", func() {
+			It("does not load the flavor again", func() {
+				instance.Spec.Flavor = "test-flavor"
+				instance.Spec.NoCore1540Fixup = true
+				instance.Status.MigrateVersion = "1.2.2"
+				Expect(comp).To(ReconcileContext(ctx))
+				job := &batchv1.Job{}
+				err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, job)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.Spec.Template.Spec.Containers[0].Command[2]).To(Equal("python manage.py migrate -v3"))
+			})
+		})
+
 		Context("with a running migration job", func() {
 			BeforeEach(func() {
 				job := &batchv1.Job{
@@ -141,6 +310,15 @@ var _ = Describe("SummonPlatform Migrations Component", func() {
 				ctx.Client = fake.NewFakeClient(job)
 			})
 
+			It("reports migrations as incomplete", func() {
+				res, err := comp.Reconcile(ctx)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.Conditions).To(HaveLen(1))
+				Expect(res.Conditions[0].Type).To(Equal(summonv1beta1.ConditionMigrationsComplete))
+				Expect(res.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+				Expect(res.Conditions[0].Reason).To(Equal(summonv1beta1.StatusMigrating))
+			})
+
 			It("still has a migration job", func() {
 				Expect(comp).To(ReconcileContext(ctx))
 				job := &batchv1.Job{}
@@ -173,6 +351,31 @@ var _ = Describe("SummonPlatform Migrations Component", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(jobs.Items).To(BeEmpty())
 				Expect(instance.Status.MigrateVersion).To(Equal("1.2.3"))
+				Expect(instance.Status.Flavor.Status).To(Equal(""))
+			})
+
+			It("marks the flavor imported when the job loaded one", func() {
+				job := &batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "foo-dev-migrations",
+						Namespace:   "summon-dev",
+						Labels:      map[string]string{"app.kubernetes.io/version": "1.2.3"},
+						Annotations: map[string]string{"summon.ridecell.io/flavor": "test-flavor"},
+					},
+					Status: batchv1.JobStatus{
+						Succeeded: 1,
+					},
+				}
+				ctx.Client = fake.NewFakeClient(job)
+				instance.Spec.Flavor = "test-flavor"
+				instance.Status.Flavor = summonv1beta1.FlavorStatus{Flavor: "test-flavor", Status: summonv1beta1.FlavorStatusImporting, Version: "1.2.3"}
+				Expect(comp).To(ReconcileContext(ctx))
+				Expect(instance.Status.Flavor.Status).To(Equal(summonv1beta1.FlavorStatusImported))
+				Expect(instance.Status.Flavor.Version).To(Equal("1.2.3"))
+				Expect(instance.Status.Flavor.ImportedAt).ToNot(BeEmpty())
+				recorder := ctx.Recorder.(*record.FakeRecorder)
+				Expect(recorder.Events).To(Receive(ContainSubstring("MigrationSucceeded")))
+				Expect(recorder.Events).To(Receive(ContainSubstring("FlavorImported")))
 			})
 		})
 
@@ -193,6 +396,8 @@ var _ = Describe("SummonPlatform Migrations Component", func() {
 
 			It("leaves the migration", func() {
 				Expect(comp).NotTo(ReconcileContext(ctx))
+				recorder := ctx.Recorder.(*record.FakeRecorder)
+				Expect(recorder.Events).To(Receive(ContainSubstring("MigrationFailed")))
 				job := &batchv1.Job{}
 				err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-migrations", Namespace: "summon-dev"}, job)
 				Expect(err).NotTo(HaveOccurred())
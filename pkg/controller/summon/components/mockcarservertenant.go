@@ -21,7 +21,6 @@ import (
 	"github.com/Ridecell/ridecell-operator/pkg/components"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 )
 
 type newMockCarServerTenantComponent struct{}
@@ -40,18 +39,18 @@ func (_ *newMockCarServerTenantComponent) IsReconcilable(_ *components.Component
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *newMockCarServerTenantComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *newMockCarServerTenantComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 	if !instance.Spec.EnableMockCarServer {
-		// Check if mock tenant is present or not?
-		// if present, then delete it
-		mockcarservertenant := &summonv1beta1.MockCarServerTenant{}
-		err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, mockcarservertenant)
-		if err == nil {
-			err = ctx.Delete(ctx.Context, mockcarservertenant)
-			if err != nil {
-				return components.Result{}, errors.Wrapf(err, "mockcarservertenant: unable to delete MockCarServerTenant %s", instance.Name)
-			}
+		// Clean up any tenant created while it was enabled, including one from before templates were labeled.
+		_, err := ctx.PruneNamed("mockcarservertenant.yml.tpl", &summonv1beta1.MockCarServerTenant{}, instance.Name)
+		if err != nil {
+			return components.Result{}, errors.Wrap(err, "mockcarservertenant: unable to prune")
 		}
 		return components.Result{}, nil
 	}
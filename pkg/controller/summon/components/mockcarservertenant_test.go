@@ -20,7 +20,10 @@ import (
 	"context"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
@@ -45,11 +48,59 @@ var _ = Describe("SummonPlatform MockCarServerTenant Component", func() {
 		})
 
 		It("deletes a MockCarServerTenant when disabled", func() {
-			instance.Spec.EnableMockCarServer = false
+			instance.Spec.EnableMockCarServer = true
 			Expect(comp).To(ReconcileContext(ctx))
 			mockTenant := &summonv1beta1.MockCarServerTenant{}
 			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mockTenant.Labels).To(HaveKeyWithValue(components.TemplateLabel, "mockcarservertenant.yml"))
+
+			instance.Spec.EnableMockCarServer = false
+			Expect(comp).To(ReconcileContext(ctx))
+			err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("deletes an unlabeled MockCarServerTenant from before templates were labeled", func() {
+			mockTenant := &summonv1beta1.MockCarServerTenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+			}
+			err := controllerutil.SetControllerReference(instance, mockTenant, ctx.Scheme)
+			Expect(err).NotTo(HaveOccurred())
+			err = ctx.Client.Create(context.TODO(), mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(comp).To(ReconcileContext(ctx))
+			err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("leaves an unlabeled MockCarServerTenant it doesn't control", func() {
+			mockTenant := &summonv1beta1.MockCarServerTenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+			}
+			err := ctx.Client.Create(context.TODO(), mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(comp).To(ReconcileContext(ctx))
+			err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not delete a MockCarServerTenant marked to skip pruning", func() {
+			instance.Spec.EnableMockCarServer = true
+			Expect(comp).To(ReconcileContext(ctx))
+			mockTenant := &summonv1beta1.MockCarServerTenant{}
+			err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+			mockTenant.Annotations = map[string]string{components.SkipPruneAnnotation: "true"}
+			err = ctx.Client.Update(context.TODO(), mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+
+			instance.Spec.EnableMockCarServer = false
+			Expect(comp).To(ReconcileContext(ctx))
+			err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, mockTenant)
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 })
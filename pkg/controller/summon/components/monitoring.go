@@ -42,6 +42,11 @@ func (_ *monitoringComponent) IsReconcilable(_ *components.ComponentContext) boo
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *monitoringComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *monitoringComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 	if !(instance.Spec.Monitoring.Enabled != nil && *instance.Spec.Monitoring.Enabled) || len(instance.Spec.Notifications.SlackChannel) == 0 {
@@ -40,6 +40,11 @@ func (_ *newRelicComponent) IsReconcilable(_ *components.ComponentContext) bool
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *newRelicComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *newRelicComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 	if instance.Spec.EnableNewRelic == nil || !*instance.Spec.EnableNewRelic {
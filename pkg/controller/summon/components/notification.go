@@ -26,6 +26,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/nlopes/slack"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -135,8 +136,9 @@ func (_ *notificationComponent) WatchTypes() []runtime.Object {
 	return []runtime.Object{}
 }
 
-func (_ *notificationComponent) IsReconcilable(_ *components.ComponentContext) bool {
-	return true
+func (_ *notificationComponent) IsReconcilable(ctx *components.ComponentContext) bool {
+	// Never send notifications from a dry run.
+	return !ctx.DryRun
 }
 
 func (c *notificationComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
@@ -144,8 +146,12 @@ func (c *notificationComponent) Reconcile(ctx *components.ComponentContext) (com
 
 	if instance.Status.Status == summonv1beta1.StatusReady {
 		return c.handleSuccess(instance)
-	} else if instance.Status.Status == summonv1beta1.StatusError {
+	} else if instance.Status.Status == summonv1beta1.StatusError || instance.Status.Status == summonv1beta1.StatusRolledBack {
 		return c.handleError(instance, instance.Status.Message)
+	} else if instance.Status.Status == summonv1beta1.StatusDeploying && len(instance.Status.RolloutProblems) > 0 {
+		return c.handleError(instance, fmt.Sprintf("rollout of version %s is stuck: %s", instance.Spec.Version, rolloutProblemSummary(instance.Status.RolloutProblems)))
+	} else if instance.Status.Status == summonv1beta1.StatusDeploying && selfCheckFailingFor(instance, time.Now()) >= selfCheckNotifyDelay {
+		return c.handleError(instance, fmt.Sprintf("self checks for version %s are failing: %s", instance.Spec.Version, selfCheckFailures(instance.Status.SelfCheck.Results)))
 	}
 
 	// No notifications needed.
@@ -154,7 +160,7 @@ func (c *notificationComponent) Reconcile(ctx *components.ComponentContext) (com
 
 // ReconcileError implements components.ErrorHandler.
 func (c *notificationComponent) ReconcileError(ctx *components.ComponentContext, err error) (components.Result, error) {
-	if !errors.ShouldNotify(err) {
+	if ctx.DryRun || !errors.ShouldNotify(err) {
 		return components.Result{}, nil
 	}
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
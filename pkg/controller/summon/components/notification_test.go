@@ -19,6 +19,7 @@ package components_test
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/nlopes/slack"
 	. "github.com/onsi/ginkgo"
@@ -84,6 +85,42 @@ var _ = Describe("SummonPlatform Notification Component", func() {
 			Expect(mockedDeployStatusClient.PostStatusCalls()).To(HaveLen(0))
 		})
 
+		It("sends an error notification once self checks have been failing for a while", func() {
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			instance.Status.SelfCheck.FailingSince = time.Now().Add(-10 * time.Minute).Format(time.UnixDate)
+			instance.Status.SelfCheck.Results = []summonv1beta1.HTTPCheckResult{{Service: "web", Message: "web /healthz returned 502"}}
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(mockedSlackClient.PostMessageCalls()).To(HaveLen(1))
+			post := mockedSlackClient.PostMessageCalls()[0]
+			Expect(post.In2.Fallback).To(ContainSubstring("self checks for version 1.2.3 are failing: web /healthz returned 502"))
+
+			instance.Status.SelfCheck.FailingSince = time.Now().Add(-time.Minute).Format(time.UnixDate)
+			comp = summoncomponents.NewNotification()
+			comp.InjectSlackClient(mockedSlackClient)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(mockedSlackClient.PostMessageCalls()).To(HaveLen(1))
+		})
+
+		It("sends an error notification for a stuck rollout", func() {
+			instance.Status.Status = summonv1beta1.StatusDeploying
+			instance.Status.RolloutProblems = []summonv1beta1.RolloutProblem{{
+				Workload:     "web",
+				Reason:       "CrashLoopBackOff",
+				Pod:          "foo-dev-web-1234",
+				Container:    "default",
+				RestartCount: 4,
+			}}
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(mockedSlackClient.PostMessageCalls()).To(HaveLen(1))
+			post := mockedSlackClient.PostMessageCalls()[0]
+			Expect(post.In2.Fallback).To(ContainSubstring("rollout of version 1.2.3 is stuck: web container default is CrashLoopBackOff"))
+
+			// Restarts alone don't notify again.
+			instance.Status.RolloutProblems[0].RestartCount = 5
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(mockedSlackClient.PostMessageCalls()).To(HaveLen(1))
+		})
+
 		It("sends a success notification on a new deployment", func() {
 			instance.Spec.Version = "1234-eb6b515-master"
 			instance.Status.Notification.NotifyVersion = ""
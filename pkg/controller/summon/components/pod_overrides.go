@@ -0,0 +1,165 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"path"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/templates"
+)
+
+// Render a process template, merge in the pod overrides for that process and apply the result.
+func applyProcessTemplate(ctx *components.ComponentContext, templatePath string, extra map[string]interface{}, mutateFn func(runtime.Object, runtime.Object) error) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	target, err := ctx.GetTemplate(templatePath, extra)
+	if err == templates.ErrEmptyTemplate {
+		// Nothing to override, let Apply take care of pruning.
+		res, _, err := ctx.Apply(templatePath, extra, mutateFn)
+		return res, err
+	} else if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+
+	override := podOverride(instance, templatePath)
+	podTemplate := podTemplateFor(target)
+	if override != nil && podTemplate != nil {
+		applyPodOverride(podTemplate, override)
+	}
+
+	res, _, err := ctx.ApplyObject(templatePath, target, mutateFn)
+	return res, err
+}
+
+func podOverride(instance *summonv1beta1.SummonPlatform, templatePath string) *summonv1beta1.PodOverrideSpec {
+	switch path.Dir(templatePath) {
+	case "web":
+		return instance.Spec.PodOverrides.Web
+	case "daphne":
+		return instance.Spec.PodOverrides.Daphne
+	case "celeryd":
+		return instance.Spec.PodOverrides.Celeryd
+	case "celerybeat":
+		return instance.Spec.PodOverrides.CeleryBeat
+	case "channelworker":
+		return instance.Spec.PodOverrides.ChannelWorker
+	case "static":
+		return instance.Spec.PodOverrides.Static
+	case "dispatch":
+		return instance.Spec.PodOverrides.Dispatch
+	case "businessPortal":
+		return instance.Spec.PodOverrides.BusinessPortal
+	default:
+		return nil
+	}
+}
+
+func podTemplateFor(obj runtime.Object) *corev1.PodTemplateSpec {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	default:
+		return nil
+	}
+}
+
+func applyPodOverride(podTemplate *corev1.PodTemplateSpec, override *summonv1beta1.PodOverrideSpec) {
+	if len(override.Annotations) > 0 && podTemplate.Annotations == nil {
+		podTemplate.Annotations = map[string]string{}
+	}
+	for key, value := range override.Annotations {
+		podTemplate.Annotations[key] = value
+	}
+
+	podSpec := &podTemplate.Spec
+	if len(override.NodeSelector) > 0 && podSpec.NodeSelector == nil {
+		podSpec.NodeSelector = map[string]string{}
+	}
+	for key, value := range override.NodeSelector {
+		podSpec.NodeSelector[key] = value
+	}
+	for _, toleration := range override.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, *toleration.DeepCopy())
+	}
+	if override.Affinity != nil {
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &corev1.Affinity{}
+		}
+		if override.Affinity.NodeAffinity != nil {
+			podSpec.Affinity.NodeAffinity = override.Affinity.NodeAffinity.DeepCopy()
+		}
+		if override.Affinity.PodAffinity != nil {
+			podSpec.Affinity.PodAffinity = override.Affinity.PodAffinity.DeepCopy()
+		}
+		if override.Affinity.PodAntiAffinity != nil {
+			podSpec.Affinity.PodAntiAffinity = override.Affinity.PodAntiAffinity.DeepCopy()
+		}
+	}
+	if override.PriorityClassName != "" {
+		podSpec.PriorityClassName = override.PriorityClassName
+	}
+
+	// Init containers are left alone, they are small helpers with their own resources.
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != "default" {
+			continue
+		}
+		container.Resources.Requests = mergeResourceList(container.Resources.Requests, override.Resources.Requests)
+		container.Resources.Limits = mergeResourceList(container.Resources.Limits, override.Resources.Limits)
+		container.Env = mergeEnv(container.Env, override.Env)
+	}
+}
+
+func mergeResourceList(base, override corev1.ResourceList) corev1.ResourceList {
+	if len(override) == 0 {
+		return base
+	}
+	if base == nil {
+		base = corev1.ResourceList{}
+	}
+	for name, quantity := range override {
+		base[name] = quantity.DeepCopy()
+	}
+	return base
+}
+
+// Replace variables with the same name in place so ordering (and any $(VAR) references) stays stable, then add
+// the rest at the end.
+func mergeEnv(base, override []corev1.EnvVar) []corev1.EnvVar {
+	for _, envVar := range override {
+		replaced := false
+		for i := range base {
+			if base[i].Name == envVar.Name {
+				base[i] = *envVar.DeepCopy()
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, *envVar.DeepCopy())
+		}
+	}
+	return base
+}
@@ -17,7 +17,15 @@ limitations under the License.
 package components
 
 import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
@@ -37,11 +45,39 @@ func (comp *postgresComponent) WatchTypes() []runtime.Object {
 	}
 }
 
-func (_ *postgresComponent) IsReconcilable(_ *components.ComponentContext) bool {
+func (_ *postgresComponent) IsReconcilable(ctx *components.ComponentContext) bool {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if instance.Spec.CloneFrom == nil {
+		return true
+	}
+	// A clone's database has to start out restored, so wait until the clone knows what to restore.
+	switch instance.Status.Clone.Phase {
+	case "", summonv1beta1.ClonePhaseCopyingConfig, summonv1beta1.ClonePhaseSnapshotting:
+		// Unless the database already exists, so a cloneFrom added to an existing instance can't freeze it.
+		existing := &dbv1beta1.PostgresDatabase{}
+		err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, existing)
+		return !kerrors.IsNotFound(err)
+	}
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *postgresComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
+// ConditionType implements components.ConditionReporter.
+func (_ *postgresComponent) ConditionType() string {
+	return summonv1beta1.ConditionPostgresReady
+}
+
 func (comp *postgresComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	restorePhase := instance.Status.AutoRollback.Restore.Phase
+	if restorePhase == summonv1beta1.RestorePhaseDeletingDatabase {
+		return comp.deleteForRestore(ctx)
+	}
+
 	var existing *dbv1beta1.PostgresDatabase
 	res, _, err := ctx.CreateOrUpdate("postgres_database.yml.tpl", nil, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*dbv1beta1.PostgresDatabase)
@@ -55,6 +91,13 @@ func (comp *postgresComponent) Reconcile(ctx *components.ComponentContext) (comp
 		if existing.Status.Status == dbv1beta1.StatusError {
 			return res, errors.Errorf("postgres: %s", existing.Status.Message)
 		}
+		if existing.Status.Status != dbv1beta1.StatusReady {
+			res.Conditions = []components.Condition{components.NewCondition(summonv1beta1.ConditionPostgresReady, false, conditionReason(existing.Status.Status), existing.Status.Message)}
+		}
+		restored := restorePhase == summonv1beta1.RestorePhaseRestoring && existing.Status.Status == dbv1beta1.StatusReady
+		if restored {
+			ctx.Eventf(corev1.EventTypeNormal, "SnapshotRestored", "Restored database from snapshot %s", instance.Status.AutoRollback.Restore.SnapshotID)
+		}
 		res.StatusModifier = func(obj runtime.Object) error {
 			instance := obj.(*summonv1beta1.SummonPlatform)
 			instance.Status.PostgresStatus = existing.Status.Status
@@ -62,8 +105,46 @@ func (comp *postgresComponent) Reconcile(ctx *components.ComponentContext) (comp
 			if existing.Status.Status != "" {
 				instance.Status.Status = summonv1beta1.StatusInitializing
 			}
+			if restored {
+				instance.Status.AutoRollback.Restore.Phase = summonv1beta1.RestorePhaseComplete
+			}
 			return nil
 		}
 	}
 	return res, err
 }
+
+// Delete the PostgresDatabase so it can be re-created from the snapshot after a rollback. Waits until it is
+// completely gone, since the restored RDS instance gets the same ID as the old one.
+func (comp *postgresComponent) deleteForRestore(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	snapshotID := instance.Status.AutoRollback.Restore.SnapshotID
+	conditions := []components.Condition{components.NewCondition(summonv1beta1.ConditionPostgresReady, false, "RestoringSnapshot", fmt.Sprintf("Restoring database from snapshot %s", snapshotID))}
+
+	existing := &dbv1beta1.PostgresDatabase{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, existing)
+	if kerrors.IsNotFound(err) {
+		ctx.Eventf(corev1.EventTypeNormal, "RestoringSnapshot", "Re-creating database from snapshot %s", snapshotID)
+		return components.Result{Requeue: true, Conditions: conditions, StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.PostgresStatus = dbv1beta1.StatusCreating
+			instance.Status.AutoRollback.Restore.Phase = summonv1beta1.RestorePhaseRestoring
+			return nil
+		}}, nil
+	} else if err != nil {
+		return components.Result{}, errors.Wrapf(err, "postgres: unable to get PostgresDatabase %s/%s", instance.Namespace, instance.Name)
+	}
+
+	if existing.DeletionTimestamp == nil {
+		// Foreground so the PostgresDatabase sticks around until its RDSInstance is gone too.
+		err = ctx.Delete(ctx.Context, existing, client.PropagationPolicy(metav1.DeletePropagationForeground))
+		if err != nil && !kerrors.IsNotFound(err) {
+			return components.Result{}, errors.Wrapf(err, "postgres: unable to delete PostgresDatabase %s/%s to restore snapshot %s", instance.Namespace, instance.Name, snapshotID)
+		}
+	}
+	return components.Result{RequeueAfter: 30 * time.Second, Conditions: conditions, StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.PostgresStatus = dbv1beta1.StatusCreating
+		return nil
+	}}, nil
+}
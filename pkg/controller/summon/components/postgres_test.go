@@ -24,10 +24,13 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -40,11 +43,29 @@ var _ = Describe("SummonPlatform Postgres Component", func() {
 	})
 
 	Describe("IsReconcilable", func() {
-		It("should always be true", func() {
+		It("should be true", func() {
 			ok := comp.IsReconcilable(ctx)
 			Expect(ok).To(BeTrue())
 		})
 
+		It("waits for a clone to know what to restore", func() {
+			instance.Spec.CloneFrom = &summonv1beta1.CloneFromSpec{Instance: "bar-uat"}
+			Expect(comp.IsReconcilable(ctx)).To(BeFalse())
+			instance.Status.Clone.Phase = summonv1beta1.ClonePhaseSnapshotting
+			Expect(comp.IsReconcilable(ctx)).To(BeFalse())
+			instance.Status.Clone.Phase = summonv1beta1.ClonePhaseRestoringDatabase
+			Expect(comp.IsReconcilable(ctx)).To(BeTrue())
+		})
+
+		It("keeps reconciling an existing database when cloneFrom gets added", func() {
+			db := &dbv1beta1.PostgresDatabase{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+			}
+			ctx.Client = fake.NewFakeClient(instance, db)
+			instance.Spec.CloneFrom = &summonv1beta1.CloneFromSpec{Instance: "bar-uat"}
+			Expect(comp.IsReconcilable(ctx)).To(BeTrue())
+		})
+
 	})
 
 	Describe("Reconcile", func() {
@@ -56,6 +77,81 @@ var _ = Describe("SummonPlatform Postgres Component", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("restores a clone's database from its snapshot", func() {
+			instance.Status.Clone = summonv1beta1.CloneStatus{
+				Phase:               summonv1beta1.ClonePhaseRestoringDatabase,
+				SourceRDSInstanceID: "bar-uat",
+				SourceDatabase:      "bar_uat",
+				SourceOwner:         "bar-uat",
+				SnapshotID:          "bar-uat-clone-snapshot",
+			}
+			Expect(comp).To(ReconcileContext(ctx))
+
+			db := &dbv1beta1.PostgresDatabase{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, db)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Spec.RestoreFrom).ToNot(BeNil())
+			Expect(db.Spec.RestoreFrom.SnapshotID).To(Equal("bar-uat-clone-snapshot"))
+			Expect(db.Spec.RestoreFrom.SourceInstanceID).To(BeEmpty())
+			Expect(db.Spec.RestoreFrom.DatabaseName).To(Equal("bar_uat"))
+			Expect(db.Spec.RestoreFrom.Owner).To(Equal("bar-uat"))
+		})
+
+		It("restores a clone's database to a point in time", func() {
+			instance.Status.Clone = summonv1beta1.CloneStatus{
+				Phase:               summonv1beta1.ClonePhaseRestoringDatabase,
+				SourceRDSInstanceID: "bar-uat",
+				SourceDatabase:      "bar_uat",
+				RestoreTime:         "2020-01-06T20:00:00Z",
+			}
+			Expect(comp).To(ReconcileContext(ctx))
+
+			db := &dbv1beta1.PostgresDatabase{}
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, db)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Spec.RestoreFrom.SourceInstanceID).To(Equal("bar-uat"))
+			Expect(db.Spec.RestoreFrom.RestoreTime).To(Equal("2020-01-06T20:00:00Z"))
+		})
+
+		It("re-creates the database from the snapshot after a rollback", func() {
+			instance.Status.AutoRollback.Restore = summonv1beta1.SnapshotRestoreStatus{
+				Phase:      summonv1beta1.RestorePhaseDeletingDatabase,
+				SnapshotID: "foo-dev-1-2-3-snap",
+				Database:   "foo_dev",
+				Owner:      "foo_dev",
+			}
+			db := &dbv1beta1.PostgresDatabase{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+				Status:     dbv1beta1.PostgresDatabaseStatus{Status: dbv1beta1.StatusReady},
+			}
+			ctx.Client = fake.NewFakeClient(db)
+
+			// First the old database goes away.
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.PostgresStatus).To(Equal(dbv1beta1.StatusCreating))
+			err := ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, db)
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.AutoRollback.Restore.Phase).To(Equal(summonv1beta1.RestorePhaseRestoring))
+
+			// Then it comes back from the snapshot.
+			Expect(comp).To(ReconcileContext(ctx))
+			db = &dbv1beta1.PostgresDatabase{}
+			err = ctx.Get(context.TODO(), types.NamespacedName{Name: "foo-dev", Namespace: "summon-dev"}, db)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(db.Spec.RestoreFrom).ToNot(BeNil())
+			Expect(db.Spec.RestoreFrom.SnapshotID).To(Equal("foo-dev-1-2-3-snap"))
+			Expect(db.Spec.RestoreFrom.DatabaseName).To(Equal("foo_dev"))
+			Expect(db.Spec.RestoreFrom.Owner).To(Equal("foo_dev"))
+			Expect(instance.Status.AutoRollback.Restore.Phase).To(Equal(summonv1beta1.RestorePhaseRestoring))
+
+			db.Status.Status = dbv1beta1.StatusReady
+			Expect(ctx.Update(context.TODO(), db)).To(Succeed())
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.AutoRollback.Restore.Phase).To(Equal(summonv1beta1.RestorePhaseComplete))
+			Expect(instance.Status.PostgresStatus).To(Equal(dbv1beta1.StatusReady))
+		})
+
 		It("sets PostgresStatus", func() {
 			db := &dbv1beta1.PostgresDatabase{
 				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
@@ -69,6 +165,23 @@ var _ = Describe("SummonPlatform Postgres Component", func() {
 			Expect(instance.Status.PostgresStatus).To(Equal(dbv1beta1.StatusReady))
 		})
 
+		It("reports a PostgresReady condition while the database is still creating", func() {
+			db := &dbv1beta1.PostgresDatabase{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+				Status: dbv1beta1.PostgresDatabaseStatus{
+					Status: dbv1beta1.StatusCreating,
+				},
+			}
+			ctx.Client = fake.NewFakeClient(db)
+
+			res, err := comp.Reconcile(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Conditions).To(HaveLen(1))
+			Expect(res.Conditions[0].Type).To(Equal(summonv1beta1.ConditionPostgresReady))
+			Expect(res.Conditions[0].Status).To(Equal(corev1.ConditionFalse))
+			Expect(res.Conditions[0].Reason).To(Equal(dbv1beta1.StatusCreating))
+		})
+
 		Context("with database name migration override", func() {
 			BeforeEach(func() {
 				instance.Spec.MigrationOverrides.PostgresDatabase = "legacy"
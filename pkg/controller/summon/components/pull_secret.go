@@ -45,6 +45,16 @@ func (_ *pullSecretComponent) IsReconcilable(_ *components.ComponentContext) boo
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *pullSecretComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
+// ConditionType implements components.ConditionReporter.
+func (_ *pullSecretComponent) ConditionType() string {
+	return summonv1beta1.ConditionPullSecretReady
+}
+
 func (comp *pullSecretComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	var existing *secretsv1beta1.PullSecret
 	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
@@ -59,5 +69,8 @@ func (comp *pullSecretComponent) Reconcile(ctx *components.ComponentContext) (co
 		instance.Status.PullSecretStatus = existing.Status.Status
 		return nil
 	}
+	if existing != nil && existing.Status.Status != secretsv1beta1.StatusReady {
+		res.Conditions = []components.Condition{components.NewCondition(summonv1beta1.ConditionPullSecretReady, false, conditionReason(existing.Status.Status), existing.Status.Message)}
+	}
 	return res, err
 }
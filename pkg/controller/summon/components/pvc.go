@@ -41,6 +41,11 @@ func (comp *pvcComponent) IsReconcilable(ctx *components.ComponentContext) bool
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *pvcComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *pvcComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*corev1.PersistentVolumeClaim)
@@ -44,6 +44,16 @@ func (_ *rabbitmqVhostComponent) IsReconcilable(_ *components.ComponentContext)
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *rabbitmqVhostComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
+// ConditionType implements components.ConditionReporter.
+func (_ *rabbitmqVhostComponent) ConditionType() string {
+	return summonv1beta1.ConditionRabbitMQReady
+}
+
 func (comp *rabbitmqVhostComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	var existing *dbv1beta1.RabbitmqVhost
 	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
@@ -58,6 +68,9 @@ func (comp *rabbitmqVhostComponent) Reconcile(ctx *components.ComponentContext)
 		if existing.Status.Status == dbv1beta1.StatusError {
 			return res, errors.Errorf("rabbitmq: %s", existing.Status.Message)
 		}
+		if existing.Status.Status != dbv1beta1.StatusReady {
+			res.Conditions = []components.Condition{components.NewCondition(summonv1beta1.ConditionRabbitMQReady, false, conditionReason(existing.Status.Status), existing.Status.Message)}
+		}
 		res.StatusModifier = func(obj runtime.Object) error {
 			instance := obj.(*summonv1beta1.SummonPlatform)
 			instance.Status.RabbitMQStatus = existing.Status.Status
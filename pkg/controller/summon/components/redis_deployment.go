@@ -45,13 +45,21 @@ func (comp *redisDeploymentComponent) IsReconcilable(ctx *components.ComponentCo
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *redisDeploymentComponent) Dependencies() []components.Component {
+	return []components.Component{
+		&pvcComponent{},
+	}
+}
+
 func (comp *redisDeploymentComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 	// If we're not in deploying state do nothing and exit early.
-	if instance.Status.Status != summonv1beta1.StatusDeploying {
+	if instance.Status.Status != summonv1beta1.StatusDeploying && instance.Status.Status != summonv1beta1.StatusHibernating {
 		return components.Result{}, nil
 	}
-	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
+	extra := map[string]interface{}{"hibernating": instance.Status.Status == summonv1beta1.StatusHibernating}
+	res, _, err := ctx.CreateOrUpdate(comp.templatePath, extra, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*appsv1.Deployment)
 		existing := existingObj.(*appsv1.Deployment)
 		// Copy the Spec over.
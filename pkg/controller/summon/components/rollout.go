@@ -0,0 +1,310 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	dbv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/db/v1beta1"
+	secretsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/secrets/v1beta1"
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// How often to re-run the canary checks while waiting out the canary duration.
+const canaryCheckInterval = 30 * time.Second
+
+type rolloutComponent struct {
+	templatePath string
+}
+
+func NewRollout(templatePath string) *rolloutComponent {
+	return &rolloutComponent{templatePath: templatePath}
+}
+
+func (_ *rolloutComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{},
+	}
+}
+
+func (_ *rolloutComponent) IsReconcilable(ctx *components.ComponentContext) bool {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if instance.Status.PullSecretStatus != secretsv1beta1.StatusReady {
+		return false
+	}
+	if instance.Status.PostgresStatus != dbv1beta1.StatusReady {
+		return false
+	}
+	// Don't check Status.Status here, Reconcile only does anything while Deploying anyway.
+	return true
+}
+
+// Dependencies implements components.Dependent.
+func (_ *rolloutComponent) Dependencies() []components.Component {
+	return []components.Component{
+		&appSecretComponent{},
+		&configmapComponent{},
+		&migrationComponent{},
+	}
+}
+
+// Should this version go through a canary at all. Needs a previous version to fall back to.
+func canaryWanted(instance *summonv1beta1.SummonPlatform) bool {
+	stable := instance.Status.Rollout.StableVersion
+	return instance.Spec.Rollout.Strategy == summonv1beta1.RolloutStrategyCanary && stable != "" && stable != instance.Spec.Version
+}
+
+// Should the regular Deployments be left at the stable version, because the canary for this version is still
+// running or was rolled back.
+func rolloutHolding(instance *summonv1beta1.SummonPlatform) bool {
+	if !canaryWanted(instance) {
+		return false
+	}
+	rollout := instance.Status.Rollout
+	return !(rollout.Phase == summonv1beta1.RolloutPhasePromoted && rollout.CanaryVersion == instance.Spec.Version)
+}
+
+func (comp *rolloutComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if instance.Status.Status != summonv1beta1.StatusDeploying {
+		return components.Result{}, nil
+	}
+	rollout := instance.Status.Rollout
+
+	if !canaryWanted(instance) {
+		// Clean up after a canary which no longer applies, like when switching back to AllAtOnce.
+		err := comp.deleteCanary(ctx)
+		if err != nil {
+			return components.Result{Requeue: true}, err
+		}
+		if rollout.Phase == summonv1beta1.RolloutPhaseCanary {
+			return components.Result{StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.Rollout.Phase = ""
+				instance.Status.Rollout.Message = fmt.Sprintf("Canary for version %s abandoned", rollout.CanaryVersion)
+				return nil
+			}}, nil
+		}
+		return components.Result{}, nil
+	}
+
+	if rollout.CanaryVersion == instance.Spec.Version {
+		switch rollout.Phase {
+		case summonv1beta1.RolloutPhasePromoted:
+			return components.Result{}, comp.deleteCanary(ctx)
+		case summonv1beta1.RolloutPhaseRolledBack:
+			// Stay rolled back until a new version is set.
+			err := comp.deleteCanary(ctx)
+			if err != nil {
+				return components.Result{Requeue: true}, err
+			}
+			return components.Result{StatusModifier: setRolledBackStatus(instance, rollout.Message)}, nil
+		case summonv1beta1.RolloutPhaseCanary:
+			return comp.checkCanary(ctx)
+		}
+	}
+	return comp.startCanary(ctx)
+}
+
+func (comp *rolloutComponent) applyCanary(ctx *components.ComponentContext) error {
+	extra, err := deploymentHashes(ctx)
+	if err != nil {
+		return err
+	}
+	extra["canary"] = true
+	extra["canaryReplicas"] = canaryReplicas(ctx.Top.(*summonv1beta1.SummonPlatform))
+	_, err = applyProcessTemplate(ctx, comp.templatePath, extra, nil)
+	if err != nil {
+		return errors.Wrapf(err, "rollout: failed to update canary template %s", comp.templatePath)
+	}
+	return nil
+}
+
+func canaryReplicas(instance *summonv1beta1.SummonPlatform) int32 {
+	if instance.Spec.Rollout.Canary.Replicas == nil {
+		return 1
+	}
+	return *instance.Spec.Rollout.Canary.Replicas
+}
+
+func (comp *rolloutComponent) startCanary(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	err := comp.applyCanary(ctx)
+	if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+
+	version := instance.Spec.Version
+	stable := instance.Status.Rollout.StableVersion
+	glog.Infof("[%s/%s] rollout: starting canary for version %s\n", instance.Namespace, instance.Name, version)
+	ctx.Eventf(corev1.EventTypeNormal, "CanaryStarted", "Started %d canary pods for version %s next to %s", canaryReplicas(instance), version, stable)
+	startTime := time.Now()
+	return components.Result{
+		StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPlatform)
+			instance.Status.Rollout.Phase = summonv1beta1.RolloutPhaseCanary
+			instance.Status.Rollout.CanaryVersion = version
+			instance.Status.Rollout.StartTime = startTime.Format(time.UnixDate)
+			instance.Status.Rollout.Message = "Canary started"
+			instance.Status.Message = fmt.Sprintf("Running canary for version %s", version)
+			return nil
+		},
+		RequeueAfter: comp.checkInterval(instance),
+	}, nil
+}
+
+func (comp *rolloutComponent) checkCanary(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	err := comp.applyCanary(ctx)
+	if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+	// Not found is fine, the checks see a canary with nothing available yet.
+	canary := &appsv1.Deployment{}
+	err = ctx.Get(ctx.Context, comp.canaryName(instance), canary)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return components.Result{Requeue: true}, errors.Wrap(err, "rollout: unable to get canary deployment")
+	}
+	canary.Name = comp.canaryName(instance).Name
+	canary.Namespace = instance.Namespace
+
+	startTime, err := time.Parse(time.UnixDate, instance.Status.Rollout.StartTime)
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "rollout: failed to parse canary start time")
+	}
+	remaining := startTime.Add(instance.Spec.Rollout.Canary.Duration.Duration).Sub(time.Now())
+	done := remaining <= 0
+
+	version := instance.Spec.Version
+	messages := []string{}
+	for _, name := range instance.Spec.Rollout.Canary.Checks {
+		check, ok := canaryChecks[name]
+		if !ok {
+			return components.Result{}, errors.Errorf("rollout: unknown canary check %s", name)
+		}
+		passed, message, err := check(ctx, canary, done)
+		if err != nil {
+			return components.Result{Requeue: true}, errors.Wrapf(err, "rollout: canary check %s failed to run", name)
+		}
+		if !passed {
+			return comp.rollBack(ctx, fmt.Sprintf("%s: %s", name, message))
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", name, message))
+	}
+	message := strings.Join(messages, ", ")
+
+	if !done {
+		return components.Result{
+			StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.Rollout.Message = message
+				instance.Status.Message = fmt.Sprintf("Running canary for version %s", version)
+				return nil
+			},
+			RequeueAfter: minDuration(remaining, canaryCheckInterval),
+		}, nil
+	}
+
+	// Promote by removing the canary and letting the regular Deployments roll out on the next reconcile.
+	err = comp.deleteCanary(ctx)
+	if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+	glog.Infof("[%s/%s] rollout: promoting canary for version %s\n", instance.Namespace, instance.Name, version)
+	ctx.Eventf(corev1.EventTypeNormal, "CanaryPromoted", "Canary for version %s passed (%s), rolling out", version, message)
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Rollout.Phase = summonv1beta1.RolloutPhasePromoted
+		instance.Status.Rollout.Message = message
+		return nil
+	}}, nil
+}
+
+func (comp *rolloutComponent) rollBack(ctx *components.ComponentContext, reason string) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	err := comp.deleteCanary(ctx)
+	if err != nil {
+		return components.Result{Requeue: true}, err
+	}
+	message := fmt.Sprintf("Canary for version %s failed, %s. Staying on version %s", instance.Spec.Version, reason, instance.Status.Rollout.StableVersion)
+	glog.Errorf("[%s/%s] rollout: %s\n", instance.Namespace, instance.Name, message)
+	ctx.Event(corev1.EventTypeWarning, "CanaryFailed", message)
+	rolledBack := setRolledBackStatus(instance, message)
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Rollout.Phase = summonv1beta1.RolloutPhaseRolledBack
+		return rolledBack(obj)
+	}}, nil
+}
+
+// Mark the version as rolled back the same way an automatic rollback does, so migrations hold and the Deployments
+// stay at the stable version until a new version is set.
+func setRolledBackStatus(instance *summonv1beta1.SummonPlatform, message string) components.StatusModifier {
+	failed := instance.Spec.Version
+	revertTo := instance.Status.Rollout.StableVersion
+	return func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Rollout.Message = message
+		instance.Status.Status = summonv1beta1.StatusRolledBack
+		instance.Status.Message = message
+		instance.Status.AutoRollback.FailedVersion = failed
+		instance.Status.AutoRollback.RevertedTo = revertTo
+		return nil
+	}
+}
+
+func (comp *rolloutComponent) deleteCanary(ctx *components.ComponentContext) error {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	canary := &appsv1.Deployment{}
+	err := ctx.Get(ctx.Context, comp.canaryName(instance), canary)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "rollout: unable to get canary deployment")
+	}
+	err = ctx.Delete(ctx.Context, canary)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrap(err, "rollout: unable to delete canary deployment")
+	}
+	return nil
+}
+
+func (_ *rolloutComponent) canaryName(instance *summonv1beta1.SummonPlatform) types.NamespacedName {
+	return types.NamespacedName{Name: fmt.Sprintf("%s-web-canary", instance.Name), Namespace: instance.Namespace}
+}
+
+func (_ *rolloutComponent) checkInterval(instance *summonv1beta1.SummonPlatform) time.Duration {
+	return minDuration(instance.Spec.Rollout.Canary.Duration.Duration, canaryCheckInterval)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
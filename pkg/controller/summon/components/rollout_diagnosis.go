@@ -0,0 +1,141 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
+)
+
+const (
+	// How many log lines to keep from a failing container.
+	rolloutLogLines = 20
+	// Cap on the logs kept per container, so a chatty traceback can't bloat the object.
+	rolloutLogBytes = 2048
+)
+
+//go:generate moq -pkg components -out zz_generated.mock_podlogclient_test.go ../../../utils PodLogClient
+
+// Find out why workloads aren't becoming available. Returns nil if nothing looks stuck, they might just be slow.
+func (comp *statusComponent) diagnoseRollout(ctx *components.ComponentContext, workloads []workload) ([]summonv1beta1.RolloutProblem, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	var problems []summonv1beta1.RolloutProblem
+	for _, workload := range workloads {
+		if workload.Ready {
+			continue
+		}
+		if workload.Rollout == summonv1beta1.WorkloadRolloutStuck {
+			problems = append(problems, summonv1beta1.RolloutProblem{
+				Workload: workload.Name,
+				Reason:   "ProgressDeadlineExceeded",
+				Message:  workload.Message,
+			})
+		}
+		if len(workload.podLabels) == 0 {
+			continue
+		}
+
+		pods := &corev1.PodList{}
+		err := ctx.List(ctx.Context, &client.ListOptions{Namespace: instance.Namespace, LabelSelector: labels.SelectorFromSet(workload.podLabels)}, pods)
+		if err != nil {
+			return nil, errors.Wrapf(err, "status: unable to list pods for %s subsystem", workload.Name)
+		}
+		// Every replica usually fails the same way, one of each is plenty.
+		seen := map[string]bool{}
+		for _, pod := range pods.Items {
+			for _, containerStatus := range pod.Status.ContainerStatuses {
+				problem := containerProblem(containerStatus)
+				if problem == nil || seen[problem.Container+"/"+problem.Reason] {
+					continue
+				}
+				seen[problem.Container+"/"+problem.Reason] = true
+				problem.Workload = workload.Name
+				problem.Pod = pod.Name
+				if problem.Reason != "ImagePullBackOff" && problem.Reason != "ErrImagePull" {
+					problem.LastLogLines = comp.lastLogLines(pod.Namespace, pod.Name, containerStatus)
+				}
+				problems = append(problems, *problem)
+			}
+		}
+	}
+	return problems, nil
+}
+
+// Check a container for the failures which never fix themselves.
+func containerProblem(status corev1.ContainerStatus) *summonv1beta1.RolloutProblem {
+	lastTerminated := status.LastTerminationState.Terminated
+	if waiting := status.State.Waiting; waiting != nil {
+		switch waiting.Reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull":
+			problem := &summonv1beta1.RolloutProblem{
+				Container:    status.Name,
+				Reason:       waiting.Reason,
+				Message:      waiting.Message,
+				RestartCount: status.RestartCount,
+			}
+			// Running out of memory is the more useful thing to know about a crashloop.
+			if waiting.Reason == "CrashLoopBackOff" && lastTerminated != nil && lastTerminated.Reason == "OOMKilled" {
+				problem.Reason = "OOMKilled"
+			}
+			return problem
+		}
+	}
+	if !status.Ready && lastTerminated != nil && lastTerminated.Reason == "OOMKilled" {
+		return &summonv1beta1.RolloutProblem{
+			Container:    status.Name,
+			Reason:       "OOMKilled",
+			Message:      fmt.Sprintf("Exited with code %d after running out of memory", lastTerminated.ExitCode),
+			RestartCount: status.RestartCount,
+		}
+	}
+	return nil
+}
+
+// The last lines the container logged, from before its most recent restart if it has restarted.
+func (comp *statusComponent) lastLogLines(namespace string, pod string, status corev1.ContainerStatus) string {
+	logs, err := comp.podLogClient.TailLogs(namespace, pod, status.Name, status.RestartCount > 0, rolloutLogLines)
+	if err != nil {
+		// Not worth failing the reconcile over, the reason alone is still useful.
+		glog.Errorf("status: unable to get logs for %s/%s container %s: %s\n", namespace, pod, status.Name, err)
+		return ""
+	}
+	return utils.TrimLogs(logs, rolloutLogBytes)
+}
+
+// One line summary of the problems, for the status message and notifications. Leaves out anything which changes
+// between reconciles, so the same problem doesn't get notified over and over.
+func rolloutProblemSummary(problems []summonv1beta1.RolloutProblem) string {
+	summaries := []string{}
+	for _, problem := range problems {
+		if problem.Container == "" {
+			summaries = append(summaries, fmt.Sprintf("%s is past its progress deadline", problem.Workload))
+		} else {
+			summaries = append(summaries, fmt.Sprintf("%s container %s is %s", problem.Workload, problem.Container, problem.Reason))
+		}
+	}
+	return strings.Join(summaries, ", ")
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform rollout diagnosis", func() {
+	comp := summoncomponents.NewStatus()
+	var mockLogs *summoncomponents.PodLogClientMock
+	var webDeployment *appsv1.Deployment
+	var webPod *corev1.Pod
+
+	BeforeEach(func() {
+		comp = summoncomponents.NewStatus()
+		mockLogs = &summoncomponents.PodLogClientMock{
+			TailLogsFunc: func(_ string, _ string, _ string, _ bool, _ int64) (string, error) {
+				return "Traceback (most recent call last):\nImportError: No module named summon_platform\n", nil
+			},
+		}
+		comp.InjectPodLogClient(mockLogs)
+
+		webDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-web", Namespace: "summon-dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: intp(1)},
+		}
+		webPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-dev-web-1234",
+				Namespace: "summon-dev",
+				Labels: map[string]string{
+					"app.kubernetes.io/instance": "foo-dev-web",
+					"app.kubernetes.io/version":  "1.2.3",
+				},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "default",
+					RestartCount: 4,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "Back-off 1m20s restarting failed container",
+						},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1},
+					},
+				}},
+			},
+		}
+		instance.Status.Status = summonv1beta1.StatusDeploying
+	})
+
+	reconcile := func() {
+		ctx.Client = fake.NewFakeClient(webDeployment, webPod)
+		Expect(comp).To(ReconcileContext(ctx))
+	}
+
+	It("diagnoses a crashloop", func() {
+		reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Message).To(Equal("Rollout stuck: web container default is CrashLoopBackOff"))
+		Expect(instance.Status.RolloutProblems).To(HaveLen(1))
+		problem := instance.Status.RolloutProblems[0]
+		Expect(problem.Workload).To(Equal("web"))
+		Expect(problem.Pod).To(Equal("foo-dev-web-1234"))
+		Expect(problem.RestartCount).To(Equal(int32(4)))
+		Expect(problem.LastLogLines).To(Equal("Traceback (most recent call last):\nImportError: No module named summon_platform"))
+		Expect(mockLogs.TailLogsCalls()).To(HaveLen(1))
+		Expect(mockLogs.TailLogsCalls()[0].Previous).To(BeTrue())
+	})
+
+	It("points out containers running out of memory", func() {
+		webPod.Status.ContainerStatuses[0].LastTerminationState.Terminated.Reason = "OOMKilled"
+		reconcile()
+		Expect(instance.Status.RolloutProblems).To(HaveLen(1))
+		Expect(instance.Status.RolloutProblems[0].Reason).To(Equal("OOMKilled"))
+	})
+
+	It("doesn't fetch logs for a bad image", func() {
+		webPod.Status.ContainerStatuses[0].RestartCount = 0
+		webPod.Status.ContainerStatuses[0].State.Waiting.Reason = "ImagePullBackOff"
+		reconcile()
+		Expect(instance.Status.RolloutProblems).To(HaveLen(1))
+		Expect(instance.Status.RolloutProblems[0].Reason).To(Equal("ImagePullBackOff"))
+		Expect(mockLogs.TailLogsCalls()).To(BeEmpty())
+	})
+
+	It("ignores pods from other versions", func() {
+		webPod.Labels["app.kubernetes.io/version"] = "1.2.2"
+		reconcile()
+		Expect(instance.Status.RolloutProblems).To(BeEmpty())
+	})
+
+	It("reports deployments past their progress deadline", func() {
+		webPod.Status.ContainerStatuses[0].State.Waiting.Reason = "ContainerCreating"
+		webDeployment.Status.Conditions = []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentProgressing,
+			Reason:  "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "foo-dev-web-1234" has timed out progressing.`,
+		}}
+		reconcile()
+		Expect(instance.Status.RolloutProblems).To(HaveLen(1))
+		Expect(instance.Status.RolloutProblems[0].Reason).To(Equal("ProgressDeadlineExceeded"))
+		Expect(instance.Status.Message).To(Equal("Rollout stuck: web is past its progress deadline"))
+	})
+})
@@ -0,0 +1,236 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPlatform Rollout Component", func() {
+	comp := summoncomponents.NewRollout("web/deployment.yml.tpl")
+
+	setupClient := func(extra ...runtime.Object) {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-config", Namespace: instance.Namespace},
+			Data:       map[string]string{"summon-platform.yml": "{}\n"},
+		}
+		appSecrets := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev.app-secrets", Namespace: instance.Namespace},
+			Data:       map[string][]byte{"filler": []byte("test")},
+		}
+		ctx.Client = fake.NewFakeClient(append([]runtime.Object{configMap, appSecrets}, extra...)...)
+	}
+
+	getCanary := func() (*appsv1.Deployment, error) {
+		canary := &appsv1.Deployment{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web-canary", Namespace: instance.Namespace}, canary)
+		return canary, err
+	}
+
+	runningCanary := func(available int32) *appsv1.Deployment {
+		instance.Status.Rollout.Phase = summonv1beta1.RolloutPhaseCanary
+		instance.Status.Rollout.CanaryVersion = "1.2.3"
+		instance.Status.Rollout.StartTime = time.Now().Add(-10 * time.Minute).Format(time.UnixDate)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-web-canary", Namespace: instance.Namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: intp(1)},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: available},
+		}
+	}
+
+	BeforeEach(func() {
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		instance.Status.Rollout.StableVersion = "1.2.2"
+		instance.Spec.Rollout = summonv1beta1.RolloutSpec{
+			Strategy: summonv1beta1.RolloutStrategyCanary,
+			Canary: summonv1beta1.CanarySpec{
+				Replicas: intp(1),
+				Duration: metav1.Duration{Duration: 5 * time.Minute},
+				Checks:   []string{"readiness"},
+			},
+		}
+		setupClient()
+	})
+
+	It("does nothing for the AllAtOnce strategy", func() {
+		instance.Spec.Rollout.Strategy = summonv1beta1.RolloutStrategyAllAtOnce
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err := getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		Expect(instance.Status.Rollout.Phase).To(Equal(""))
+	})
+
+	It("does nothing without a stable version to fall back to", func() {
+		instance.Status.Rollout.StableVersion = ""
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err := getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("starts a canary for a new version", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		canary, err := getCanary()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(canary.Spec.Replicas).To(PointTo(BeEquivalentTo(1)))
+		Expect(canary.Spec.Template.Spec.Containers[0].Image).To(Equal("us.gcr.io/ridecell-1/summon:1.2.3"))
+		Expect(canary.Spec.Template.Labels["app.kubernetes.io/name"]).To(Equal("web"))
+		Expect(canary.Spec.Selector.MatchLabels["app.kubernetes.io/instance"]).To(Equal("foo-dev-web-canary"))
+		Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhaseCanary))
+		Expect(instance.Status.Rollout.CanaryVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.Rollout.StartTime).ToNot(BeEmpty())
+	})
+
+	It("holds the regular deployments while the canary runs", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		web := summoncomponents.NewDeployment("web/deployment.yml.tpl")
+		Expect(web).To(ReconcileContext(ctx))
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, &appsv1.Deployment{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("keeps waiting while the canary duration hasn't passed", func() {
+		setupClient(runningCanary(0))
+		instance.Status.Rollout.StartTime = time.Now().Format(time.UnixDate)
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhaseCanary))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		_, err := getCanary()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("promotes a healthy canary", func() {
+		setupClient(runningCanary(1))
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhasePromoted))
+		_, err := getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+		web := summoncomponents.NewDeployment("web/deployment.yml.tpl")
+		Expect(web).To(ReconcileContext(ctx))
+		err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, &appsv1.Deployment{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rolls back a canary that never became ready", func() {
+		setupClient(runningCanary(0))
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhaseRolledBack))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+		Expect(instance.Status.Message).To(ContainSubstring("Staying on version 1.2.2"))
+		Expect(instance.Status.AutoRollback.FailedVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.AutoRollback.RevertedTo).To(Equal("1.2.2"))
+		_, err := getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		recorder := ctx.Recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).To(Receive(ContainSubstring("CanaryFailed")))
+
+		// Next time around it stays rolled back.
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+		_, err = getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("keeps the Deployments on the stable version after rolling back, like an automatic rollback", func() {
+		setupClient(runningCanary(0))
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusRolledBack))
+
+		Expect(summoncomponents.NewDeployment("web/deployment.yml.tpl")).To(ReconcileContext(ctx))
+		deployment := &appsv1.Deployment{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: instance.Namespace}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("us.gcr.io/ridecell-1/summon:1.2.2"))
+	})
+
+	It("cleans up a canary after switching back to AllAtOnce", func() {
+		setupClient(runningCanary(0))
+		instance.Spec.Rollout.Strategy = summonv1beta1.RolloutStrategyAllAtOnce
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Rollout.Phase).To(Equal(""))
+		_, err := getCanary()
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	Context("with the errorRate check", func() {
+		var server *httptest.Server
+		var errorRate string
+
+		BeforeEach(func() {
+			errorRate = "0"
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/api/v1/query"))
+				Expect(r.URL.Query().Get("query")).To(ContainSubstring(`pod=~"foo-dev-web-canary-.*"`))
+				fmt.Fprintf(w, `{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {}, "value": [1580000000, "%s"]}]}}`, errorRate)
+			}))
+			os.Setenv("PROMETHEUS_URL", server.URL)
+			instance.Spec.Rollout.Canary.Checks = []string{"readiness", "errorRate"}
+			instance.Spec.Rollout.Canary.MaxErrorRate = intp(5)
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("PROMETHEUS_URL")
+			server.Close()
+		})
+
+		It("promotes a canary with few errors", func() {
+			errorRate = "0.01"
+			setupClient(runningCanary(1))
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhasePromoted))
+			Expect(instance.Status.Rollout.Message).To(ContainSubstring("error rate 1.0%"))
+		})
+
+		It("treats no traffic as passing", func() {
+			errorRate = "NaN"
+			setupClient(runningCanary(1))
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhasePromoted))
+		})
+
+		It("rolls back a canary with too many errors before the duration is up", func() {
+			errorRate = "0.2"
+			setupClient(runningCanary(1))
+			instance.Status.Rollout.StartTime = time.Now().Format(time.UnixDate)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Rollout.Phase).To(Equal(summonv1beta1.RolloutPhaseRolledBack))
+			Expect(instance.Status.Message).To(ContainSubstring("error rate 20.0% is above 5%"))
+		})
+	})
+})
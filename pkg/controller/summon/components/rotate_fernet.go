@@ -56,6 +56,16 @@ func (_ *fernetRotateComponent) IsReconcilable(_ *components.ComponentContext) b
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *fernetRotateComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
+// RunWhilePaused implements components.PauseExempt. Key rotation shouldn't stop because deploys are frozen.
+func (_ *fernetRotateComponent) RunWhilePaused() bool {
+	return true
+}
+
 func (comp *fernetRotateComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
@@ -125,6 +135,7 @@ func (comp *fernetRotateComponent) Reconcile(ctx *components.ComponentContext) (
 	if err != nil {
 		return components.Result{}, errors.Wrap(err, "rotate_fernet: Failed to update secret")
 	}
+	ctx.Eventf(corev1.EventTypeNormal, "FernetKeyRotated", "Added new fernet key %s to %s", timeStamp, fetchSecret.Name)
 
 	return components.Result{}, nil
 }
@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
@@ -53,6 +54,8 @@ var _ = Describe("rotate_fernet Component", func() {
 		for _, v := range fernetSecret.Data {
 			Expect(v).To(HaveLen(86))
 		}
+		recorder := ctx.Recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).To(Receive(ContainSubstring("FernetKeyRotated")))
 	})
 
 	It("Adds a new key if the old one is expired", func() {
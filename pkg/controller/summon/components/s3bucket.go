@@ -52,6 +52,11 @@ func (_ *s3BucketComponent) IsReconcilable(_ *components.ComponentContext) bool
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *s3BucketComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *s3BucketComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta.SummonPlatform)
 	if comp.miv && instance.Spec.MIV.ExistingBucket != "" {
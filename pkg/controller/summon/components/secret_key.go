@@ -50,6 +50,11 @@ func (_ *secretKeyComponent) IsReconcilable(ctx *components.ComponentContext) bo
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *secretKeyComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *secretKeyComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
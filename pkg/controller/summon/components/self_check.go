@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+const (
+	defaultSelfCheckTimeout = 5 * time.Second
+	// How often to re-run failing checks.
+	selfCheckRetryInterval = 15 * time.Second
+	// How long the checks can fail before an error notification goes out, so slow starts don't page anyone.
+	selfCheckNotifyDelay = 5 * time.Minute
+)
+
+func selfCheckServiceNames() []string {
+	return []string{"web", "daphne", "dispatch", "businessPortal"}
+}
+
+// In-cluster URL for one of an instance's Services, matching the names in the service templates.
+func serviceURL(instance *summonv1beta1.SummonPlatform, service string) string {
+	return fmt.Sprintf("http://%s-%s.%s.svc:8000", instance.Name, strings.ToLower(service), instance.Namespace)
+}
+
+// The checks to run when none are configured.
+func defaultSelfChecks(instance *summonv1beta1.SummonPlatform) []summonv1beta1.HTTPCheckSpec {
+	checks := []summonv1beta1.HTTPCheckSpec{
+		{Service: "web", Path: "/healthz"},
+		{Service: "daphne", Path: "/healthz"},
+	}
+	if instance.Spec.Dispatch.Version != "" {
+		checks = append(checks, summonv1beta1.HTTPCheckSpec{Service: "dispatch", Path: "/"})
+	}
+	if instance.Spec.BusinessPortal.Version != "" {
+		checks = append(checks, summonv1beta1.HTTPCheckSpec{Service: "businessPortal", Path: "/"})
+	}
+	return checks
+}
+
+// Run every configured check. Returns the results and whether all of them passed.
+func (comp *statusComponent) runSelfChecks(ctx *components.ComponentContext) ([]summonv1beta1.HTTPCheckResult, bool) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	results := []summonv1beta1.HTTPCheckResult{}
+	passed := true
+	for _, check := range instance.Spec.SelfCheck.Checks {
+		result := comp.runSelfCheck(ctx.Context, instance, check)
+		passed = passed && result.Passed
+		results = append(results, result)
+	}
+	return results, passed
+}
+
+func (comp *statusComponent) runSelfCheck(ctx context.Context, instance *summonv1beta1.SummonPlatform, check summonv1beta1.HTTPCheckSpec) summonv1beta1.HTTPCheckResult {
+	path := check.Path
+	if path == "" {
+		path = "/"
+	}
+	timeout := check.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultSelfCheckTimeout
+	}
+	url := comp.serviceURL(instance, check.Service) + path
+	result := summonv1beta1.HTTPCheckResult{Service: check.Service, URL: url}
+
+	statusCode, elapsed, err := comp.httpGet(ctx, url, timeout)
+	result.StatusCode = statusCode
+	result.ResponseTime = metav1.Duration{Duration: elapsed.Round(time.Millisecond)}
+	switch {
+	case err != nil && elapsed >= timeout:
+		result.Message = fmt.Sprintf("%s %s took longer than %s", check.Service, path, timeout)
+	case err != nil:
+		result.Message = fmt.Sprintf("%s %s failed: %s", check.Service, path, err)
+	case !expectedStatus(check, statusCode):
+		result.Message = fmt.Sprintf("%s %s returned %d", check.Service, path, statusCode)
+	default:
+		result.Passed = true
+	}
+	return result
+}
+
+func (comp *statusComponent) httpGet(ctx context.Context, url string, timeout time.Duration) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "unable to build request")
+	}
+	req.Header.Set("User-Agent", "ridecell-operator self check")
+	start := time.Now()
+	resp, err := comp.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, time.Since(start), err
+	}
+	defer resp.Body.Close()
+	// Read the whole body so a handler which stalls part way through counts against the timeout too.
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, time.Since(start), err
+}
+
+func expectedStatus(check summonv1beta1.HTTPCheckSpec, statusCode int) bool {
+	if len(check.ExpectedStatus) == 0 {
+		return statusCode >= 200 && statusCode < 400
+	}
+	for _, expected := range check.ExpectedStatus {
+		if statusCode == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// How long the self checks have been failing, 0 if they aren't.
+func selfCheckFailingFor(instance *summonv1beta1.SummonPlatform, now time.Time) time.Duration {
+	if instance.Status.SelfCheck.FailingSince == "" {
+		return 0
+	}
+	failingSince, err := time.Parse(time.UnixDate, instance.Status.SelfCheck.FailingSince)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(failingSince)
+}
+
+// Summary of the failed checks, for the status message and notifications.
+func selfCheckFailures(results []summonv1beta1.HTTPCheckResult) string {
+	failures := []string{}
+	for _, result := range results {
+		if !result.Passed {
+			failures = append(failures, result.Message)
+		}
+	}
+	return strings.Join(failures, ", ")
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+)
+
+var _ = Describe("SummonPlatform self checks", func() {
+	var server *httptest.Server
+	var handler http.HandlerFunc
+
+	BeforeEach(func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+
+		// Everything available, so only the self checks decide.
+		deployment := func(name string) *appsv1.Deployment {
+			return &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-" + name, Namespace: "summon-dev"},
+				Spec:       appsv1.DeploymentSpec{Replicas: intp(1)},
+				Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+			}
+		}
+		celerybeat := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-celerybeat", Namespace: "summon-dev"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: intp(1)},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		ctx.Client = fake.NewFakeClient(instance, deployment("web"), deployment("daphne"), deployment("celeryd"),
+			deployment("channelworker"), deployment("static"), deployment("redis"), celerybeat)
+
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		instance.Spec.SelfCheck.Checks = []summonv1beta1.HTTPCheckSpec{{Service: "web", Path: "/healthz"}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	reconcile := func() components.Result {
+		status := summoncomponents.NewStatus()
+		status.InjectServiceURL(func(_ *summonv1beta1.SummonPlatform, _ string) string {
+			return server.URL
+		})
+		res, err := status.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		if res.StatusModifier != nil {
+			Expect(res.StatusModifier(instance)).To(Succeed())
+		}
+		return res
+	}
+
+	It("marks the instance ready when the checks pass", func() {
+		var path string
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(http.StatusOK)
+		}
+		reconcile()
+		Expect(path).To(Equal("/healthz"))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+		Expect(instance.Status.SelfCheck.PassedVersion).To(Equal("1.2.3"))
+		Expect(instance.Status.SelfCheck.Results).To(HaveLen(1))
+		Expect(instance.Status.SelfCheck.Results[0].Passed).To(BeTrue())
+		Expect(instance.Status.SelfCheck.Results[0].StatusCode).To(Equal(200))
+	})
+
+	It("stays in deploying when a check returns an error", func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		res := reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Message).To(Equal("Waiting for self checks to pass: web /healthz returned 502"))
+		Expect(instance.Status.SelfCheck.FailingSince).ToNot(BeEmpty())
+		Expect(instance.Status.SelfCheck.PassedVersion).To(BeEmpty())
+		Expect(res.RequeueAfter).To(Equal(15 * time.Second))
+	})
+
+	It("accepts configured status codes", func() {
+		instance.Spec.SelfCheck.Checks[0].ExpectedStatus = []int{401}
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+	})
+
+	It("fails a slow check", func() {
+		instance.Spec.SelfCheck.Checks[0].Timeout = metav1.Duration{Duration: 50 * time.Millisecond}
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}
+		reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Message).To(ContainSubstring("took longer than 50ms"))
+	})
+
+	It("only checks each version once", func() {
+		instance.Status.SelfCheck.PassedVersion = "1.2.3"
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+	})
+
+	It("skips the checks when disabled", func() {
+		instance.Spec.SelfCheck.Disabled = true
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		reconcile()
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+		Expect(instance.Status.SelfCheck.PassedVersion).To(BeEmpty())
+	})
+})
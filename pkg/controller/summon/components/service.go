@@ -42,6 +42,11 @@ func (_ *serviceComponent) IsReconcilable(_ *components.ComponentContext) bool {
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *serviceComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *serviceComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	res, _, err := ctx.CreateOrUpdate(comp.templatePath, nil, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*corev1.Service)
@@ -23,6 +23,8 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 
 	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
@@ -70,4 +72,27 @@ var _ = Describe("SummonPlatform service Component", func() {
 		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-daphne", Namespace: "summon-dev"}, target)
 		Expect(err).ToNot(HaveOccurred())
 	})
+	It("prunes the dispatch service when dispatch is disabled", func() {
+		ctx.OwnedTypes = []runtime.Object{&corev1.Service{}}
+		comp := summoncomponents.NewService("dispatch/service.yml.tpl")
+		instance.Spec.Dispatch.Version = "1.0"
+		Expect(comp).To(ReconcileContext(ctx))
+		target := &corev1.Service{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-dispatch", Namespace: "summon-dev"}, target)
+		Expect(err).ToNot(HaveOccurred())
+
+		instance.Spec.Dispatch.Version = ""
+		Expect(comp).To(ReconcileContext(ctx))
+		err = ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-dispatch", Namespace: "summon-dev"}, target)
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("leaves other services alone when pruning", func() {
+		ctx.OwnedTypes = []runtime.Object{&corev1.Service{}}
+		Expect(summoncomponents.NewService("web/service.yml.tpl")).To(ReconcileContext(ctx))
+		Expect(summoncomponents.NewService("dispatch/service.yml.tpl")).To(ReconcileContext(ctx))
+		target := &corev1.Service{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, target)
+		Expect(err).ToNot(HaveOccurred())
+	})
 })
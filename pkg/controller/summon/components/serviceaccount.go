@@ -41,6 +41,11 @@ func (_ *serviceAccountComponent) IsReconcilable(ctx *components.ComponentContex
 	return instance.Spec.GCPProject != ""
 }
 
+// Dependencies implements components.Dependent.
+func (_ *serviceAccountComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *serviceAccountComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	res, _, err := ctx.CreateOrUpdate("gcp/serviceaccount.yml.tpl", nil, func(goalObj, existingObj runtime.Object) error {
 		goal := goalObj.(*gcpv1beta1.GCPServiceAccount)
@@ -17,15 +17,12 @@ limitations under the License.
 package components
 
 import (
-	"fmt"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 type serviceMonitorComponent struct {
@@ -44,23 +41,20 @@ func (comp *serviceMonitorComponent) IsReconcilable(ctx *components.ComponentCon
 	return true
 }
 
+// Dependencies implements components.Dependent.
+func (_ *serviceMonitorComponent) Dependencies() []components.Component {
+	return []components.Component{}
+}
+
 func (comp *serviceMonitorComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
 
-	// If our flag is not set or is false attempt to delete the object and move on
+	// If our flag is not set or is false the template renders nothing, so clean up anything left from before.
+	// ServiceMonitors aren't watched, so the type has to be given explicitly.
 	if instance.Spec.Metrics.Web == nil || !*instance.Spec.Metrics.Web {
-		existing := &promv1.ServiceMonitor{}
-		err := ctx.Get(ctx.Context, types.NamespacedName{Name: fmt.Sprintf("%s-metrics", instance.Name), Namespace: instance.Namespace}, existing)
-		if err != nil {
-			if kerrors.IsNotFound(err) {
-				return components.Result{}, nil
-			}
-			return components.Result{}, errors.Wrap(err, "servicemonitor: unable to get object")
-		}
-
-		err = ctx.Client.Delete(ctx.Context, existing)
+		_, err := ctx.PruneNamed(comp.templatePath, &promv1.ServiceMonitor{}, instance.Name+"-metrics")
 		if err != nil {
-			return components.Result{}, errors.Wrap(err, "servicemonitor: unabled to delete object")
+			return components.Result{}, errors.Wrap(err, "servicemonitor: unable to prune")
 		}
 		return components.Result{}, nil
 	}
@@ -18,21 +18,45 @@ package components
 
 import (
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 	appsv1 "k8s.io/api/apps/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
 	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
 )
 
-type statusComponent struct{}
+type statusComponent struct {
+	httpClient *http.Client
+	serviceURL func(*summonv1beta1.SummonPlatform, string) string
+
+	podLogClient utils.PodLogClient
+}
 
 func NewStatus() *statusComponent {
-	return &statusComponent{}
+	return &statusComponent{
+		httpClient: &http.Client{
+			// Only the Service's own response counts, redirects usually point at the public hostname.
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		serviceURL:   serviceURL,
+		podLogClient: utils.NewPodLogClient(),
+	}
+}
+
+// InjectServiceURL replaces how the self checks find each Service, so tests can point them at a local server.
+func (comp *statusComponent) InjectServiceURL(fn func(*summonv1beta1.SummonPlatform, string) string) {
+	comp.serviceURL = fn
+}
+
+func (comp *statusComponent) InjectPodLogClient(client utils.PodLogClient) {
+	comp.podLogClient = client
 }
 
 func (comp *statusComponent) WatchTypes() []runtime.Object {
@@ -49,74 +73,186 @@ func (_ *statusComponent) IsReconcilable(_ *components.ComponentContext) bool {
 
 func (comp *statusComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
 	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
-	if instance.Status.Status != summonv1beta1.StatusDeploying {
+	if instance.Status.Status != summonv1beta1.StatusDeploying && instance.Status.Status != summonv1beta1.StatusReady {
 		// If the migrations component didn't already set us to Deploying, don't even bother checking.
 		return components.Result{}, nil
 	}
 
-	// Grab all (important) Deployments and make sure they are all ready.
-	web := &appsv1.Deployment{}
-	daphne := &appsv1.Deployment{}
-	celeryd := &appsv1.Deployment{}
-	channelworker := &appsv1.Deployment{}
-	static := &appsv1.Deployment{}
-	celerybeat := &appsv1.StatefulSet{}
-
-	// Go's lack of generics can fuck right off.
-	err := comp.get(ctx, "web", web)
+	workloads, err := comp.workloads(ctx)
 	if err != nil {
 		return components.Result{}, err
 	}
-	err = comp.get(ctx, "daphne", daphne)
-	if err != nil {
-		return components.Result{}, err
+	statuses := []summonv1beta1.WorkloadStatus{}
+	for _, workload := range workloads {
+		statuses = append(statuses, workload.WorkloadStatus)
 	}
-	err = comp.get(ctx, "celeryd", celeryd)
+
+	res := components.Result{}
+	// Once Ready, only keep the breakdown up to date. The stable Deployments being available also says nothing
+	// while a canary is running or was rolled back.
+	if instance.Status.Status == summonv1beta1.StatusDeploying && !rolloutHolding(instance) {
+		res, err = comp.checkReady(ctx, workloads)
+		if err != nil {
+			return res, err
+		}
+	}
+	addStatusModifier(&res, func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Workloads = statuses
+		return nil
+	})
+	return res, nil
+}
+
+// Move to Ready once every workload is available and the self checks pass.
+func (comp *statusComponent) checkReady(ctx *components.ComponentContext, workloads []workload) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	for _, workload := range workloads {
+		if !workload.Ready {
+			// Not ready, alas.
+			return comp.notReady(ctx, workloads)
+		}
+	}
+
+	// Pods being available doesn't mean they serve anything, so check over HTTP once per version.
+	var selfCheckResults []summonv1beta1.HTTPCheckResult
+	selfCheck := instance.Spec.SelfCheck
+	if !selfCheck.Disabled && len(selfCheck.Checks) > 0 && instance.Status.SelfCheck.PassedVersion != instance.Spec.Version {
+		results, passed := comp.runSelfChecks(ctx)
+		if !passed {
+			return comp.selfCheckFailed(ctx, results)
+		}
+		selfCheckResults = results
+	}
+
+	version := instance.Spec.Version
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Status = summonv1beta1.StatusReady
+		if selfCheckResults != nil {
+			instance.Status.SelfCheck.PassedVersion = version
+			instance.Status.SelfCheck.FailingSince = ""
+			instance.Status.SelfCheck.Results = selfCheckResults
+		}
+		instance.Status.Message = fmt.Sprintf("Cluster %s ready", instance.Name)
+		// Remember what to fall back on if the next canary or rollout fails.
+		instance.Status.Rollout.StableVersion = version
+		instance.Status.NextDeployWindow = ""
+		instance.Status.RolloutProblems = nil
+		autoRollback := &instance.Status.AutoRollback
+		autoRollback.RolloutVersion = ""
+		autoRollback.RolloutStartTime = ""
+		autoRollback.FailedVersion = ""
+		autoRollback.RevertedTo = ""
+		autoRollback.SnapshotID = ""
+		return nil
+	}}, nil
+}
+
+// Stay in Deploying, pointing out anything which looks like it will never become available.
+func (comp *statusComponent) notReady(ctx *components.ComponentContext, workloads []workload) (components.Result, error) {
+	problems, err := comp.diagnoseRollout(ctx, workloads)
 	if err != nil {
 		return components.Result{}, err
 	}
-	err = comp.get(ctx, "channelworker", channelworker)
+	res, err := comp.checkRolloutDeadline(ctx)
 	if err != nil {
-		return components.Result{}, err
+		return res, err
 	}
-	err = comp.get(ctx, "static", static)
+	deadlineModifier := res.StatusModifier
+	res.StatusModifier = nil
+	addStatusModifier(&res, func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.RolloutProblems = problems
+		if len(problems) > 0 {
+			instance.Status.Message = fmt.Sprintf("Rollout stuck: %s", rolloutProblemSummary(problems))
+		}
+		return nil
+	})
+	// A rollback replaces the message.
+	addStatusModifier(&res, deadlineModifier)
+	return res, nil
+}
+
+// Stay in Deploying while the self checks fail. They count against the rollout deadline the same as pods which
+// never become available.
+func (comp *statusComponent) selfCheckFailed(ctx *components.ComponentContext, results []summonv1beta1.HTTPCheckResult) (components.Result, error) {
+	res, err := comp.checkRolloutDeadline(ctx)
 	if err != nil {
-		return components.Result{}, err
+		return res, err
 	}
-	err = comp.get(ctx, "celerybeat", celerybeat)
+	message := fmt.Sprintf("Waiting for self checks to pass: %s", selfCheckFailures(results))
+	failingSince := time.Now().Format(time.UnixDate)
+	deadlineModifier := res.StatusModifier
+	res.StatusModifier = nil
+	addStatusModifier(&res, func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonPlatform)
+		instance.Status.Message = message
+		instance.Status.SelfCheck.Results = results
+		if instance.Status.SelfCheck.FailingSince == "" {
+			instance.Status.SelfCheck.FailingSince = failingSince
+		}
+		return nil
+	})
+	// A rollback replaces the message.
+	addStatusModifier(&res, deadlineModifier)
+	if res.RequeueAfter == 0 || res.RequeueAfter > selfCheckRetryInterval {
+		res.RequeueAfter = selfCheckRetryInterval
+	}
+	return res, nil
+}
+
+// Roll back a new version whose Deployments haven't all become available within the rollout deadline.
+func (comp *statusComponent) checkRolloutDeadline(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
+	if !instance.Spec.AutoRollback.Enabled || rollbackTarget(instance) == "" {
+		return components.Result{}, nil
+	}
+	deadline := instance.Spec.AutoRollback.RolloutDeadline.Duration
+
+	if instance.Status.AutoRollback.RolloutVersion != instance.Spec.Version {
+		// First time seeing this version roll out, start the clock.
+		version := instance.Spec.Version
+		startTime := time.Now()
+		return components.Result{
+			StatusModifier: func(obj runtime.Object) error {
+				instance := obj.(*summonv1beta1.SummonPlatform)
+				instance.Status.AutoRollback.RolloutVersion = version
+				instance.Status.AutoRollback.RolloutStartTime = startTime.Format(time.UnixDate)
+				return nil
+			},
+			RequeueAfter: deadline,
+		}, nil
+	}
+
+	startTime, err := time.Parse(time.UnixDate, instance.Status.AutoRollback.RolloutStartTime)
 	if err != nil {
+		return components.Result{}, errors.Wrap(err, "status: failed to parse rollout start time")
+	}
+	remaining := startTime.Add(deadline).Sub(time.Now())
+	if remaining > 0 {
+		return components.Result{RequeueAfter: remaining}, nil
+	}
+	rollback, err := autoRollback(ctx, fmt.Sprintf("did not become available within %s", deadline))
+	if err != nil || rollback == nil {
 		return components.Result{}, err
 	}
-
-	// The big check!
-	if web.Spec.Replicas != nil && web.Status.AvailableReplicas == *web.Spec.Replicas &&
-		daphne.Spec.Replicas != nil && daphne.Status.AvailableReplicas == *daphne.Spec.Replicas &&
-		celeryd.Spec.Replicas != nil && celeryd.Status.AvailableReplicas == *celeryd.Spec.Replicas &&
-		channelworker.Spec.Replicas != nil && channelworker.Status.AvailableReplicas == *channelworker.Spec.Replicas &&
-		static.Spec.Replicas != nil && static.Status.AvailableReplicas == *static.Spec.Replicas &&
-		// Note this one is different, available vs ready.
-		celerybeat.Spec.Replicas != nil && celerybeat.Status.ReadyReplicas == *celerybeat.Spec.Replicas {
-		// TODO: Add an actual HTTP self check in here.
-		return components.Result{StatusModifier: func(obj runtime.Object) error {
-			instance := obj.(*summonv1beta1.SummonPlatform)
-			instance.Status.Status = summonv1beta1.StatusReady
-			instance.Status.Message = fmt.Sprintf("Cluster %s ready", instance.Name)
-			return nil
-		}}, nil
-	}
-
-	// Not ready, alas.
-	return components.Result{}, nil
+	return *rollback, nil
 }
 
-// Short helper because we need to do this 6 times.
-func (comp *statusComponent) get(ctx *components.ComponentContext, part string, obj runtime.Object) error {
-	instance := ctx.Top.(*summonv1beta1.SummonPlatform)
-	name := types.NamespacedName{Name: fmt.Sprintf("%s-%s", instance.Name, part), Namespace: instance.Namespace}
-	err := ctx.Get(ctx.Context, name, obj)
-	// If it's a NotFound error, just ignore it since we don't want to that to fail things and the zero value will fail later on.
-	if err != nil && !kerrors.IsNotFound(err) {
-		return errors.Wrapf(err, "status: unable to get Deployment or StatefulSet %s for %s subsystem", name, part)
+// Chain another status modifier after whatever the result already has.
+func addStatusModifier(res *components.Result, modifier components.StatusModifier) {
+	if modifier == nil {
+		return
+	}
+	previous := res.StatusModifier
+	res.StatusModifier = func(obj runtime.Object) error {
+		if previous != nil {
+			err := previous(obj)
+			if err != nil {
+				return err
+			}
+		}
+		return modifier(obj)
 	}
-	return nil
 }
@@ -36,9 +36,10 @@ var _ = Describe("SummonPlatform Status Component", func() {
 	var channelworkersDeployment *appsv1.Deployment
 	var staticDeployment *appsv1.Deployment
 	var celerybeatStatefulSet *appsv1.StatefulSet
+	var redisDeployment *appsv1.Deployment
 	makeClient := func() client.Client {
 		return fake.NewFakeClient(instance, webDeployment, daphneDeployment, celerydDeployment,
-			channelworkersDeployment, staticDeployment, celerybeatStatefulSet)
+			channelworkersDeployment, staticDeployment, celerybeatStatefulSet, redisDeployment)
 	}
 
 	BeforeEach(func() {
@@ -78,6 +79,15 @@ var _ = Describe("SummonPlatform Status Component", func() {
 				Replicas: intp(2),
 			},
 		}
+		redisDeployment = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-redis", Namespace: "summon-dev"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: intp(1),
+			},
+			Status: appsv1.DeploymentStatus{
+				AvailableReplicas: 1,
+			},
+		}
 
 		ctx.Client = makeClient()
 	})
@@ -105,6 +115,26 @@ var _ = Describe("SummonPlatform Status Component", func() {
 		comp := summoncomponents.NewStatus()
 		Expect(comp).To(ReconcileContext(ctx))
 		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+		Expect(instance.Status.Rollout.StableVersion).To(Equal("1.2.3"))
+	})
+
+	It("doesn't update while a canary is running", func() {
+		webDeployment.Status.AvailableReplicas = 2
+		daphneDeployment.Status.AvailableReplicas = 2
+		celerydDeployment.Status.AvailableReplicas = 2
+		channelworkersDeployment.Status.AvailableReplicas = 2
+		staticDeployment.Status.AvailableReplicas = 2
+		celerybeatStatefulSet.Status.ReadyReplicas = 2
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		instance.Spec.Rollout.Strategy = summonv1beta1.RolloutStrategyCanary
+		instance.Status.Rollout.StableVersion = "1.2.2"
+		instance.Status.Rollout.Phase = summonv1beta1.RolloutPhaseCanary
+		instance.Status.Rollout.CanaryVersion = "1.2.3"
+		ctx.Client = makeClient()
+
+		comp := summoncomponents.NewStatus()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
 	})
 
 	It("doesn't update if still migrating", func() {
@@ -141,4 +171,81 @@ var _ = Describe("SummonPlatform Status Component", func() {
 		Expect(comp).To(ReconcileContext(ctx))
 		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
 	})
+
+	It("waits for dispatch when it is enabled", func() {
+		webDeployment.Status.AvailableReplicas = 2
+		daphneDeployment.Status.AvailableReplicas = 2
+		celerydDeployment.Status.AvailableReplicas = 2
+		channelworkersDeployment.Status.AvailableReplicas = 2
+		staticDeployment.Status.AvailableReplicas = 2
+		celerybeatStatefulSet.Status.ReadyReplicas = 2
+		instance.Spec.Dispatch.Version = "1.0.0"
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		ctx.Client = makeClient()
+
+		comp := summoncomponents.NewStatus()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+		Expect(instance.Status.Workloads).To(HaveLen(8))
+		dispatch := instance.Status.Workloads[6]
+		Expect(dispatch.Name).To(Equal("dispatch"))
+		Expect(dispatch.Ready).To(BeFalse())
+		Expect(dispatch.Rollout).To(Equal(summonv1beta1.WorkloadRolloutMissing))
+	})
+
+	It("waits for redis", func() {
+		webDeployment.Status.AvailableReplicas = 2
+		daphneDeployment.Status.AvailableReplicas = 2
+		celerydDeployment.Status.AvailableReplicas = 2
+		channelworkersDeployment.Status.AvailableReplicas = 2
+		staticDeployment.Status.AvailableReplicas = 2
+		celerybeatStatefulSet.Status.ReadyReplicas = 2
+		redisDeployment.Status.AvailableReplicas = 0
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		ctx.Client = makeClient()
+
+		comp := summoncomponents.NewStatus()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusDeploying))
+	})
+
+	It("reports a breakdown for each workload", func() {
+		webDeployment.Status.AvailableReplicas = 1
+		webDeployment.Status.UpdatedReplicas = 1
+		webDeployment.Status.Conditions = []appsv1.DeploymentCondition{{
+			Type:    appsv1.DeploymentProgressing,
+			Reason:  "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "foo-dev-web-123" has timed out progressing.`,
+		}}
+		daphneDeployment.Status.AvailableReplicas = 2
+		daphneDeployment.Status.UpdatedReplicas = 2
+		instance.Status.Status = summonv1beta1.StatusDeploying
+		ctx.Client = makeClient()
+
+		comp := summoncomponents.NewStatus()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Workloads).To(HaveLen(7))
+		Expect(instance.Status.Workloads[0]).To(Equal(summonv1beta1.WorkloadStatus{
+			Name:              "web",
+			Kind:              "Deployment",
+			DesiredReplicas:   2,
+			AvailableReplicas: 1,
+			UpdatedReplicas:   1,
+			Rollout:           summonv1beta1.WorkloadRolloutStuck,
+			Message:           `ReplicaSet "foo-dev-web-123" has timed out progressing.`,
+		}))
+		Expect(instance.Status.Workloads[1].Ready).To(BeTrue())
+		Expect(instance.Status.Workloads[1].Rollout).To(Equal(summonv1beta1.WorkloadRolloutComplete))
+		Expect(instance.Status.Workloads[4].Kind).To(Equal("StatefulSet"))
+	})
+
+	It("keeps the breakdown up to date once ready", func() {
+		instance.Status.Status = summonv1beta1.StatusReady
+
+		comp := summoncomponents.NewStatus()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.StatusReady))
+		Expect(instance.Status.Workloads).To(HaveLen(7))
+		Expect(instance.Status.Workloads[0].Ready).To(BeFalse())
+	})
 })
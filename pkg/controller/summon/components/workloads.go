@@ -0,0 +1,165 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/templates"
+)
+
+// Templates for the workloads which have to be available before an instance is Ready. Optional ones like
+// dispatch render nothing while they're turned off and get skipped.
+var workloadTemplates = []string{
+	"web/deployment.yml.tpl",
+	"daphne/deployment.yml.tpl",
+	"static/deployment.yml.tpl",
+	"celeryd/deployment.yml.tpl",
+	"celerybeat/statefulset.yml.tpl",
+	"channelworker/deployment.yml.tpl",
+	"dispatch/deployment.yml.tpl",
+	"businessPortal/deployment.yml.tpl",
+	"redis/deployment.yml.tpl",
+}
+
+// A workload's status along with the labels on its pods, for digging into why it isn't ready.
+type workload struct {
+	summonv1beta1.WorkloadStatus
+	podLabels map[string]string
+}
+
+// Look up the readiness of every workload the instance renders.
+func (comp *statusComponent) workloads(ctx *components.ComponentContext) ([]workload, error) {
+	workloads := []workload{}
+	for _, templatePath := range workloadTemplates {
+		target, err := ctx.GetTemplate(templatePath, nil)
+		if err == templates.ErrEmptyTemplate {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "status: error rendering template %s", templatePath)
+		}
+		workload, err := comp.workloadStatus(ctx, path.Dir(templatePath), target)
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, workload)
+	}
+	return workloads, nil
+}
+
+func (comp *statusComponent) workloadStatus(ctx *components.ComponentContext, name string, target runtime.Object) (workload, error) {
+	meta := target.(metav1.Object)
+	key := types.NamespacedName{Name: meta.GetName(), Namespace: meta.GetNamespace()}
+	switch target := target.(type) {
+	case *appsv1.Deployment:
+		podLabels := rolloutPodLabels(target.Spec.Selector, target.Spec.Template.Labels)
+		existing := &appsv1.Deployment{}
+		err := ctx.Get(ctx.Context, key, existing)
+		if kerrors.IsNotFound(err) {
+			return workload{missingWorkload(name, "Deployment"), podLabels}, nil
+		} else if err != nil {
+			return workload{}, errors.Wrapf(err, "status: unable to get Deployment %s for %s subsystem", key, name)
+		}
+		return workload{deploymentWorkloadStatus(name, existing), podLabels}, nil
+	case *appsv1.StatefulSet:
+		podLabels := rolloutPodLabels(target.Spec.Selector, target.Spec.Template.Labels)
+		existing := &appsv1.StatefulSet{}
+		err := ctx.Get(ctx.Context, key, existing)
+		if kerrors.IsNotFound(err) {
+			return workload{missingWorkload(name, "StatefulSet"), podLabels}, nil
+		} else if err != nil {
+			return workload{}, errors.Wrapf(err, "status: unable to get StatefulSet %s for %s subsystem", key, name)
+		}
+		return workload{statefulSetWorkloadStatus(name, existing), podLabels}, nil
+	default:
+		return workload{}, errors.Errorf("status: unknown workload type %T for %s subsystem", target, name)
+	}
+}
+
+// Labels matching the pods for the version being rolled out, so older pods which are still up get left out.
+func rolloutPodLabels(selector *metav1.LabelSelector, templateLabels map[string]string) map[string]string {
+	podLabels := map[string]string{}
+	if selector != nil {
+		for key, value := range selector.MatchLabels {
+			podLabels[key] = value
+		}
+	}
+	if version, ok := templateLabels["app.kubernetes.io/version"]; ok {
+		podLabels["app.kubernetes.io/version"] = version
+	}
+	return podLabels
+}
+
+func missingWorkload(name string, kind string) summonv1beta1.WorkloadStatus {
+	return summonv1beta1.WorkloadStatus{Name: name, Kind: kind, Rollout: summonv1beta1.WorkloadRolloutMissing}
+}
+
+func deploymentWorkloadStatus(name string, deployment *appsv1.Deployment) summonv1beta1.WorkloadStatus {
+	workload := summonv1beta1.WorkloadStatus{
+		Name:              name,
+		Kind:              "Deployment",
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		Rollout:           summonv1beta1.WorkloadRolloutComplete,
+	}
+	if deployment.Spec.Replicas != nil {
+		workload.DesiredReplicas = *deployment.Spec.Replicas
+		workload.Ready = deployment.Status.AvailableReplicas == workload.DesiredReplicas
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		// Set by the Deployment controller once progressDeadlineSeconds passes without any progress.
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			workload.Rollout = summonv1beta1.WorkloadRolloutStuck
+			workload.Message = condition.Message
+			return workload
+		}
+	}
+	if deployment.Status.ObservedGeneration < deployment.Generation || workload.UpdatedReplicas < workload.DesiredReplicas || !workload.Ready {
+		workload.Rollout = summonv1beta1.WorkloadRolloutProgressing
+	}
+	return workload
+}
+
+func statefulSetWorkloadStatus(name string, statefulSet *appsv1.StatefulSet) summonv1beta1.WorkloadStatus {
+	workload := summonv1beta1.WorkloadStatus{
+		Name: name,
+		Kind: "StatefulSet",
+		// Note this one is different, available vs ready.
+		AvailableReplicas: statefulSet.Status.ReadyReplicas,
+		UpdatedReplicas:   statefulSet.Status.UpdatedReplicas,
+		Rollout:           summonv1beta1.WorkloadRolloutComplete,
+	}
+	if statefulSet.Spec.Replicas != nil {
+		workload.DesiredReplicas = *statefulSet.Spec.Replicas
+		workload.Ready = statefulSet.Status.ReadyReplicas == workload.DesiredReplicas
+	}
+	// StatefulSets have no progress deadline, so they can only ever be progressing.
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision || !workload.Ready {
+		workload.Rollout = summonv1beta1.WorkloadRolloutProgressing
+	}
+	return workload
+}
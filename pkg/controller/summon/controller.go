@@ -32,16 +32,42 @@ import (
 	gcr "github.com/Ridecell/ridecell-operator/pkg/utils/gcr"
 )
 
+// How many SummonPlatform components a single reconcile runs at the same time.
+const componentConcurrency = 4
+
 // Add creates a new Summon Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	c, err := components.NewReconciler("summon-platform-controller", mgr, &summonv1beta1.SummonPlatform{}, Templates, []components.Component{
+	c, err := components.NewReconciler("summon-platform-controller", mgr, &summonv1beta1.SummonPlatform{}, Templates, Components())
+	if err != nil {
+		return err
+	}
+	// Every component's Dependencies() has been checked against what it reads, so independent ones can run together.
+	c.ComponentConcurrency = componentConcurrency
+
+	gcrChannel := make(chan event.GenericEvent)
+
+	go watchForImages(gcrChannel, c.GetComponentClient())
+
+	err = c.Controller.Watch(
+		&source.Channel{Source: gcrChannel},
+		&handler.EnqueueRequestForObject{},
+	)
+	return err
+}
+
+// Components returns a fresh copy of the SummonPlatform component list, shared by the controller and dry runs.
+func Components() []components.Component {
+	return []components.Component{
 		// Set default values.
 		summoncomponents.NewDefaults(),
 
 		// Possibly have Spec.Version value replaced by autodeploy logic.
 		summoncomponents.NewAutoDeploy(),
 
+		// Copy data from another instance before anything is created for this one.
+		summoncomponents.NewClone(),
+
 		// Top-level components.
 		summoncomponents.NewPullSecret("pullsecret/pullsecret.yml.tpl"),
 		summoncomponents.NewPostgres(),
@@ -69,6 +95,8 @@ func Add(mgr manager.Manager) error {
 		summoncomponents.NewMigrations("migrations.yml.tpl"),
 		summoncomponents.NewMigrateWait(),
 		summoncomponents.NewSuperuser(),
+		summoncomponents.NewHibernation(),
+		summoncomponents.NewRollout("web/deployment.yml.tpl"),
 
 		// Redis components.
 		summoncomponents.NewPVC("redis/volumeclaim.yml.tpl"),
@@ -77,6 +105,7 @@ func Add(mgr manager.Manager) error {
 
 		// Web components.
 		summoncomponents.NewDeployment("web/deployment.yml.tpl"),
+		summoncomponents.NewHPA("web/hpa.yml.tpl"),
 		summoncomponents.NewPodDisruptionBudget("web/podDisruptionBudget.yml.tpl"),
 		summoncomponents.NewService("web/service.yml.tpl"),
 		summoncomponents.NewIngress("web/ingress.yml.tpl"),
@@ -95,6 +124,8 @@ func Add(mgr manager.Manager) error {
 
 		// Celery components.
 		summoncomponents.NewDeployment("celeryd/deployment.yml.tpl"),
+		summoncomponents.NewHPA("celeryd/hpa.yml.tpl"),
+		summoncomponents.NewCeleryAutoscaler(),
 		summoncomponents.NewPodDisruptionBudget("celeryd/podDisruptionBudget.yml.tpl"),
 
 		// Celerybeat components.
@@ -104,6 +135,7 @@ func Add(mgr manager.Manager) error {
 
 		// Channelworker components.
 		summoncomponents.NewDeployment("channelworker/deployment.yml.tpl"),
+		summoncomponents.NewHPA("channelworker/hpa.yml.tpl"),
 		summoncomponents.NewPodDisruptionBudget("channelworker/podDisruptionBudget.yml.tpl"),
 
 		// Dispatch components.
@@ -126,25 +158,12 @@ func Add(mgr manager.Manager) error {
 
 		// End of converge status checks.
 		summoncomponents.NewStatus(),
+		summoncomponents.NewDeployHistory(),
 
 		// Notification componenets.
 		// Keep Notification at the end of this block
 		summoncomponents.NewNotification(),
-	})
-
-	if err != nil {
-		return err
 	}
-
-	gcrChannel := make(chan event.GenericEvent)
-
-	go watchForImages(gcrChannel, c.GetComponentClient())
-
-	err = c.Controller.Watch(
-		&source.Channel{Source: gcrChannel},
-		&handler.EnqueueRequestForObject{},
-	)
-	return err
 }
 
 // Watches docker image cache for updates and triggers reconciles for summon instances with autodeploy enabled.
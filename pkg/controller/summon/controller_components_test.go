@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summon_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/controller/summon"
+)
+
+var _ = Describe("Summon components", func() {
+	var wave map[string]int
+
+	// Look a component up by name, failing rather than quietly comparing against wave 0 for a typo.
+	waveOf := func(name string) int {
+		Expect(wave).To(HaveKey(name))
+		return wave[name]
+	}
+
+	BeforeEach(func() {
+		waves, err := components.Waves(summon.Components())
+		Expect(err).ToNot(HaveOccurred())
+		wave = map[string]int{}
+		for i, comps := range waves {
+			for _, comp := range comps {
+				wave[components.ComponentName(comp)] = i
+			}
+		}
+	})
+
+	It("creates the backing resources together", func() {
+		Expect(waveOf("pullSecret:pullsecret/pullsecret.yml.tpl")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("iamUser:aws/iamuser.yml.tpl")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("s3Bucket:aws/staticbucket.yml.tpl")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("rabbitmqVhost:rabbitmq/vhost.yml.tpl")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("secretKey")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("configmap:configmap.yml.tpl")).To(Equal(waveOf("postgres")))
+		Expect(waveOf("postgres")).To(BeNumerically(">", waveOf("clone")))
+	})
+
+	It("creates the app secrets after the things they are built from", func() {
+		Expect(waveOf("appSecret")).To(BeNumerically(">", waveOf("postgres")))
+		Expect(waveOf("appSecret")).To(BeNumerically(">", waveOf("rabbitmqVhost:rabbitmq/vhost.yml.tpl")))
+		Expect(waveOf("appSecret")).To(BeNumerically(">", waveOf("iamUser:aws/iamuser.yml.tpl")))
+		Expect(waveOf("appSecret")).To(BeNumerically("<", waveOf("migration:migrations.yml.tpl")))
+	})
+
+	It("runs the workloads together after the rollout", func() {
+		Expect(waveOf("rollout:web/deployment.yml.tpl")).To(BeNumerically(">", waveOf("hibernation")))
+		Expect(waveOf("deployment:web/deployment.yml.tpl")).To(Equal(waveOf("rollout:web/deployment.yml.tpl") + 1))
+		Expect(waveOf("deployment:celeryd/deployment.yml.tpl")).To(Equal(waveOf("deployment:web/deployment.yml.tpl")))
+		Expect(waveOf("hpa:web/hpa.yml.tpl")).To(Equal(waveOf("deployment:web/deployment.yml.tpl") + 1))
+		Expect(waveOf("celeryAutoscaler")).To(Equal(waveOf("deployment:web/deployment.yml.tpl") + 1))
+		Expect(waveOf("redisDeployment:redis/deployment.yml.tpl")).To(Equal(waveOf("pvc:redis/volumeclaim.yml.tpl") + 1))
+		Expect(waveOf("service:web/service.yml.tpl")).To(Equal(waveOf("rollout:web/deployment.yml.tpl")))
+		Expect(waveOf("ingress:web/ingress.yml.tpl")).To(Equal(waveOf("rollout:web/deployment.yml.tpl")))
+	})
+
+	It("checks the status once everything else is done", func() {
+		Expect(waveOf("status")).To(BeNumerically(">", waveOf("hpa:web/hpa.yml.tpl")))
+		Expect(waveOf("deployHistory")).To(Equal(waveOf("status") + 1))
+		Expect(waveOf("notification")).To(Equal(waveOf("deployHistory") + 1))
+	})
+})
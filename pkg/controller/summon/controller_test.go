@@ -36,6 +36,7 @@ import (
 	apihelpers "github.com/Ridecell/ridecell-operator/pkg/apis/helpers"
 	secretsv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/secrets/v1beta1"
 	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
 	"github.com/Ridecell/ridecell-operator/pkg/test_helpers"
 )
 
@@ -520,4 +521,75 @@ var _ = Describe("Summon controller", func() {
 			return c.Get(context.TODO(), helpers.Name("annotest-web"), service)
 		}, time.Second*10).ShouldNot(Succeed())
 	})
+	It("skips components named in the skip-components annotation", func() {
+		c := helpers.Client
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "skiptest",
+				Namespace: helpers.Namespace,
+				Annotations: map[string]string{
+					"ridecell.io/skip-components": "web/service, backup",
+				},
+			},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+			},
+		}
+		err := c.Create(context.TODO(), instance)
+		Expect(err).ToNot(HaveOccurred())
+
+		// Other services still get created.
+		service := &corev1.Service{}
+		Eventually(func() error {
+			return c.Get(context.TODO(), helpers.Name("skiptest-static"), service)
+		}, timeout).Should(Succeed())
+		Consistently(func() error {
+			return c.Get(context.TODO(), helpers.Name("skiptest-web"), service)
+		}, time.Second*5).ShouldNot(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		Eventually(func() string {
+			c.Get(context.TODO(), helpers.Name("skiptest"), fetched)
+			condition := components.FindCondition(fetched.Status.Conditions, components.ConditionPaused)
+			if condition == nil {
+				return ""
+			}
+			return condition.Reason
+		}, timeout).Should(Equal(components.ReasonSkipping))
+	})
+
+	It("pauses until the pause-until annotation expires", func() {
+		c := helpers.Client
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pausetest",
+				Namespace: helpers.Namespace,
+				Annotations: map[string]string{
+					"ridecell.io/pause-until": time.Now().Add(10 * time.Second).Format(time.RFC3339),
+				},
+			},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+			},
+		}
+		err := c.Create(context.TODO(), instance)
+		Expect(err).ToNot(HaveOccurred())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		Eventually(func() bool {
+			c.Get(context.TODO(), helpers.Name("pausetest"), fetched)
+			return components.IsConditionTrue(fetched.Status.Conditions, components.ConditionPaused)
+		}, timeout).Should(BeTrue())
+		service := &corev1.Service{}
+		Expect(c.Get(context.TODO(), helpers.Name("pausetest-web"), service)).ToNot(Succeed())
+
+		// Once the pause expires the reconciler picks it back up on its own.
+		Eventually(func() error {
+			return c.Get(context.TODO(), helpers.Name("pausetest-web"), service)
+		}, timeout).Should(Succeed())
+		Eventually(func() bool {
+			c.Get(context.TODO(), helpers.Name("pausetest"), fetched)
+			return components.IsConditionTrue(fetched.Status.Conditions, components.ConditionPaused)
+		}, timeout).Should(BeFalse())
+	})
 })
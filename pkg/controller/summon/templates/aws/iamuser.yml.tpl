@@ -64,9 +64,9 @@ spec:
                   "sqs:CreateQueue"
                 ],
                 "Resource": [
-                  "arn:aws:sqs:us-west-2:{{ .Extra.accountId }}:{{ .Instance.Spec.SQSQueue }}",
-                  "arn:aws:sqs:eu-central-1:{{ .Extra.accountId }}:{{ .Instance.Spec.SQSQueue }}",
-                  "arn:aws:sqs:ap-south-1:{{ .Extra.accountId }}:{{ .Instance.Spec.SQSQueue }}"
+                  {{- range $i, $region := .Extra.sqsRegions }}{{ if $i }},{{ end }}
+                  "arn:aws:sqs:{{ $region }}:{{ $.Extra.accountId }}:{{ $.Instance.Spec.SQSQueue }}"
+                  {{- end }}
                 ]
               }
             }
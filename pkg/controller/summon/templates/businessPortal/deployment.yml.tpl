@@ -1,3 +1,4 @@
+{{- if .Instance.Spec.BusinessPortal.Version }}
 apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -71,3 +72,4 @@ spec:
             path: /
             port: 8000
           initialDelaySeconds: 60
+{{- end }}
@@ -1,4 +1,4 @@
 {{ define "componentName" }}businessportal{{ end }}
 {{ define "componentType" }}web{{ end }}
 {{ define "ingressPath" }}/corporate{{ end }}
-{{ template "ingress" . }}
+{{ if .Instance.Spec.BusinessPortal.Version }}{{ template "ingress" . }}{{ end }}
@@ -1,4 +1,4 @@
 {{ define "componentName" }}businessportal{{ end }}
 {{ define "componentType" }}web{{ end }}
 {{ define "maxUnavailable" }}{{ if (gt (int .Instance.Spec.Replicas.BusinessPortal) 1) }}10%{{ else }}100%{{ end }}{{ end }}
-{{ template "podDisruptionBudget" . }}
+{{ if .Instance.Spec.BusinessPortal.Version }}{{ template "podDisruptionBudget" . }}{{ end }}
@@ -1,3 +1,3 @@
 {{ define "componentName" }}businessportal{{ end }}
 {{ define "componentType" }}web{{ end }}
-{{ template "service" . }}
+{{ if .Instance.Spec.BusinessPortal.Version }}{{ template "service" . }}{{ end }}
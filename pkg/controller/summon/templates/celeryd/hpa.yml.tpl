@@ -0,0 +1,3 @@
+{{ define "componentName" }}celeryd{{ end }}
+{{ define "componentType" }}worker{{ end }}
+{{ with .Instance.Spec.Autoscaling.Celeryd }}{{ template "hpa" (dict "Instance" $.Instance "Autoscaling" .) }}{{ end }}
@@ -0,0 +1,3 @@
+{{ define "componentName" }}channelworker{{ end }}
+{{ define "componentType" }}worker{{ end }}
+{{ with .Instance.Spec.Autoscaling.ChannelWorker }}{{ template "hpa" (dict "Instance" $.Instance "Autoscaling" .) }}{{ end }}
@@ -0,0 +1,8 @@
+kind: RDSSnapshot
+apiVersion: db.ridecell.io/v1beta1
+metadata:
+ name: {{ .Instance.Name }}-clone
+ namespace: {{ .Instance.Namespace }}
+spec:
+ rdsInstanceID: {{ .Extra.rdsInstanceName }}
+ ttl: {{ .Instance.Spec.Backup.TTL.Duration }}
@@ -1,3 +1,4 @@
+{{- if .Instance.Spec.Dispatch.Version }}
 apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -98,3 +99,4 @@ spec:
           secret:
             secretName: {{ .Instance.Name }}.gcp-credentials
         {{ end }}
+{{- end }}
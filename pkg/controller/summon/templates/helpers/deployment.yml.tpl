@@ -2,11 +2,11 @@
 apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+  name: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
   namespace: {{ .Instance.Namespace }}
   labels:
     app.kubernetes.io/name: {{ block "componentName" . }}{{ end }}
-    app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+    app.kubernetes.io/instance: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
     app.kubernetes.io/version: {{ .Instance.Spec.Version }}
     app.kubernetes.io/component: {{ block "componentType" . }}{{ end }}
     app.kubernetes.io/part-of: {{ .Instance.Name }}
@@ -16,12 +16,12 @@ spec:
   replicas: {{ block "replicas" . }}1{{ end }}
   selector:
     matchLabels:
-      app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+      app.kubernetes.io/instance: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
   template:
     metadata:
       labels:
         app.kubernetes.io/name: {{ block "componentName" . }}{{ end }}
-        app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+        app.kubernetes.io/instance: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
         app.kubernetes.io/version: {{ .Instance.Spec.Version }}
         app.kubernetes.io/component: {{ block "componentType" . }}{{ end }}
         app.kubernetes.io/part-of: {{ .Instance.Name }}
@@ -39,13 +39,13 @@ spec:
               topologyKey: failure-domain.beta.kubernetes.io/zone
               labelSelector:
                 matchLabels:
-                  app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+                  app.kubernetes.io/instance: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
           - weight: 1
             podAffinityTerm:
               topologyKey: kubernetes.io/hostname
               labelSelector:
                 matchLabels:
-                  app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+                  app.kubernetes.io/instance: {{ block "deploymentName" . }}{{ .Instance.Name }}-{{ template "componentName" . }}{{ end }}
       imagePullSecrets:
       - name: pull-secret
       containers:
@@ -0,0 +1,38 @@
+{{ define "hpa" }}
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+  namespace: {{ .Instance.Namespace }}
+  labels:
+    app.kubernetes.io/name: {{ block "componentName" . }}{{ end }}
+    app.kubernetes.io/instance: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+    app.kubernetes.io/component: {{ block "componentType" . }}{{ end }}
+    app.kubernetes.io/part-of: {{ .Instance.Name }}
+    app.kubernetes.io/managed-by: summon-operator
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ .Instance.Name }}-{{ block "componentName" . }}{{ end }}
+  minReplicas: {{ .Autoscaling.MinReplicas | deref | default 1 }}
+  maxReplicas: {{ .Autoscaling.MaxReplicas }}
+  metrics:
+  {{- if .Autoscaling.TargetCPUUtilization }}
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: {{ deref .Autoscaling.TargetCPUUtilization }}
+  {{- end }}
+  {{- range .Autoscaling.Metrics }}
+  - type: Pods
+    pods:
+      metric:
+        name: {{ .Name }}
+      target:
+        type: AverageValue
+        averageValue: {{ .TargetAverageValue | quote }}
+  {{- end }}
+{{ end }}
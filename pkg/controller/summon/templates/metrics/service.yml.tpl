@@ -2,4 +2,4 @@
 {{ define "componentType" }}metrics{{ end }}
 {{ define "servicePorts" }}[{protocol: TCP, port: 9000}]{{ end }}
 {{ define "selectors" }}{app.kubernetes.io/part-of: {{ .Instance.Name }}, metrics-enabled: "true"}{{ end }}
-{{ template "service" . }}
+{{ if (deref .Instance.Spec.Metrics.Web) }}{{ template "service" . }}{{ end }}
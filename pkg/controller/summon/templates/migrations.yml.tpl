@@ -10,6 +10,10 @@ metadata:
     app.kubernetes.io/component: migration
     app.kubernetes.io/part-of: {{ .Instance.Name }}
     app.kubernetes.io/managed-by: summon-operator
+  {{- if .Extra.flavorLocation }}
+  annotations:
+    summon.ridecell.io/flavor: {{ .Instance.Spec.Flavor }}
+  {{- end }}
 spec:
   template:
     metadata:
@@ -31,8 +35,8 @@ spec:
         command:
         - sh
         - "-c"
-        {{- if ne .Extra.presignedUrl "" }}
-        - python manage.py migrate -v3 && python manage.py loadflavor {{ .Extra.presignedUrl | squote }} --silent
+        {{- if .Extra.flavorLocation }}
+        - python manage.py migrate -v3 && python manage.py loadflavor {{ .Extra.flavorLocation | squote }} --silent
         {{- else }}
         - {{ if and (not .Instance.Spec.NoCore1540Fixup) (ne .Instance.Status.MigrateVersion "") }}if [ -f common/management/commands/core_1540_pre_migrate.py ]; then python manage.py core_1540_pre_migrate; fi && {{ end }}python manage.py migrate -v3
         {{- end }}
@@ -61,6 +65,11 @@ spec:
         - name: newrelic
           mountPath: /home/ubuntu/summon-platform
         {{ end }}
+        {{ if .Extra.flavorVolume }}
+        - name: flavors
+          mountPath: /flavors
+          readOnly: true
+        {{ end }}
       volumes:
         - name: config-volume
           configMap:
@@ -73,3 +82,6 @@ spec:
           secret:
             secretName: {{ .Instance.Name }}.newrelic
         {{ end }}
+        {{ if .Extra.flavorVolume }}
+        - {{ .Extra.flavorVolume | toJson }}
+        {{ end }}
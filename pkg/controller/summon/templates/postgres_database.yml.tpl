@@ -29,4 +29,23 @@ spec:
     rdsMasterUsername: {{ .Instance.Spec.MigrationOverrides.RDSMasterUsername }}
     {{ end }}
   {{ end }}
-
+  {{ if .Instance.Status.AutoRollback.Restore.SnapshotID }}{{ with .Instance.Status.AutoRollback.Restore }}
+  restoreFrom:
+    snapshotID: {{ .SnapshotID }}
+    databaseName: {{ .Database }}
+    {{ if .Owner }}
+    owner: {{ .Owner }}
+    {{ end }}
+  {{ end }}{{ else }}{{ with .Instance.Status.Clone }}{{ if .SourceDatabase }}
+  restoreFrom:
+    {{ if .SnapshotID }}
+    snapshotID: {{ .SnapshotID }}
+    {{ else }}
+    sourceInstanceID: {{ .SourceRDSInstanceID }}
+    {{ if .RestoreTime }}
+    restoreTime: {{ .RestoreTime | quote }}
+    {{ end }}
+    {{ end }}
+    databaseName: {{ .SourceDatabase }}
+    owner: {{ .SourceOwner }}
+  {{ end }}{{ end }}{{ end }}
@@ -10,7 +10,7 @@ metadata:
     app.kubernetes.io/part-of: {{ .Instance.Name }}
     app.kubernetes.io/managed-by: summon-operator
 spec:
-  replicas: 1
+  replicas: {{ if .Extra.hibernating }}0{{ else }}1{{ end }}
   strategy:
     rollingUpdate:
       maxUnavailable: 1
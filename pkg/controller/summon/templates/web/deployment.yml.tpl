@@ -15,7 +15,8 @@
 {{- end -}}
 {{ end }}
 {{ define "metricsEnabled" }}"{{ .Instance.Spec.Metrics.Web | default false }}"{{ end }}
-{{ define "replicas" }}{{ .Instance.Spec.Replicas.Web | default 0 }}{{ end }}
+{{ define "deploymentName" }}{{ .Instance.Name }}-web{{ if .Extra.canary }}-canary{{ end }}{{ end }}
+{{ define "replicas" }}{{ if .Extra.canary }}{{ .Extra.canaryReplicas }}{{ else }}{{ .Instance.Spec.Replicas.Web | default 0 }}{{ end }}{{ end }}
 {{ define "memory_limit" }}2G{{ end }}
 {{ define "containerExtra" }}
         readinessProbe:
@@ -0,0 +1,3 @@
+{{ define "componentName" }}web{{ end }}
+{{ define "componentType" }}web{{ end }}
+{{ with .Instance.Spec.Autoscaling.Web }}{{ template "hpa" (dict "Instance" $.Instance "Autoscaling" .) }}{{ end }}
@@ -1,3 +1,4 @@
 {{ define "componentName" }}web{{ end }}
 {{ define "componentType" }}web{{ end }}
+{{ define "selectors" }}{app.kubernetes.io/name: web, app.kubernetes.io/part-of: {{ .Instance.Name }}}{{ end }}
 {{ template "service" . }}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+)
+
+const (
+	// Annotation on SummonCommands recording who approved them, for SummonPlatforms which require approval. Adding
+	// it with any value approves the command, the admission webhook replaces the value with the requesting user.
+	ApprovedByAnnotation = "summon.ridecell.io/approvedBy"
+	// Annotation on SummonCommands recording who created them, filled in by the admission webhook.
+	CreatedByAnnotation = "summon.ridecell.io/createdBy"
+)
+
+// RecordRequester fills in the createdBy and approvedBy annotations from the user making the request, so neither
+// can be made up by whoever creates the command. old is nil when the command is being created.
+func RecordRequester(old *summonv1beta1.SummonCommand, instance *summonv1beta1.SummonCommand, username string) {
+	if instance.Annotations == nil {
+		instance.Annotations = map[string]string{}
+	}
+	oldAnnotations := map[string]string{}
+	if old != nil && old.Annotations != nil {
+		oldAnnotations = old.Annotations
+	}
+
+	if old == nil {
+		instance.Annotations[CreatedByAnnotation] = username
+	} else if createdBy, ok := oldAnnotations[CreatedByAnnotation]; ok {
+		instance.Annotations[CreatedByAnnotation] = createdBy
+	} else {
+		// Created before the webhook existed, nobody gets to claim it now.
+		delete(instance.Annotations, CreatedByAnnotation)
+	}
+
+	approvedBy, ok := instance.Annotations[ApprovedByAnnotation]
+	oldApprovedBy, oldOk := oldAnnotations[ApprovedByAnnotation]
+	if ok && (!oldOk || approvedBy != oldApprovedBy) {
+		instance.Annotations[ApprovedByAnnotation] = username
+	}
+}
+
+// ValidateApproval rejects commands approved by the same user who created them.
+func ValidateApproval(instance *summonv1beta1.SummonCommand) field.ErrorList {
+	errs := field.ErrorList{}
+	if selfApproved(instance) {
+		errs = append(errs, field.Forbidden(field.NewPath("metadata", "annotations").Key(ApprovedByAnnotation), "commands can't be approved by the user who created them"))
+	}
+	return errs
+}
+
+// ValidateSpecUpdate rejects changes to the spec of an existing command, otherwise an approved command could be
+// swapped for a different one before it starts.
+func ValidateSpecUpdate(old *summonv1beta1.SummonCommand, instance *summonv1beta1.SummonCommand) field.ErrorList {
+	errs := field.ErrorList{}
+	if !reflect.DeepEqual(old.Spec, instance.Spec) {
+		errs = append(errs, field.Forbidden(field.NewPath("spec"), "spec can't be changed once the command is created"))
+	}
+	return errs
+}
+
+// Whether the command has been approved by someone other than its creator.
+func approved(instance *summonv1beta1.SummonCommand) bool {
+	return instance.Annotations[ApprovedByAnnotation] != "" && !selfApproved(instance)
+}
+
+func selfApproved(instance *summonv1beta1.SummonCommand) bool {
+	approvedBy, ok := instance.Annotations[ApprovedByAnnotation]
+	if !ok {
+		return false
+	}
+	createdBy, ok := instance.Annotations[CreatedByAnnotation]
+	return ok && approvedBy == createdBy
+}
+
+// Whether commands against the SummonPlatform need approval to run.
+func requiresApproval(platform *summonv1beta1.SummonPlatform) bool {
+	if platform.Spec.RequireCommandApproval != nil {
+		return *platform.Spec.RequireCommandApproval
+	}
+	return platform.Spec.Environment == "prod"
+}
@@ -0,0 +1,324 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	"github.com/Ridecell/ridecell-operator/pkg/utils"
+)
+
+const (
+	// How often to check on commands waiting for migrations or another command.
+	pendingRecheck = 30 * time.Second
+	// How many log lines to keep from a finished command.
+	commandLogLines = 100
+	// Cap on the logs kept, so a noisy command can't bloat the object.
+	commandLogBytes = 4096
+)
+
+//go:generate moq -pkg components -out zz_generated.mock_podlogclient_test.go ../../../utils PodLogClient
+
+type commandComponent struct {
+	podLogClient utils.PodLogClient
+}
+
+func NewCommand() *commandComponent {
+	return &commandComponent{podLogClient: utils.NewPodLogClient()}
+}
+
+func (comp *commandComponent) InjectPodLogClient(client utils.PodLogClient) {
+	comp.podLogClient = client
+}
+
+func (_ *commandComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{
+		&batchv1.Job{},
+	}
+}
+
+func (_ *commandComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return true
+}
+
+func (comp *commandComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonCommand)
+	if instance.Status.Status == summonv1beta1.CommandStatusSucceeded || instance.Status.Status == summonv1beta1.CommandStatusFailed {
+		// Commands only ever run once.
+		return components.Result{}, nil
+	}
+	if len(instance.Spec.Command) == 0 {
+		return components.Result{}, errors.New("command: spec.command must not be empty")
+	}
+	policy := instance.Spec.ConcurrencyPolicy
+	if policy == "" {
+		policy = summonv1beta1.ConcurrencyPolicyForbid
+	}
+	if policy != summonv1beta1.ConcurrencyPolicyForbid && policy != summonv1beta1.ConcurrencyPolicyReplace && policy != summonv1beta1.ConcurrencyPolicyAllow {
+		return components.Result{}, errors.Errorf("command: unknown concurrency policy %#v", policy)
+	}
+
+	platform := &summonv1beta1.SummonPlatform{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: instance.Spec.Platform, Namespace: instance.Namespace}, platform)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "command: unable to get SummonPlatform %s/%s", instance.Namespace, instance.Spec.Platform)
+	}
+
+	obj, err := ctx.GetTemplate("job.yml.tpl", map[string]interface{}{"platform": platform})
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "command: error rendering job template")
+	}
+	job := obj.(*batchv1.Job)
+
+	existing := &batchv1.Job{}
+	err = ctx.Get(ctx.Context, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
+	if err == nil {
+		return comp.checkJob(ctx, existing)
+	} else if !kerrors.IsNotFound(err) {
+		return components.Result{}, errors.Wrapf(err, "command: unable to get job %s/%s", job.Namespace, job.Name)
+	}
+	if instance.Status.Status == summonv1beta1.CommandStatusRunning {
+		// Stopped by a Replace, or deleted by hand. Either way it isn't going to finish.
+		ctx.Eventf(corev1.EventTypeWarning, "CommandFailed", "Job %s was deleted before it finished", job.Name)
+		return components.Result{StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonCommand)
+			instance.Status.Status = summonv1beta1.CommandStatusFailed
+			instance.Status.Message = "Job was deleted before it finished"
+			return nil
+		}}, nil
+	}
+
+	// Don't run against a half migrated database.
+	if platform.Status.MigrateVersion != platform.Spec.Version {
+		return components.Result{
+			StatusModifier: setStatus(summonv1beta1.CommandStatusPending, fmt.Sprintf("Waiting for migrations for version %s", platform.Spec.Version)),
+			RequeueAfter:   pendingRecheck,
+		}, nil
+	}
+
+	approvedBy := instance.Annotations[ApprovedByAnnotation]
+	if requiresApproval(platform) && !approved(instance) {
+		// Adding the annotation triggers another reconcile.
+		return components.Result{StatusModifier: setStatus(summonv1beta1.CommandStatusAwaitingApproval, fmt.Sprintf("Waiting for someone other than the creator to add a %s annotation", ApprovedByAnnotation))}, nil
+	}
+
+	if policy != summonv1beta1.ConcurrencyPolicyAllow {
+		running, queued, err := otherCommands(ctx, instance)
+		if err != nil {
+			return components.Result{}, err
+		}
+		if policy == summonv1beta1.ConcurrencyPolicyForbid {
+			// Commands created together would all see each other as not running yet, so wait on the ones ahead in
+			// the queue as well.
+			waitFor := append(running, queued...)
+			if len(waitFor) != 0 {
+				return components.Result{
+					StatusModifier: setStatus(summonv1beta1.CommandStatusPending, fmt.Sprintf("Waiting for %s to finish", waitFor[0].Name)),
+					RequeueAfter:   pendingRecheck,
+				}, nil
+			}
+		}
+		for _, other := range running {
+			otherJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: other.Status.Job, Namespace: other.Namespace}}
+			err := ctx.Delete(ctx.Context, otherJob, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !kerrors.IsNotFound(err) {
+				return components.Result{}, errors.Wrapf(err, "command: unable to stop job %s/%s", otherJob.Namespace, otherJob.Name)
+			}
+			glog.Infof("[%s/%s] command: Replaced running command %s\n", instance.Namespace, instance.Name, other.Name)
+			ctx.Eventf(corev1.EventTypeNormal, "CommandReplaced", "Stopped %s", other.Name)
+		}
+	}
+
+	err = controllerutil.SetControllerReference(instance, job, ctx.Scheme)
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "command: unable to set owner reference")
+	}
+	glog.Infof("[%s/%s] command: Creating job %s for %s\n", instance.Namespace, instance.Name, job.Name, strings.Join(instance.Spec.Command, " "))
+	err = ctx.Create(ctx.Context, job)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "command: error creating job %s/%s", job.Namespace, job.Name)
+	}
+	ctx.Eventf(corev1.EventTypeNormal, "CommandStarted", "Running %s against %s version %s", strings.Join(instance.Spec.Command, " "), platform.Name, platform.Spec.Version)
+
+	version := platform.Spec.Version
+	startTime := time.Now().Format(time.UnixDate)
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonCommand)
+		instance.Status.Status = summonv1beta1.CommandStatusRunning
+		instance.Status.Message = fmt.Sprintf("Running as job %s", job.Name)
+		instance.Status.Job = job.Name
+		instance.Status.Version = version
+		instance.Status.ApprovedBy = approvedBy
+		instance.Status.StartTime = startTime
+		return nil
+	}}, nil
+}
+
+// Record how a finished job went. Jobs still running get picked up again when their status changes.
+func (comp *commandComponent) checkJob(ctx *components.ComponentContext, job *batchv1.Job) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonCommand)
+	if job.Status.Succeeded == 0 && job.Status.Failed == 0 && !jobFailed(job) {
+		return components.Result{}, nil
+	}
+
+	pods := &corev1.PodList{}
+	err := ctx.List(ctx.Context, &client.ListOptions{Namespace: job.Namespace, LabelSelector: labels.SelectorFromSet(labels.Set{"job-name": job.Name})}, pods)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "command: unable to list pods for job %s/%s", job.Namespace, job.Name)
+	}
+	var exitCode *int32
+	var finishedAt time.Time
+	var logs string
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != "default" || status.State.Terminated == nil {
+				continue
+			}
+			code := status.State.Terminated.ExitCode
+			exitCode = &code
+			finishedAt = status.State.Terminated.FinishedAt.Time
+			logs = comp.tailLogs(pod.Namespace, pod.Name)
+		}
+	}
+
+	var startedAt time.Time
+	if job.Status.StartTime != nil {
+		startedAt = job.Status.StartTime.Time
+	}
+	if job.Status.CompletionTime != nil {
+		finishedAt = job.Status.CompletionTime.Time
+	}
+	if finishedAt.IsZero() {
+		// Killed before the container finished, like when hitting the deadline.
+		finishedAt = time.Now()
+	}
+	var duration time.Duration
+	if !startedAt.IsZero() {
+		duration = finishedAt.Sub(startedAt).Round(time.Second)
+	}
+
+	status := summonv1beta1.CommandStatusSucceeded
+	message := fmt.Sprintf("Command finished after %s", duration)
+	if job.Status.Succeeded == 0 {
+		status = summonv1beta1.CommandStatusFailed
+		if exitCode != nil {
+			message = fmt.Sprintf("Command exited with code %d after %s", *exitCode, duration)
+		} else {
+			message = "Command failed"
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue && condition.Message != "" {
+				message = fmt.Sprintf("%s: %s", message, condition.Message)
+			}
+		}
+		ctx.Eventf(corev1.EventTypeWarning, "CommandFailed", "%s", message)
+	} else {
+		ctx.Eventf(corev1.EventTypeNormal, "CommandSucceeded", "%s", message)
+	}
+	glog.Infof("[%s/%s] command: %s\n", instance.Namespace, instance.Name, message)
+
+	completionTime := finishedAt.Format(time.UnixDate)
+	return components.Result{StatusModifier: func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonCommand)
+		instance.Status.Status = status
+		instance.Status.Message = message
+		instance.Status.CompletionTime = completionTime
+		instance.Status.Duration = metav1.Duration{Duration: duration}
+		instance.Status.ExitCode = exitCode
+		instance.Status.Logs = logs
+		return nil
+	}}, nil
+}
+
+func (comp *commandComponent) tailLogs(namespace string, pod string) string {
+	logs, err := comp.podLogClient.TailLogs(namespace, pod, "default", false, commandLogLines)
+	if err != nil {
+		// The exit code is still worth recording without them.
+		glog.Errorf("command: unable to get logs for %s/%s: %s\n", namespace, pod, err)
+		return ""
+	}
+	return utils.TrimLogs(logs, commandLogBytes)
+}
+
+// A job past its deadline is marked failed before the pod counts are updated.
+func jobFailed(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// Other commands against the same SummonPlatform which are either running, or waiting to run and were created
+// before this one.
+func otherCommands(ctx *components.ComponentContext, instance *summonv1beta1.SummonCommand) ([]summonv1beta1.SummonCommand, []summonv1beta1.SummonCommand, error) {
+	commands := &summonv1beta1.SummonCommandList{}
+	err := ctx.List(ctx.Context, &client.ListOptions{Namespace: instance.Namespace}, commands)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "command: unable to list SummonCommands")
+	}
+	running := []summonv1beta1.SummonCommand{}
+	queued := []summonv1beta1.SummonCommand{}
+	for _, command := range commands.Items {
+		if command.Name == instance.Name || command.Spec.Platform != instance.Spec.Platform {
+			continue
+		}
+		switch command.Status.Status {
+		case summonv1beta1.CommandStatusRunning:
+			running = append(running, command)
+		case "", summonv1beta1.CommandStatusPending:
+			if queuedBefore(&command, instance) {
+				queued = append(queued, command)
+			}
+		}
+	}
+	return running, queued, nil
+}
+
+// Order commands by when they were created, falling back to the name so two commands can never both go first.
+func queuedBefore(a *summonv1beta1.SummonCommand, b *summonv1beta1.SummonCommand) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+func setStatus(status string, message string) components.StatusModifier {
+	return func(obj runtime.Object) error {
+		instance := obj.(*summonv1beta1.SummonCommand)
+		instance.Status.Status = status
+		instance.Status.Message = message
+		return nil
+	}
+}
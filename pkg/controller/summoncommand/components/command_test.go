@@ -0,0 +1,357 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	commandcomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summoncommand/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonCommand Command Component", func() {
+	comp := commandcomponents.NewCommand()
+	var platform *summonv1beta1.SummonPlatform
+	var mockLogs *commandcomponents.PodLogClientMock
+
+	BeforeEach(func() {
+		comp = commandcomponents.NewCommand()
+		mockLogs = &commandcomponents.PodLogClientMock{
+			TailLogsFunc: func(_ string, _ string, _ string, _ bool, _ int64) (string, error) {
+				return "Cleared 12 keys\n", nil
+			},
+		}
+		comp.InjectPodLogClient(mockLogs)
+		platform = &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev", Namespace: "summon-dev"},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version:     "1.2.3",
+				Environment: "dev",
+			},
+			Status: summonv1beta1.SummonPlatformStatus{
+				MigrateVersion: "1.2.3",
+			},
+		}
+	})
+
+	setup := func(objs ...runtime.Object) {
+		ctx.Client = fake.NewFakeClient(append([]runtime.Object{platform}, objs...)...)
+	}
+
+	getJob := func(name string) (*batchv1.Job, error) {
+		job := &batchv1.Job{}
+		err := ctx.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "summon-dev"}, job)
+		return job, err
+	}
+
+	runningCommand := func(name string, platformName string) *summonv1beta1.SummonCommand {
+		return &summonv1beta1.SummonCommand{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "summon-dev"},
+			Spec:       summonv1beta1.SummonCommandSpec{Platform: platformName, Command: []string{"rebuild_index"}},
+			Status:     summonv1beta1.SummonCommandStatus{Status: summonv1beta1.CommandStatusRunning, Job: name + "-command"},
+		}
+	}
+
+	It("creates a job running the command", func() {
+		setup()
+		Expect(comp).To(ReconcileContext(ctx))
+		job, err := getJob("clear-cache-command")
+		Expect(err).NotTo(HaveOccurred())
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal("us.gcr.io/ridecell-1/summon:1.2.3"))
+		Expect(container.Command).To(Equal([]string{"python", "manage.py", "clear_cache", "--all"}))
+		Expect(job.Spec.Template.Spec.Volumes[0].ConfigMap.Name).To(Equal("foo-dev-config"))
+		Expect(job.Spec.Template.Spec.Volumes[1].Secret.SecretName).To(Equal("foo-dev.app-secrets"))
+		Expect(*job.Spec.BackoffLimit).To(Equal(int32(0)))
+		Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(3600)))
+		Expect(job.OwnerReferences).To(HaveLen(1))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		Expect(instance.Status.Job).To(Equal("clear-cache-command"))
+		Expect(instance.Status.Version).To(Equal("1.2.3"))
+		Expect(instance.Status.StartTime).ToNot(BeEmpty())
+		recorder := ctx.Recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).To(Receive(ContainSubstring("CommandStarted")))
+	})
+
+	It("honors a custom deadline", func() {
+		deadline := int64(60)
+		instance.Spec.ActiveDeadlineSeconds = &deadline
+		setup()
+		Expect(comp).To(ReconcileContext(ctx))
+		job, err := getJob("clear-cache-command")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(60)))
+	})
+
+	It("returns an error for an empty command", func() {
+		instance.Spec.Command = nil
+		setup()
+		Expect(comp).NotTo(ReconcileContext(ctx))
+	})
+
+	It("returns an error for a missing SummonPlatform", func() {
+		ctx.Client = fake.NewFakeClient()
+		Expect(comp).NotTo(ReconcileContext(ctx))
+	})
+
+	It("waits for migrations to finish", func() {
+		platform.Spec.Version = "1.2.4"
+		setup()
+		res, err := comp.Reconcile(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+		Expect(res.StatusModifier(instance)).To(Succeed())
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusPending))
+		_, err = getJob("clear-cache-command")
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	Context("with a prod SummonPlatform", func() {
+		BeforeEach(func() {
+			platform.Spec.Environment = "prod"
+		})
+
+		It("waits for approval", func() {
+			setup()
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusAwaitingApproval))
+			_, err := getJob("clear-cache-command")
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("runs once approved", func() {
+			instance.Annotations = map[string]string{
+				"summon.ridecell.io/createdBy":  "dev@ridecell.com",
+				"summon.ridecell.io/approvedBy": "oncall@ridecell.com",
+			}
+			setup()
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+			Expect(instance.Status.ApprovedBy).To(Equal("oncall@ridecell.com"))
+			_, err := getJob("clear-cache-command")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("doesn't count approval by the creator", func() {
+			instance.Annotations = map[string]string{
+				"summon.ridecell.io/createdBy":  "dev@ridecell.com",
+				"summon.ridecell.io/approvedBy": "dev@ridecell.com",
+			}
+			setup()
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusAwaitingApproval))
+			_, err := getJob("clear-cache-command")
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("runs without approval when it is turned off", func() {
+			requireApproval := false
+			platform.Spec.RequireCommandApproval = &requireApproval
+			setup()
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		})
+	})
+
+	Context("with another command running", func() {
+		It("waits for it by default", func() {
+			setup(runningCommand("reindex", "foo-dev"))
+			res, err := comp.Reconcile(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.RequeueAfter).To(Equal(30 * time.Second))
+			Expect(res.StatusModifier(instance)).To(Succeed())
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusPending))
+			Expect(instance.Status.Message).To(ContainSubstring("reindex"))
+		})
+
+		It("waits for a command created before it which hasn't started yet", func() {
+			instance.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 5, 0, time.UTC))
+			other := runningCommand("reindex", "foo-dev")
+			other.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC))
+			other.Status = summonv1beta1.SummonCommandStatus{Status: summonv1beta1.CommandStatusPending}
+			setup(other)
+			res, err := comp.Reconcile(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.StatusModifier(instance)).To(Succeed())
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusPending))
+			Expect(instance.Status.Message).To(ContainSubstring("reindex"))
+		})
+
+		It("goes ahead of a command created after it", func() {
+			instance.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC))
+			other := runningCommand("reindex", "foo-dev")
+			other.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 5, 0, time.UTC))
+			other.Status = summonv1beta1.SummonCommandStatus{}
+			setup(other)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		})
+
+		It("doesn't wait for a command still awaiting approval", func() {
+			instance.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 5, 0, time.UTC))
+			other := runningCommand("reindex", "foo-dev")
+			other.CreationTimestamp = metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC))
+			other.Status = summonv1beta1.SummonCommandStatus{Status: summonv1beta1.CommandStatusAwaitingApproval}
+			setup(other)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		})
+
+		It("ignores commands against other SummonPlatforms", func() {
+			setup(runningCommand("reindex", "bar-dev"))
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		})
+
+		It("runs alongside it with Allow", func() {
+			instance.Spec.ConcurrencyPolicy = summonv1beta1.ConcurrencyPolicyAllow
+			setup(runningCommand("reindex", "foo-dev"))
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+		})
+
+		It("stops it with Replace", func() {
+			instance.Spec.ConcurrencyPolicy = summonv1beta1.ConcurrencyPolicyReplace
+			otherJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "reindex-command", Namespace: "summon-dev"}}
+			setup(runningCommand("reindex", "foo-dev"), otherJob)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+			_, err := getJob("reindex-command")
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			_, err = getJob("clear-cache-command")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an unknown policy", func() {
+			instance.Spec.ConcurrencyPolicy = "Sometimes"
+			setup()
+			Expect(comp).NotTo(ReconcileContext(ctx))
+		})
+	})
+
+	Context("with a finished job", func() {
+		var job *batchv1.Job
+		var pod *corev1.Pod
+		startTime := metav1.NewTime(time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC))
+		finishTime := metav1.NewTime(time.Date(2020, 3, 1, 10, 2, 30, 0, time.UTC))
+
+		BeforeEach(func() {
+			instance.Status.Status = summonv1beta1.CommandStatusRunning
+			instance.Status.Job = "clear-cache-command"
+			job = &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "clear-cache-command", Namespace: "summon-dev"},
+				Status:     batchv1.JobStatus{StartTime: &startTime},
+			}
+			pod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "clear-cache-command-abcde", Namespace: "summon-dev", Labels: map[string]string{"job-name": "clear-cache-command"}},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "default",
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{FinishedAt: finishTime},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("records a success", func() {
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &finishTime
+			setup(job, pod)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusSucceeded))
+			Expect(instance.Status.ExitCode).ToNot(BeNil())
+			Expect(*instance.Status.ExitCode).To(Equal(int32(0)))
+			Expect(instance.Status.Duration.Duration).To(Equal(150 * time.Second))
+			Expect(instance.Status.Logs).To(Equal("Cleared 12 keys"))
+			Expect(mockLogs.TailLogsCalls()).To(HaveLen(1))
+			Expect(mockLogs.TailLogsCalls()[0].Pod).To(Equal("clear-cache-command-abcde"))
+			recorder := ctx.Recorder.(*record.FakeRecorder)
+			Expect(recorder.Events).To(Receive(ContainSubstring("CommandSucceeded")))
+		})
+
+		It("records a failure", func() {
+			job.Status.Failed = 1
+			pod.Status.ContainerStatuses[0].State.Terminated.ExitCode = 2
+			setup(job, pod)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusFailed))
+			Expect(*instance.Status.ExitCode).To(Equal(int32(2)))
+			Expect(instance.Status.Message).To(ContainSubstring("exited with code 2"))
+			Expect(instance.Status.Duration.Duration).To(Equal(150 * time.Second))
+			recorder := ctx.Recorder.(*record.FakeRecorder)
+			Expect(recorder.Events).To(Receive(ContainSubstring("CommandFailed")))
+		})
+
+		It("keeps only the end of long logs", func() {
+			mockLogs.TailLogsFunc = func(_ string, _ string, _ string, _ bool, _ int64) (string, error) {
+				logs := ""
+				for i := 0; i < 500; i++ {
+					logs += "Processed another batch of records\n"
+				}
+				return logs + "Done\n", nil
+			}
+			job.Status.Succeeded = 1
+			setup(job, pod)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(len(instance.Status.Logs)).To(BeNumerically("<=", 4096))
+			Expect(instance.Status.Logs).To(HavePrefix("Processed"))
+			Expect(instance.Status.Logs).To(HaveSuffix("Done"))
+		})
+
+		It("does nothing once recorded", func() {
+			instance.Status.Status = summonv1beta1.CommandStatusSucceeded
+			job.Status.Failed = 1
+			setup(job, pod)
+			Expect(comp).To(ReconcileContext(ctx))
+			Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusSucceeded))
+			Expect(mockLogs.TailLogsCalls()).To(BeEmpty())
+		})
+	})
+
+	It("leaves a running job alone", func() {
+		instance.Status.Status = summonv1beta1.CommandStatusRunning
+		setup(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "clear-cache-command", Namespace: "summon-dev"}})
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusRunning))
+	})
+
+	It("fails when the job disappears", func() {
+		instance.Status.Status = summonv1beta1.CommandStatusRunning
+		setup()
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Status).To(Equal(summonv1beta1.CommandStatusFailed))
+		_, err := getJob("clear-cache-command")
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,76 @@
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Instance.Name }}-command
+  namespace: {{ .Instance.Namespace }}
+  labels:
+    app.kubernetes.io/name: command
+    app.kubernetes.io/instance: {{ .Instance.Name }}-command
+    app.kubernetes.io/version: {{ .Extra.platform.Spec.Version }}
+    app.kubernetes.io/component: command
+    app.kubernetes.io/part-of: {{ .Extra.platform.Name }}
+    app.kubernetes.io/managed-by: summon-operator
+spec:
+  # One-off commands aren't safe to retry.
+  backoffLimit: 0
+  activeDeadlineSeconds: {{ .Instance.Spec.ActiveDeadlineSeconds | deref | default 3600 }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: command
+        app.kubernetes.io/instance: {{ .Instance.Name }}-command
+        app.kubernetes.io/version: {{ .Extra.platform.Spec.Version }}
+        app.kubernetes.io/component: command
+        app.kubernetes.io/part-of: {{ .Extra.platform.Name }}
+        app.kubernetes.io/managed-by: summon-operator
+    spec:
+      restartPolicy: Never
+      imagePullSecrets:
+      - name: pull-secret
+      containers:
+      - name: default
+        image: us.gcr.io/ridecell-1/summon:{{ .Extra.platform.Spec.Version }}
+        imagePullPolicy: Always
+        command:
+        - python
+        - manage.py
+        {{- range .Instance.Spec.Command }}
+        - {{ . | quote }}
+        {{- end }}
+        resources:
+          requests:
+            memory: 1.5G
+            cpu: 500m
+          limits:
+            memory: 2.5G
+        {{ if .Extra.platform.Spec.EnableNewRelic }}
+        env:
+        - name: NEW_RELIC_LICENSE_KEY
+          valueFrom:
+          secretKeyRef:
+            name: {{ .Extra.platform.Name }}.newrelic
+            key: NEW_RELIC_LICENSE_KEY
+        - name: NEW_RELIC_APP_NAME
+          value: {{ .Extra.platform.Name }}-summon-platform
+        {{ end }}
+        volumeMounts:
+        - name: config-volume
+          mountPath: /etc/config
+        - name: app-secrets
+          mountPath: /etc/secrets
+        {{ if .Extra.platform.Spec.EnableNewRelic }}
+        - name: newrelic
+          mountPath: /home/ubuntu/summon-platform
+        {{ end }}
+      volumes:
+        - name: config-volume
+          configMap:
+            name: {{ .Extra.platform.Name }}-config
+        - name: app-secrets
+          secret:
+            secretName: {{ .Extra.platform.Name }}.app-secrets
+        {{ if .Extra.platform.Spec.EnableNewRelic }}
+        - name: newrelic
+          secret:
+            secretName: {{ .Extra.platform.Name }}.newrelic
+        {{ end }}
@@ -0,0 +1,289 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+	gcr "github.com/Ridecell/ridecell-operator/pkg/utils/gcr"
+)
+
+const (
+	defaultPreviewTTL = 72 * time.Hour
+	// Longer names run into limits on the IAM users and databases named after them.
+	maxPreviewNameLength = 40
+	// Label on preview SummonPlatforms naming the template they came from.
+	previewTemplateLabel = "summon.ridecell.io/previewTemplate"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type previewsComponent struct {
+	branchLister func() ([]string, error)
+	tagFetcher   func(string) (string, error)
+}
+
+func NewPreviews() *previewsComponent {
+	return &previewsComponent{
+		branchLister: gcr.GetBranches,
+		tagFetcher:   gcr.GetLatestImageOfBranch,
+	}
+}
+
+func (comp *previewsComponent) InjectMockBranchLister(branchListerFunc func() ([]string, error)) {
+	comp.branchLister = branchListerFunc
+}
+
+func (comp *previewsComponent) InjectMockTagFetcher(tagFetcherFunc func(string) (string, error)) {
+	comp.tagFetcher = tagFetcherFunc
+}
+
+func (_ *previewsComponent) WatchTypes() []runtime.Object {
+	return []runtime.Object{
+		&summonv1beta1.SummonPlatform{},
+	}
+}
+
+func (_ *previewsComponent) IsReconcilable(_ *components.ComponentContext) bool {
+	return true
+}
+
+func (comp *previewsComponent) Reconcile(ctx *components.ComponentContext) (components.Result, error) {
+	instance := ctx.Top.(*summonv1beta1.SummonPreviewTemplate)
+	if ctx.DryRun {
+		// Don't query GCR or touch any previews during a dry run.
+		return components.Result{}, nil
+	}
+
+	branchPattern, err := regexp.Compile(instance.Spec.BranchPattern)
+	if err != nil {
+		return components.Result{}, errors.Wrapf(err, "previews: invalid branchPattern %#v", instance.Spec.BranchPattern)
+	}
+	ttl := instance.Spec.TTL.Duration
+	if ttl == 0 {
+		ttl = defaultPreviewTTL
+	}
+
+	branches, err := comp.branchLister()
+	if err != nil {
+		return components.Result{}, errors.Wrap(err, "previews: unable to list branches")
+	}
+
+	now := time.Now()
+	previous := map[string]summonv1beta1.PreviewStatus{}
+	for _, preview := range instance.Status.Previews {
+		previous[preview.Branch] = preview
+	}
+	previews := []summonv1beta1.PreviewStatus{}
+	// Check again when the image cache expires or the next preview does, whichever is first.
+	requeueAfter := gcr.GetCacheExpiry()
+	running := 0
+	failed := 0
+
+	for _, branch := range branches {
+		if !branchPattern.MatchString(branch) {
+			continue
+		}
+		preview, ok := previous[branch]
+		if !ok {
+			name := previewName(instance, branch)
+			preview = summonv1beta1.PreviewStatus{Branch: branch, Name: name}
+			if instance.Spec.HostnameDomain != "" {
+				preview.Hostname = fmt.Sprintf("%s.%s", name, instance.Spec.HostnameDomain)
+			}
+		}
+
+		image, err := comp.tagFetcher(branch)
+		if err != nil {
+			// One bad branch shouldn't hold up every other preview. Leave this one as it was until the lookup works.
+			glog.Errorf("[%s/%s] previews: unable to find image for branch %s: %s\n", instance.Namespace, instance.Name, branch, err)
+			delete(previous, branch)
+			preview.Error = fmt.Sprintf("Unable to find image: %s", err)
+			previews = append(previews, preview)
+			failed++
+			if preview.Image != "" && !preview.Expired {
+				running++
+			}
+			continue
+		}
+		if image == "" {
+			continue
+		}
+		delete(previous, branch)
+		preview.Error = ""
+		if preview.Image != image {
+			// A new image means someone is still working on the branch, so it gets a fresh TTL.
+			preview.Image = image
+			preview.ExpiresAt = now.Add(ttl).Format(time.UnixDate)
+			preview.Expired = false
+		}
+
+		expiresAt, err := time.Parse(time.UnixDate, preview.ExpiresAt)
+		if err != nil {
+			return components.Result{}, errors.Wrapf(err, "previews: unable to parse expiry time for branch %s", branch)
+		}
+		if now.After(expiresAt) {
+			if !preview.Expired {
+				err = comp.deletePreview(ctx, preview.Name)
+				if err != nil {
+					return components.Result{}, err
+				}
+				ctx.Eventf(corev1.EventTypeNormal, "PreviewExpired", "Deleted preview %s for branch %s after its TTL lapsed", preview.Name, branch)
+				preview.Expired = true
+			}
+		} else {
+			err = comp.createPreview(ctx, preview)
+			if err != nil {
+				return components.Result{}, err
+			}
+			running++
+			if expiresAt.Sub(now) < requeueAfter {
+				requeueAfter = expiresAt.Sub(now)
+			}
+		}
+		previews = append(previews, preview)
+	}
+
+	// Anything left over no longer has images, or the pattern changed.
+	for _, preview := range previous {
+		// A preview whose image lookups have only ever failed was never created.
+		if !preview.Expired && preview.Image != "" {
+			err = comp.deletePreview(ctx, preview.Name)
+			if err != nil {
+				return components.Result{}, err
+			}
+			ctx.Eventf(corev1.EventTypeNormal, "PreviewRemoved", "Deleted preview %s as branch %s no longer has images", preview.Name, preview.Branch)
+		}
+	}
+
+	message := fmt.Sprintf("%d previews running", running)
+	if failed > 0 {
+		message = fmt.Sprintf("%s, unable to find images for %d branches", message, failed)
+	}
+	return components.Result{
+		StatusModifier: func(obj runtime.Object) error {
+			instance := obj.(*summonv1beta1.SummonPreviewTemplate)
+			instance.Status.Status = summonv1beta1.StatusReady
+			instance.Status.Message = message
+			instance.Status.Previews = previews
+			return nil
+		},
+		RequeueAfter: requeueAfter,
+	}, nil
+}
+
+// Generate a SummonPlatform name for a branch, following the usual <name>-<environment> format.
+func previewName(instance *summonv1beta1.SummonPreviewTemplate, branch string) string {
+	prefix := instance.Spec.NamePrefix
+	if prefix == "" {
+		prefix = instance.Name
+	}
+	environment := instance.Spec.Template.Environment
+	if environment == "" {
+		environment = strings.TrimPrefix(instance.Namespace, "summon-")
+	}
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(branch), "-"), "-")
+
+	maxSlugLength := maxPreviewNameLength - len(prefix) - len(environment) - 2
+	if maxSlugLength < 12 {
+		// Long prefixes win over the length limit, the branch still has to be recognizable.
+		maxSlugLength = 12
+	}
+	if len(slug) > maxSlugLength {
+		// Keep truncated names unique with a hash of the full branch name.
+		hash := sha1.Sum([]byte(branch))
+		suffix := hex.EncodeToString(hash[:])[:6]
+		slug = strings.Trim(slug[:maxSlugLength-len(suffix)-1], "-") + "-" + suffix
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, slug, environment)
+}
+
+// Create the SummonPlatform for a preview if it doesn't exist yet. Existing previews are left alone, autodeploy
+// takes care of new images.
+func (comp *previewsComponent) createPreview(ctx *components.ComponentContext, preview summonv1beta1.PreviewStatus) error {
+	instance := ctx.Top.(*summonv1beta1.SummonPreviewTemplate)
+	existing := &summonv1beta1.SummonPlatform{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: preview.Name, Namespace: instance.Namespace}, existing)
+	if err == nil {
+		return nil
+	} else if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "previews: unable to get SummonPlatform %s", preview.Name)
+	}
+
+	platform := &summonv1beta1.SummonPlatform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      preview.Name,
+			Namespace: instance.Namespace,
+			Labels:    map[string]string{previewTemplateLabel: instance.Name},
+		},
+		Spec: *instance.Spec.Template.DeepCopy(),
+	}
+	platform.Spec.Version = ""
+	platform.Spec.AutoDeploy = preview.Branch
+	platform.Spec.Hostname = preview.Hostname
+	// Deleting the template deletes its previews too.
+	err = controllerutil.SetControllerReference(instance, platform, ctx.Scheme)
+	if err != nil {
+		return errors.Wrapf(err, "previews: unable to set controller reference on %s", preview.Name)
+	}
+	glog.Infof("previews: creating SummonPlatform %s/%s for branch %s\n", instance.Namespace, preview.Name, preview.Branch)
+	err = ctx.Create(ctx.Context, platform)
+	if err != nil {
+		return errors.Wrapf(err, "previews: unable to create SummonPlatform %s", preview.Name)
+	}
+	return nil
+}
+
+// Delete a preview's SummonPlatform. The finalizers on its database, bucket and IAM user take care of cleaning up
+// the AWS side.
+func (comp *previewsComponent) deletePreview(ctx *components.ComponentContext, name string) error {
+	instance := ctx.Top.(*summonv1beta1.SummonPreviewTemplate)
+	existing := &summonv1beta1.SummonPlatform{}
+	err := ctx.Get(ctx.Context, types.NamespacedName{Name: name, Namespace: instance.Namespace}, existing)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "previews: unable to get SummonPlatform %s", name)
+	}
+	if existing.Labels[previewTemplateLabel] != instance.Name {
+		// Never delete an instance somebody made by hand, even if the name matches.
+		glog.Errorf("previews: not deleting SummonPlatform %s/%s, it wasn't created by preview template %s\n", instance.Namespace, name, instance.Name)
+		return nil
+	}
+	glog.Infof("previews: deleting SummonPlatform %s/%s\n", instance.Namespace, name)
+	err = ctx.Delete(ctx.Context, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "previews: unable to delete SummonPlatform %s", name)
+	}
+	return nil
+}
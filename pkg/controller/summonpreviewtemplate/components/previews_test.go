@@ -0,0 +1,173 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package components_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	previewcomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summonpreviewtemplate/components"
+	. "github.com/Ridecell/ridecell-operator/pkg/test_helpers/matchers"
+)
+
+var _ = Describe("SummonPreviewTemplate Previews Component", func() {
+	comp := previewcomponents.NewPreviews()
+	var branches []string
+	var images map[string]string
+
+	BeforeEach(func() {
+		comp = previewcomponents.NewPreviews()
+		branches = []string{"feature-login", "master", "feature-maps"}
+		images = map[string]string{
+			"feature-login": "1234-abcdef1-feature-login",
+			"master":        "1230-1234567-master",
+			"feature-maps":  "1235-abcdef2-feature-maps",
+		}
+		comp.InjectMockBranchLister(func() ([]string, error) {
+			return branches, nil
+		})
+		comp.InjectMockTagFetcher(func(branch string) (string, error) {
+			return images[branch], nil
+		})
+	})
+
+	getPlatform := func(name string) (*summonv1beta1.SummonPlatform, error) {
+		platform := &summonv1beta1.SummonPlatform{}
+		err := ctx.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "summon-dev"}, platform)
+		return platform, err
+	}
+
+	It("creates a SummonPlatform for each matching branch", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Previews).To(HaveLen(2))
+		Expect(instance.Status.Message).To(Equal("2 previews running"))
+
+		platform, err := getPlatform("preview-feature-login-dev")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(platform.Spec.AutoDeploy).To(Equal("feature-login"))
+		Expect(platform.Spec.Hostname).To(Equal("preview-feature-login-dev.preview.ridecell.us"))
+		Expect(platform.OwnerReferences).To(HaveLen(1))
+		_, err = getPlatform("preview-master-dev")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("keeps long names short", func() {
+		branches = []string{"feature-a-very-long-branch-name-for-a-small-change"}
+		images["feature-a-very-long-branch-name-for-a-small-change"] = "1236-abcdef3-feature-a-very-long-branch-name-for-a-small-change"
+		Expect(comp).To(ReconcileContext(ctx))
+		name := instance.Status.Previews[0].Name
+		Expect(len(name)).To(BeNumerically("<=", 40))
+		Expect(strings.HasPrefix(name, "preview-feature-a-very")).To(BeTrue())
+		Expect(strings.HasSuffix(name, "-dev")).To(BeTrue())
+	})
+
+	It("deletes a preview once its TTL lapses", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		instance.Status.Previews[0].ExpiresAt = time.Now().Add(-time.Minute).Format(time.UnixDate)
+
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Previews[0].Expired).To(BeTrue())
+		_, err := getPlatform(instance.Status.Previews[0].Name)
+		Expect(err).To(HaveOccurred())
+		Expect(instance.Status.Message).To(Equal("1 previews running"))
+
+		// Not recreated until there is a new image.
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err = getPlatform(instance.Status.Previews[0].Name)
+		Expect(err).To(HaveOccurred())
+
+		images["feature-login"] = "1240-abcdef9-feature-login"
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Previews[0].Expired).To(BeFalse())
+		_, err = getPlatform(instance.Status.Previews[0].Name)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("deletes a preview once its branch has no images", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		branches = []string{"feature-login", "master"}
+
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Previews).To(HaveLen(1))
+		_, err := getPlatform("preview-feature-maps-dev")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("doesn't delete SummonPlatforms it didn't create", func() {
+		instance.Status.Previews = []summonv1beta1.PreviewStatus{{
+			Branch:    "feature-old",
+			Name:      "handmade-dev",
+			Image:     "1200-abcdef0-feature-old",
+			ExpiresAt: time.Now().Add(time.Hour).Format(time.UnixDate),
+		}}
+		handmade := &summonv1beta1.SummonPlatform{ObjectMeta: metav1.ObjectMeta{Name: "handmade-dev", Namespace: "summon-dev"}}
+		Expect(ctx.Create(context.TODO(), handmade)).To(Succeed())
+
+		Expect(comp).To(ReconcileContext(ctx))
+		_, err := getPlatform("handmade-dev")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("carries on with other branches when one image lookup fails", func() {
+		Expect(comp).To(ReconcileContext(ctx))
+		branches = []string{"feature-login", "master", "feature-maps", "feature-search"}
+		images["feature-search"] = "1241-abcdef4-feature-search"
+		comp.InjectMockTagFetcher(func(branch string) (string, error) {
+			if branch == "feature-maps" {
+				return "", fmt.Errorf("gcr unavailable")
+			}
+			return images[branch], nil
+		})
+
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Message).To(Equal("3 previews running, unable to find images for 1 branches"))
+		_, err := getPlatform("preview-feature-search-dev")
+		Expect(err).ToNot(HaveOccurred())
+		// The failed branch keeps its preview.
+		_, err = getPlatform("preview-feature-maps-dev")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.Status.Previews).To(ContainElement(MatchFields(IgnoreExtras, Fields{
+			"Branch": Equal("feature-maps"),
+			"Image":  Equal("1235-abcdef2-feature-maps"),
+			"Error":  ContainSubstring("gcr unavailable"),
+		})))
+
+		// And the error clears once the lookup works again.
+		comp.InjectMockTagFetcher(func(branch string) (string, error) {
+			return images[branch], nil
+		})
+		Expect(comp).To(ReconcileContext(ctx))
+		Expect(instance.Status.Message).To(Equal("3 previews running"))
+		for _, preview := range instance.Status.Previews {
+			Expect(preview.Error).To(BeEmpty())
+		}
+	})
+
+	It("rejects an invalid branch pattern", func() {
+		instance.Spec.BranchPattern = "feature-("
+		Expect(comp).ToNot(ReconcileContext(ctx))
+	})
+})
@@ -0,0 +1,174 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// Identifies a single object across all types.
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	types.NamespacedName
+}
+
+// A tracked object, with the live state from before the dry run and the state after it.
+type recordedObject struct {
+	key     objectKey
+	live    runtime.Object
+	goal    runtime.Object
+	deleted bool
+}
+
+// RecordingClient is a client.Client which reads from the live cluster but never writes to it. Writes are recorded
+// and stored in an in-memory overlay so later reads see them, the same as they would in a real reconcile.
+// List calls only see the live cluster.
+type RecordingClient struct {
+	live    client.Client
+	overlay client.Client
+	scheme  *runtime.Scheme
+	mutex   sync.Mutex
+	objects map[objectKey]*recordedObject
+	// Keys in the order they were first written, to keep the output stable.
+	order []objectKey
+}
+
+var _ client.Client = &RecordingClient{}
+
+func NewRecordingClient(live client.Client, scheme *runtime.Scheme) *RecordingClient {
+	return &RecordingClient{
+		live:    live,
+		overlay: fake.NewFakeClientWithScheme(scheme),
+		scheme:  scheme,
+		objects: map[objectKey]*recordedObject{},
+	}
+}
+
+func (c *RecordingClient) keyFor(obj runtime.Object) (objectKey, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return objectKey{}, errors.Wrap(err, "dryrun: unable to find kind")
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKey{}, errors.Wrap(err, "dryrun: unable to access object metadata")
+	}
+	return objectKey{gvk: gvk, NamespacedName: types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}}, nil
+}
+
+func notFound(key objectKey) error {
+	return kerrors.NewNotFound(schema.GroupResource{Group: key.gvk.Group, Resource: strings.ToLower(key.gvk.Kind)}, key.Name)
+}
+
+func (c *RecordingClient) Get(ctx context.Context, name client.ObjectKey, obj runtime.Object) error {
+	key, err := c.keyFor(obj)
+	if err != nil {
+		return err
+	}
+	key.NamespacedName = name
+
+	c.mutex.Lock()
+	recorded, ok := c.objects[key]
+	c.mutex.Unlock()
+	if ok && recorded.deleted {
+		return notFound(key)
+	}
+	if ok {
+		return c.overlay.Get(ctx, name, obj)
+	}
+	return c.live.Get(ctx, name, obj)
+}
+
+func (c *RecordingClient) List(ctx context.Context, opts *client.ListOptions, list runtime.Object) error {
+	return c.live.List(ctx, opts, list)
+}
+
+func (c *RecordingClient) Create(ctx context.Context, obj runtime.Object) error {
+	return c.record(ctx, obj, false)
+}
+
+func (c *RecordingClient) Update(ctx context.Context, obj runtime.Object) error {
+	return c.record(ctx, obj, false)
+}
+
+func (c *RecordingClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOptionFunc) error {
+	return c.record(ctx, obj, true)
+}
+
+// Status updates are recorded like any other update.
+func (c *RecordingClient) Status() client.StatusWriter {
+	return c
+}
+
+// Store a write in the overlay, grabbing the live copy the first time the object is seen.
+func (c *RecordingClient) record(ctx context.Context, obj runtime.Object, deleted bool) error {
+	key, err := c.keyFor(obj)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	recorded, ok := c.objects[key]
+	if !ok {
+		recorded = &recordedObject{key: key}
+		live, err := c.scheme.New(key.gvk)
+		if err != nil {
+			return errors.Wrapf(err, "dryrun: unable to create a %s", key.gvk.Kind)
+		}
+		err = c.live.Get(ctx, key.NamespacedName, live)
+		if err == nil {
+			recorded.live = live
+		} else if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "dryrun: error getting live copy of %s %s", key.gvk.Kind, key.NamespacedName)
+		}
+		c.objects[key] = recorded
+		c.order = append(c.order, key)
+	}
+
+	// Replace whatever was in the overlay with this version.
+	err = c.overlay.Delete(ctx, obj.DeepCopyObject())
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "dryrun: error clearing overlay for %s %s", key.gvk.Kind, key.NamespacedName)
+	}
+	recorded.deleted = deleted
+	if deleted {
+		recorded.goal = nil
+		return nil
+	}
+	goal := obj.DeepCopyObject()
+	accessor, _ := meta.Accessor(goal)
+	accessor.SetResourceVersion("")
+	err = c.overlay.Create(ctx, goal)
+	if err != nil {
+		return errors.Wrapf(err, "dryrun: error storing %s %s", key.gvk.Kind, key.NamespacedName)
+	}
+	recorded.goal = obj.DeepCopyObject()
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/Ridecell/ridecell-operator/pkg/dryrun"
+)
+
+var _ = Describe("RecordingClient", func() {
+	var live client.Client
+	var recorder *dryrun.RecordingClient
+
+	BeforeEach(func() {
+		replicas := int32(1)
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-web", Namespace: "summon-dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev.app-secrets", Namespace: "summon-dev"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		}
+		live = fake.NewFakeClient(deployment, secret)
+		recorder = dryrun.NewRecordingClient(live, scheme.Scheme)
+	})
+
+	It("records a create without touching the live cluster", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-config", Namespace: "summon-dev"},
+			Data:       map[string]string{"summon-platform.yml": "{}"},
+		}
+		err := recorder.Create(context.TODO(), configMap)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = live.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-config", Namespace: "summon-dev"}, &corev1.ConfigMap{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+		// Later reads see the recorded object.
+		fetched := &corev1.ConfigMap{}
+		err = recorder.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-config", Namespace: "summon-dev"}, fetched)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Data).To(HaveKeyWithValue("summon-platform.yml", "{}"))
+
+		changes, err := recorder.Changes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Action).To(Equal(dryrun.ActionCreate))
+		Expect(changes[0].Kind).To(Equal("ConfigMap"))
+		Expect(changes[0].Fields).To(ContainElement(dryrun.FieldChange{Path: "data.summon-platform.yml", New: "{}"}))
+	})
+
+	It("records an update as a field diff", func() {
+		deployment := &appsv1.Deployment{}
+		err := recorder.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		replicas := int32(3)
+		deployment.Spec.Replicas = &replicas
+		err = recorder.Update(context.TODO(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = live.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+
+		changes, err := recorder.Changes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Action).To(Equal(dryrun.ActionUpdate))
+		Expect(changes[0].Fields).To(Equal([]dryrun.FieldChange{{Path: "spec.replicas", Old: int64(1), New: int64(3)}}))
+	})
+
+	It("skips updates which don't change anything", func() {
+		deployment := &appsv1.Deployment{}
+		err := recorder.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+		err = recorder.Update(context.TODO(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		changes, err := recorder.Changes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("redacts secret values", func() {
+		secret := &corev1.Secret{}
+		err := recorder.Get(context.TODO(), types.NamespacedName{Name: "foo-dev.app-secrets", Namespace: "summon-dev"}, secret)
+		Expect(err).ToNot(HaveOccurred())
+		secret.Data["password"] = []byte("correcthorsebatterystaple")
+		err = recorder.Update(context.TODO(), secret)
+		Expect(err).ToNot(HaveOccurred())
+
+		changes, err := recorder.Changes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Fields).To(Equal([]dryrun.FieldChange{{Path: "data.password", Old: "<redacted>", New: "<redacted>"}}))
+	})
+
+	It("records a delete and hides the object from later reads", func() {
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "foo-dev-web", Namespace: "summon-dev"}}
+		err := recorder.Delete(context.TODO(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = recorder.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, deployment)
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		err = live.Get(context.TODO(), types.NamespacedName{Name: "foo-dev-web", Namespace: "summon-dev"}, deployment)
+		Expect(err).ToNot(HaveOccurred())
+
+		changes, err := recorder.Changes()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(HaveLen(1))
+		Expect(changes[0].Action).To(Equal(dryrun.ActionDelete))
+	})
+})
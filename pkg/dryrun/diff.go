@@ -0,0 +1,205 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// What would happen to an object.
+type Action string
+
+const (
+	ActionCreate Action = "Create"
+	ActionUpdate Action = "Update"
+	ActionDelete Action = "Delete"
+)
+
+// Values of changed Secret fields are replaced with this.
+const redacted = "<redacted>"
+
+// FieldChange is a single changed field, addressed by a path like spec.template.spec.containers[0].image.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Change is everything that would happen to a single object.
+type Change struct {
+	Action     Action        `json:"action"`
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Namespace  string        `json:"namespace,omitempty"`
+	Name       string        `json:"name"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+}
+
+// Fields which are already part of the Change or are managed by the API server, so aren't interesting in a plan.
+var ignoredPaths = map[string]bool{
+	"apiVersion":                 true,
+	"kind":                       true,
+	"status":                     true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"metadata.creationTimestamp": true,
+	"metadata.generation":        true,
+	"metadata.selfLink":          true,
+	"metadata.annotations." + components.LastAppliedAnnotation: true,
+}
+
+// Changes returns the net effect of every write recorded so far, in the order the objects were first written.
+// Updates which didn't actually change anything are left out.
+func (c *RecordingClient) Changes() ([]Change, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changes := []Change{}
+	for _, key := range c.order {
+		recorded := c.objects[key]
+		change := Change{
+			APIVersion: key.gvk.GroupVersion().String(),
+			Kind:       key.gvk.Kind,
+			Namespace:  key.Namespace,
+			Name:       key.Name,
+		}
+		switch {
+		case recorded.live == nil && recorded.goal == nil:
+			// Created and then deleted again, or deleted when it never existed.
+			continue
+		case recorded.goal == nil:
+			change.Action = ActionDelete
+		case recorded.live == nil:
+			change.Action = ActionCreate
+		default:
+			change.Action = ActionUpdate
+		}
+
+		if change.Action != ActionDelete {
+			fields, err := diffObjects(recorded.live, recorded.goal, key.gvk.Kind == "Secret")
+			if err != nil {
+				return nil, errors.Wrapf(err, "dryrun: error diffing %s %s", key.gvk.Kind, key.NamespacedName)
+			}
+			if change.Action == ActionUpdate && len(fields) == 0 {
+				continue
+			}
+			change.Fields = fields
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Compare two objects field by field. Either may be nil.
+func diffObjects(old, new runtime.Object, secret bool) ([]FieldChange, error) {
+	oldMap, err := toMap(old)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := toMap(new)
+	if err != nil {
+		return nil, err
+	}
+	fields := []FieldChange{}
+	diffValues("", oldMap, newMap, &fields)
+
+	if secret {
+		for i, field := range fields {
+			if strings.HasPrefix(field.Path, "data.") || strings.HasPrefix(field.Path, "stringData.") {
+				if field.Old != nil {
+					fields[i].Old = redacted
+				}
+				if field.New != nil {
+					fields[i].New = redacted
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+func toMap(obj runtime.Object) (map[string]interface{}, error) {
+	if obj == nil || reflect.ValueOf(obj).IsNil() {
+		return map[string]interface{}{}, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Walk two unstructured values in parallel, adding a FieldChange for each leaf that differs.
+func diffValues(path string, old, new interface{}, out *[]FieldChange) {
+	if ignoredPaths[path] {
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if (oldIsMap || old == nil) && (newIsMap || new == nil) && (oldIsMap || newIsMap) {
+		keys := []string{}
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			diffValues(joinPath(path, key), oldMap[key], newMap[key], out)
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if (oldIsSlice || old == nil) && (newIsSlice || new == nil) && (oldIsSlice || newIsSlice) {
+		length := len(oldSlice)
+		if len(newSlice) > length {
+			length = len(newSlice)
+		}
+		for i := 0; i < length; i++ {
+			var oldItem, newItem interface{}
+			if i < len(oldSlice) {
+				oldItem = oldSlice[i]
+			}
+			if i < len(newSlice) {
+				newItem = newSlice[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), oldItem, newItem, out)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*out = append(*out, FieldChange{Path: path, Old: old, New: new})
+	}
+}
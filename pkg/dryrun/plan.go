@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dryrun runs components against a live object without changing anything, to show what a reconcile
+// would do.
+package dryrun
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Ridecell/ridecell-operator/pkg/components"
+)
+
+// Plan is the outcome of a dry run.
+type Plan struct {
+	// Writes the components would make, compared to the live objects.
+	Changes []Change `json:"changes"`
+	// Components which weren't ready to reconcile, so their changes can't be included yet.
+	Waiting []string `json:"waiting,omitempty"`
+	// The error the run stopped on, if any. Components after it didn't run.
+	Error string `json:"error,omitempty"`
+	// The status the top object would have afterwards.
+	Status components.Status `json:"status,omitempty"`
+}
+
+// Run the components once against top, reading from the live client but recording every write instead of
+// applying it. The top object itself is modified in place, just like in a real reconcile.
+func Run(live client.Client, scheme *runtime.Scheme, templates http.FileSystem, top runtime.Object, comps []components.Component) (*Plan, error) {
+	recorder := NewRecordingClient(live, scheme)
+	ctx := components.NewDryRunContext(top, templates, recorder, scheme)
+
+	plan := &Plan{}
+	for _, comp := range comps {
+		if !comp.IsReconcilable(ctx) {
+			plan.Waiting = append(plan.Waiting, components.ComponentName(comp))
+		}
+	}
+
+	err := components.DryRun(ctx, comps)
+	if err != nil {
+		plan.Error = err.Error()
+	}
+
+	plan.Changes, err = recorder.Changes()
+	if err != nil {
+		return nil, err
+	}
+	if statuser, ok := top.(components.Statuser); ok {
+		plan.Status = statuser.GetStatus()
+	}
+	return plan, nil
+}
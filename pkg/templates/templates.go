@@ -18,9 +18,11 @@ package templates
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"path"
 	"reflect"
+	"strings"
 	"text/template"
 
 	// "github.com/golang/glog"
@@ -31,6 +33,10 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 )
 
+// Returned by Get when a template renders to nothing, which is how templates say the object they describe is not
+// wanted for this instance.
+var ErrEmptyTemplate = errors.New("template rendered no object")
+
 func parseTemplate(fs http.FileSystem, filename string) (*template.Template, error) {
 	// Wrote this because if statements with pointers don't work how you'd think they would
 	customFuncMap := template.FuncMap{
@@ -106,6 +112,9 @@ func Get(fs http.FileSystem, filename string, data interface{}) (runtime.Object,
 	if err != nil {
 		return nil, err
 	}
+	if strings.TrimSpace(out) == "" {
+		return nil, ErrEmptyTemplate
+	}
 	obj, err := parseObject(out)
 	if err != nil {
 		return nil, err
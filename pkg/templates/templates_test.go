@@ -62,4 +62,19 @@ var _ = Describe("Templates", func() {
 			Expect(deployment.Spec.Replicas).To(PointTo(BeEquivalentTo(1)))
 		})
 	})
+
+	Context("a template which renders nothing", func() {
+		It("should return ErrEmptyTemplate", func() {
+			_, err := templates.Get(testTemplates, "test4.yml.tpl", struct{ Enabled bool }{Enabled: false})
+			Expect(err).To(Equal(templates.ErrEmptyTemplate))
+		})
+
+		It("should render the Deployment when enabled", func() {
+			rawObject, err := templates.Get(testTemplates, "test4.yml.tpl", struct{ Enabled bool }{Enabled: true})
+			Expect(err).ToNot(HaveOccurred())
+			deployment, ok := rawObject.(*appsv1.Deployment)
+			Expect(ok).To(BeTrue())
+			Expect(deployment.Name).To(Equal("test-four"))
+		})
+	})
 })
@@ -28,6 +28,7 @@ type FakeRabbitClient struct {
 	Vhosts      []rabbithole.VhostInfo
 	Policies    map[string]map[string]rabbithole.Policy
 	Permissions map[string][]rabbithole.PermissionInfo
+	Queues      map[string][]rabbithole.QueueInfo
 }
 
 func New() *FakeRabbitClient {
@@ -36,6 +37,7 @@ func New() *FakeRabbitClient {
 		Vhosts:      []rabbithole.VhostInfo{},
 		Policies:    make(map[string]map[string]rabbithole.Policy),
 		Permissions: make(map[string][]rabbithole.PermissionInfo),
+		Queues:      make(map[string][]rabbithole.QueueInfo),
 	}
 }
 
@@ -142,3 +144,7 @@ func (frc *FakeRabbitClient) ClearPermissionsIn(vhost, username string) (res *ht
 	}
 	return &http.Response{StatusCode: 204}, nil
 }
+
+func (frc *FakeRabbitClient) ListQueuesIn(vhost string) (rec []rabbithole.QueueInfo, err error) {
+	return frc.Queues[vhost], nil
+}
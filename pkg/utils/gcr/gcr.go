@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -51,36 +52,45 @@ func SanitizeBranchName(branch string) (string, error) {
 	return sanitized_branch_tag, nil
 }
 
+// Fetch tags if the cache expired.
+func refreshCache() error {
+	if time.Since(LastCacheUpdate) < GetCacheExpiry() {
+		return nil
+	}
+
+	// Setup hub connection
+	var key = os.Getenv("GOOGLE_SERVICE_ACCOUNT_KEY")
+	var registry_url = os.Getenv("LOCAL_REGISTRY_URL")
+	// If we don't have a test registry, use the real one.
+	if registry_url == "" {
+		registry_url = "https://us.gcr.io"
+	}
+
+	var transport = registry.WrapTransport(http.DefaultTransport, registry_url, "_json_key", key)
+	var summonHub = &registry.Registry{
+		URL: registry_url,
+		Client: &http.Client{
+			Transport: transport,
+		},
+		Logf: registry.Quiet,
+	}
+
+	tags, err := summonHub.Tags("ridecell-1/summon")
+	if err != nil {
+		return errors.Wrapf(err, "Could not retrieve tags from registry: ")
+	}
+	CachedTags = tags
+	LastCacheUpdate = time.Now()
+	return nil
+}
+
 func GetLatestImageOfBranch(branchTag string) (string, error) {
 	var latestImage string
 	latestBuild := 0
-	elapsed := time.Since(LastCacheUpdate)
-
-	// Fetch tags if cache expired.
-	if elapsed >= GetCacheExpiry() {
-		// Setup hub connection
-		var key = os.Getenv("GOOGLE_SERVICE_ACCOUNT_KEY")
-		var registry_url = os.Getenv("LOCAL_REGISTRY_URL")
-		// If we don't have a test registry, use the real one.
-		if registry_url == "" {
-			registry_url = "https://us.gcr.io"
-		}
 
-		var transport = registry.WrapTransport(http.DefaultTransport, registry_url, "_json_key", key)
-		var summonHub = &registry.Registry{
-			URL: registry_url,
-			Client: &http.Client{
-				Transport: transport,
-			},
-			Logf: registry.Quiet,
-		}
-
-		tags, err := summonHub.Tags("ridecell-1/summon")
-		if err != nil {
-			return "", errors.Wrapf(err, "Could not retrieve tags from registry: ")
-		}
-		CachedTags = tags
-		LastCacheUpdate = time.Now()
+	err := refreshCache()
+	if err != nil {
+		return "", err
 	}
 
 	for _, image := range CachedTags {
@@ -109,3 +119,31 @@ func GetLatestImageOfBranch(branchTag string) (string, error) {
 	}
 	return latestImage, nil
 }
+
+// GetBranches returns the (sanitized) names of all branches with images, sorted.
+func GetBranches() ([]string, error) {
+	err := refreshCache()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	branches := []string{}
+	for _, image := range CachedTags {
+		// Expects docker image to follow format <circleci buildnum>-<git hash>-<branchname>
+		parts := strings.SplitN(image, "-", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		_, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+		if !seen[parts[2]] {
+			seen[parts[2]] = true
+			branches = append(branches, parts[2])
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// Interface for reading pod logs to allow for a mock implementation. The controller-runtime client can't get
+// at the log subresource.
+type PodLogClient interface {
+	TailLogs(namespace string, pod string, container string, previous bool, lines int64) (string, error)
+}
+
+type realPodLogClient struct {
+	once      sync.Once
+	clientset kubernetes.Interface
+	err       error
+}
+
+func NewPodLogClient() PodLogClient {
+	return &realPodLogClient{}
+}
+
+func (c *realPodLogClient) TailLogs(namespace string, pod string, container string, previous bool, lines int64) (string, error) {
+	// Created on first use, so tests which inject a mock never need a kubeconfig.
+	c.once.Do(func() {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			c.err = errors.Wrap(err, "unable to load kubernetes config")
+			return
+		}
+		c.clientset, c.err = kubernetes.NewForConfig(cfg)
+	})
+	if c.err != nil {
+		return "", c.err
+	}
+	raw, err := c.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+		TailLines: &lines,
+	}).Do().Raw()
+	return string(raw), err
+}
+
+// Cut logs down to at most maxBytes from the end, so they can't bloat the object they're stored on. Drops the
+// partial first line left by the cut.
+func TrimLogs(logs string, maxBytes int) string {
+	logs = strings.TrimRight(logs, "\n")
+	if len(logs) > maxBytes {
+		logs = logs[len(logs)-maxBytes:]
+		if i := strings.Index(logs, "\n"); i != -1 {
+			logs = logs[i+1:]
+		}
+	}
+	return logs
+}
@@ -41,6 +41,7 @@ type RabbitMQManager interface {
 	ListPermissionsOf(username string) (rec []rabbithole.PermissionInfo, err error)
 	UpdatePermissionsIn(vhost, username string, permissions rabbithole.Permissions) (res *http.Response, err error)
 	ClearPermissionsIn(vhost, username string) (res *http.Response, err error)
+	ListQueuesIn(vhost string) (rec []rabbithole.QueueInfo, err error)
 }
 
 type RabbitMQClientFactory func(uri string, user string, pass string, t *http.Transport) (RabbitMQManager, error)
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/errors"
+)
+
+// Region to assume when nothing else names one. Mostly for local testing stuff.
+const defaultRegion = "us-west-2"
+
+// Instances in the built-in regions have always been allowed to use their queue in any of them.
+var builtinSQSRegions = []string{"us-west-2", "eu-central-1", "ap-south-1"}
+
+// Settings for the regions we ran in before RegionProfiles existed, used when a cluster doesn't have a
+// RegionProfile object of its own. Any other region gets the us-west-2 settings.
+var builtinRegionProfiles = map[string]summonv1beta1.RegionProfileSpec{
+	"us-west-2": {
+		Region:        "us-west-2",
+		GatewayRegion: "us",
+		SQSQueues: map[string]string{
+			"prod":    "prod-data-pipeline",
+			"uat":     "us-uat-data-pipeline",
+			"qa":      "us-qa-data-pipeline",
+			"default": "master-data-pipeline",
+		},
+		SQSRegions: builtinSQSRegions,
+	},
+	"eu-central-1": {
+		Region:        "eu-central-1",
+		GatewayRegion: "eu",
+		SQSQueues: map[string]string{
+			"prod":    "eu-prod-data-pipeline",
+			"uat":     "eu-prod-data-pipeline",
+			"qa":      "us-qa-data-pipeline",
+			"default": "master-data-pipeline",
+		},
+		SQSRegions: builtinSQSRegions,
+	},
+	"ap-south-1": {
+		Region:        "ap-south-1",
+		GatewayRegion: "in",
+		SQSQueues: map[string]string{
+			"prod":    "in-prod-data-pipeline",
+			"uat":     "in-prod-data-pipeline",
+			"qa":      "us-qa-data-pipeline",
+			"default": "master-data-pipeline",
+		},
+		SQSRegions: builtinSQSRegions,
+	},
+}
+
+// RegionProfileName picks the RegionProfile to use. An explicit name wins, otherwise it comes from
+// $REGION_PROFILE, then $AWS_REGION.
+func RegionProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	name = os.Getenv("REGION_PROFILE")
+	if name != "" {
+		return name
+	}
+	name = os.Getenv("AWS_REGION")
+	if name != "" {
+		return name
+	}
+	return defaultRegion
+}
+
+// GetRegionProfile loads a cluster RegionProfile by name, falling back to the built-in settings for that region if
+// there isn't one. Optional fields are filled in on the returned copy.
+func GetRegionProfile(ctx context.Context, c client.Client, name string) (*summonv1beta1.RegionProfileSpec, error) {
+	profile := &summonv1beta1.RegionProfile{}
+	err := c.Get(ctx, types.NamespacedName{Name: name}, profile)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "unable to get region profile %s", name)
+	}
+	var spec *summonv1beta1.RegionProfileSpec
+	if err == nil {
+		spec = profile.Spec.DeepCopy()
+	} else {
+		spec = builtinRegionProfile(name)
+	}
+
+	if spec.Region == "" {
+		spec.Region = name
+	}
+	if spec.GatewayRegion == "" {
+		spec.GatewayRegion = strings.Split(spec.Region, "-")[0]
+	}
+	if len(spec.SQSRegions) == 0 {
+		spec.SQSRegions = []string{spec.Region}
+	}
+	if spec.FlavorBucket == "" {
+		spec.FlavorBucket = "ridecell-flavors"
+	}
+	if spec.FlavorBucketRegion == "" {
+		// All the flavors have always lived in Oregon.
+		spec.FlavorBucketRegion = defaultRegion
+	}
+	if spec.KMSRegion == "" {
+		spec.KMSRegion = "us-west-1"
+	}
+	if len(spec.BaseHostnames) == 0 {
+		spec.BaseHostnames = map[string]string{
+			"prod":    "ridecell.com",
+			"uat":     "ridecell.com",
+			"default": "ridecell.us",
+		}
+	}
+	return spec, nil
+}
+
+func builtinRegionProfile(region string) *summonv1beta1.RegionProfileSpec {
+	spec, ok := builtinRegionProfiles[region]
+	if !ok {
+		spec = builtinRegionProfiles[defaultRegion]
+		spec.Region = region
+		spec.GatewayRegion = ""
+		spec.SQSRegions = nil
+	}
+	return spec.DeepCopy()
+}
+
+// RegionQueueFor returns the data pipeline queue for an environment.
+func RegionQueueFor(profile *summonv1beta1.RegionProfileSpec, environment string) string {
+	queue, ok := profile.SQSQueues[environment]
+	if !ok {
+		queue = profile.SQSQueues["default"]
+	}
+	return queue
+}
+
+// RegionBaseHostnameFor returns the base hostname for new instances in an environment.
+func RegionBaseHostnameFor(profile *summonv1beta1.RegionProfileSpec, environment string) string {
+	hostname, ok := profile.BaseHostnames[environment]
+	if !ok {
+		hostname = profile.BaseHostnames["default"]
+	}
+	return hostname
+}
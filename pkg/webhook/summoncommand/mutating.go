@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summoncommand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	commandcomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summoncommand/components"
+)
+
+// RequesterHandler records the user creating a SummonCommand, and the user approving it, from the admission
+// request rather than trusting whatever the annotations say.
+type RequesterHandler struct {
+	Decoder types.Decoder
+}
+
+var _ admission.Handler = &RequesterHandler{}
+
+// Handle implements admission.Handler.
+func (h *RequesterHandler) Handle(ctx context.Context, req types.Request) types.Response {
+	instance := &summonv1beta1.SummonCommand{}
+	err := h.Decoder.Decode(req, instance)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	var old *summonv1beta1.SummonCommand
+	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+		old = &summonv1beta1.SummonCommand{}
+		err = json.Unmarshal(req.AdmissionRequest.OldObject.Raw, old)
+		if err != nil {
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+	}
+
+	recorded := instance.DeepCopy()
+	commandcomponents.RecordRequester(old, recorded, req.AdmissionRequest.UserInfo.Username)
+	return admission.PatchResponse(instance, recorded)
+}
+
+var _ inject.Decoder = &RequesterHandler{}
+
+// InjectDecoder injects the decoder.
+func (h *RequesterHandler) InjectDecoder(d types.Decoder) error {
+	h.Decoder = d
+	return nil
+}
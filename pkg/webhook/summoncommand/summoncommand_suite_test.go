@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summoncommand_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/onsi/ginkgo"
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/Ridecell/ridecell-operator/pkg/test_helpers"
+	"github.com/Ridecell/ridecell-operator/pkg/webhook"
+)
+
+var testHelpers *test_helpers.TestHelpers
+var certDir string
+
+func TestSummonCommandWebhook(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "SummonCommand Webhook Suite")
+}
+
+var _ = ginkgo.BeforeSuite(func() {
+	var err error
+	certDir, err = ioutil.TempDir("", "summoncommand-webhook")
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// Grab a free port for the webhook server to listen on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	port := int32(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	// The test API server runs locally, so point the webhook configs straight at our server rather than a Service.
+	host := "127.0.0.1"
+	testHelpers = test_helpers.Start(func(mgr manager.Manager) error {
+		return webhook.AddToManager(mgr, crwebhook.ServerOptions{
+			Port:    port,
+			CertDir: certDir,
+			BootstrapOptions: &crwebhook.BootstrapOptions{
+				MutatingWebhookConfigName:   "test-mutating-webhook-configuration",
+				ValidatingWebhookConfigName: "test-validating-webhook-configuration",
+				Host:                        &host,
+			},
+		})
+	}, false)
+})
+
+var _ = ginkgo.AfterSuite(func() {
+	testHelpers.Stop()
+	os.RemoveAll(certDir)
+})
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summoncommand
+
+import (
+	"github.com/pkg/errors"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/builder"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+)
+
+// Webhooks builds the webhooks recording who created and approved each SummonCommand.
+func Webhooks(mgr manager.Manager) ([]*admission.Webhook, error) {
+	mutating, err := builder.NewWebhookBuilder().
+		Name("mutating.summoncommands.summon.ridecell.io").
+		Path("/mutate-summoncommands").
+		Mutating().
+		Operations(admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update).
+		FailurePolicy(admissionregistrationv1beta1.Fail).
+		ForType(&summonv1beta1.SummonCommand{}).
+		WithManager(mgr).
+		Handlers(&RequesterHandler{}).
+		Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "summoncommand: unable to build mutating webhook")
+	}
+
+	validating, err := builder.NewWebhookBuilder().
+		Name("validating.summoncommands.summon.ridecell.io").
+		Path("/validate-summoncommands").
+		Validating().
+		Operations(admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update).
+		FailurePolicy(admissionregistrationv1beta1.Fail).
+		ForType(&summonv1beta1.SummonCommand{}).
+		WithManager(mgr).
+		Handlers(&ValidatingHandler{}).
+		Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "summoncommand: unable to build validating webhook")
+	}
+
+	return []*admission.Webhook{mutating, validating}, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summoncommand_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/test_helpers"
+)
+
+const timeout = time.Second * 30
+
+var _ = Describe("SummonCommand webhook", func() {
+	var helpers *test_helpers.PerTestHelpers
+	var creator, approver client.Client
+
+	// A client acting as someone else, so the webhook sees a known user.
+	clientAs := func(username string) client.Client {
+		cfg := rest.CopyConfig(testHelpers.Cfg)
+		cfg.Impersonate = rest.ImpersonationConfig{UserName: username}
+		c, err := client.New(cfg, client.Options{Scheme: testHelpers.Manager.GetScheme()})
+		Expect(err).NotTo(HaveOccurred())
+		return c
+	}
+
+	BeforeEach(func() {
+		helpers = testHelpers.SetupTest()
+		creator = clientAs("dev@ridecell.com")
+		approver = clientAs("oncall@ridecell.com")
+		// Requests skip the webhooks entirely until the server has installed its configs.
+		Eventually(func() error {
+			return helpers.Client.Get(context.TODO(), types.NamespacedName{Name: "test-mutating-webhook-configuration"}, &admissionregistrationv1beta1.MutatingWebhookConfiguration{})
+		}, timeout).Should(Succeed())
+		Eventually(func() error {
+			return helpers.Client.Get(context.TODO(), types.NamespacedName{Name: "test-validating-webhook-configuration"}, &admissionregistrationv1beta1.ValidatingWebhookConfiguration{})
+		}, timeout).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		helpers.TeardownTest()
+	})
+
+	newCommand := func(annotations map[string]string) *summonv1beta1.SummonCommand {
+		return &summonv1beta1.SummonCommand{
+			ObjectMeta: metav1.ObjectMeta{Name: "clear-cache", Namespace: helpers.Namespace, Annotations: annotations},
+			Spec: summonv1beta1.SummonCommandSpec{
+				Platform: "foo",
+				Command:  []string{"clear_cache"},
+			},
+		}
+	}
+
+	// The webhook server starts in the background, so retry the first create until it's answering.
+	create := func(instance *summonv1beta1.SummonCommand) error {
+		var err error
+		Eventually(func() error {
+			err = creator.Create(context.TODO(), instance.DeepCopy())
+			if err != nil && !isRejection(err) {
+				return err
+			}
+			return nil
+		}, timeout).Should(Succeed())
+		return err
+	}
+
+	fetch := func(c client.Client) *summonv1beta1.SummonCommand {
+		fetched := &summonv1beta1.SummonCommand{}
+		err := c.Get(context.TODO(), helpers.Name("clear-cache"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		return fetched
+	}
+
+	It("records who created the command", func() {
+		Expect(create(newCommand(nil))).To(Succeed())
+		fetched := fetch(helpers.Client)
+		Expect(fetched.Annotations).To(HaveKeyWithValue("summon.ridecell.io/createdBy", "dev@ridecell.com"))
+		Expect(fetched.Annotations).NotTo(HaveKey("summon.ridecell.io/approvedBy"))
+	})
+
+	It("ignores a made up creator", func() {
+		Expect(create(newCommand(map[string]string{"summon.ridecell.io/createdBy": "someone@ridecell.com"}))).To(Succeed())
+		fetched := fetch(helpers.Client)
+		Expect(fetched.Annotations).To(HaveKeyWithValue("summon.ridecell.io/createdBy", "dev@ridecell.com"))
+
+		fetched.Annotations["summon.ridecell.io/createdBy"] = "someone@ridecell.com"
+		Expect(creator.Update(context.TODO(), fetched)).To(Succeed())
+		fetched = fetch(helpers.Client)
+		Expect(fetched.Annotations).To(HaveKeyWithValue("summon.ridecell.io/createdBy", "dev@ridecell.com"))
+	})
+
+	It("rejects a command created already approved", func() {
+		err := create(newCommand(map[string]string{"summon.ridecell.io/approvedBy": "oncall@ridecell.com"}))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("can't be approved by the user who created them"))
+	})
+
+	It("records who approved the command", func() {
+		Expect(create(newCommand(nil))).To(Succeed())
+		fetched := fetch(approver)
+		fetched.Annotations["summon.ridecell.io/approvedBy"] = "yes"
+		Expect(approver.Update(context.TODO(), fetched)).To(Succeed())
+
+		fetched = fetch(helpers.Client)
+		Expect(fetched.Annotations).To(HaveKeyWithValue("summon.ridecell.io/approvedBy", "oncall@ridecell.com"))
+		Expect(fetched.Annotations).To(HaveKeyWithValue("summon.ridecell.io/createdBy", "dev@ridecell.com"))
+	})
+
+	It("rejects the creator approving their own command", func() {
+		Expect(create(newCommand(nil))).To(Succeed())
+		fetched := fetch(creator)
+		fetched.Annotations["summon.ridecell.io/approvedBy"] = "oncall@ridecell.com"
+		err := creator.Update(context.TODO(), fetched)
+		Expect(err).To(HaveOccurred())
+		Expect(isRejection(err)).To(BeTrue())
+	})
+
+	It("rejects changing the command after it is created", func() {
+		Expect(create(newCommand(nil))).To(Succeed())
+		fetched := fetch(approver)
+		fetched.Annotations["summon.ridecell.io/approvedBy"] = "yes"
+		Expect(approver.Update(context.TODO(), fetched)).To(Succeed())
+
+		fetched = fetch(creator)
+		fetched.Spec.Command = []string{"flush", "--noinput"}
+		err := creator.Update(context.TODO(), fetched)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec can't be changed"))
+		Expect(fetch(helpers.Client).Spec.Command).To(Equal([]string{"clear_cache"}))
+	})
+})
+
+// Check if an error came from the webhook denying the request, rather than the server not being up yet.
+func isRejection(err error) bool {
+	return strings.Contains(err.Error(), "denied the request")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summonplatform
+
+import (
+	"context"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+)
+
+// DefaultingHandler saves the SummonPlatform defaults on the object when it is created or updated, using the same
+// logic as the defaults component.
+type DefaultingHandler struct {
+	Client  client.Client
+	Decoder types.Decoder
+}
+
+var _ admission.Handler = &DefaultingHandler{}
+
+// Handle implements admission.Handler.
+func (h *DefaultingHandler) Handle(ctx context.Context, req types.Request) types.Response {
+	instance := &summonv1beta1.SummonPlatform{}
+	err := h.Decoder.Decode(req, instance)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	defaulted := instance.DeepCopy()
+	// The namespace isn't always filled in on create, but the environment default is based on it.
+	if defaulted.Namespace == "" {
+		defaulted.Namespace = req.AdmissionRequest.Namespace
+	}
+	profile, err := summoncomponents.RegionProfileFor(ctx, h.Client, defaulted)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusInternalServerError, err)
+	}
+	summoncomponents.DefaultSpec(defaulted, profile)
+	defaulted.Namespace = instance.Namespace
+
+	return admission.PatchResponse(instance, defaulted)
+}
+
+var _ inject.Client = &DefaultingHandler{}
+
+// InjectClient injects the client.
+func (h *DefaultingHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
+
+var _ inject.Decoder = &DefaultingHandler{}
+
+// InjectDecoder injects the decoder.
+func (h *DefaultingHandler) InjectDecoder(d types.Decoder) error {
+	h.Decoder = d
+	return nil
+}
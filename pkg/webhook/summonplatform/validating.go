@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summonplatform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	summoncomponents "github.com/Ridecell/ridecell-operator/pkg/controller/summon/components"
+)
+
+// ValidatingHandler rejects SummonPlatforms the defaults component would fail on, so mistakes show up at
+// `kubectl apply` time rather than as an Error status later.
+type ValidatingHandler struct {
+	Decoder types.Decoder
+}
+
+var _ admission.Handler = &ValidatingHandler{}
+
+// Handle implements admission.Handler.
+func (h *ValidatingHandler) Handle(ctx context.Context, req types.Request) types.Response {
+	instance := &summonv1beta1.SummonPlatform{}
+	err := h.Decoder.Decode(req, instance)
+	if err != nil {
+		return admission.ErrorResponse(http.StatusBadRequest, err)
+	}
+
+	// Never block deletion, otherwise an object created before validation existed could get stuck on its
+	// finalizers.
+	if instance.DeletionTimestamp != nil {
+		return admission.ValidationResponse(true, "")
+	}
+
+	errs := summoncomponents.ValidateSpec(instance)
+	if req.AdmissionRequest.Operation == admissionv1beta1.Update {
+		old := &summonv1beta1.SummonPlatform{}
+		err = json.Unmarshal(req.AdmissionRequest.OldObject.Raw, old)
+		if err != nil {
+			return admission.ErrorResponse(http.StatusBadRequest, err)
+		}
+		errs = append(errs, summoncomponents.ValidateSpecUpdate(old, instance)...)
+	}
+	if len(errs) != 0 {
+		return admission.ValidationResponse(false, errs.ToAggregate().Error())
+	}
+	return admission.ValidationResponse(true, "")
+}
+
+var _ inject.Decoder = &ValidatingHandler{}
+
+// InjectDecoder injects the decoder.
+func (h *ValidatingHandler) InjectDecoder(d types.Decoder) error {
+	h.Decoder = d
+	return nil
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package summonplatform_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	"golang.org/x/net/context"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	summonv1beta1 "github.com/Ridecell/ridecell-operator/pkg/apis/summon/v1beta1"
+	"github.com/Ridecell/ridecell-operator/pkg/test_helpers"
+)
+
+const timeout = time.Second * 30
+
+var _ = Describe("SummonPlatform webhook", func() {
+	var helpers *test_helpers.PerTestHelpers
+
+	BeforeEach(func() {
+		helpers = testHelpers.SetupTest()
+		// Requests skip the webhooks entirely until the server has installed its configs.
+		Eventually(func() error {
+			return helpers.Client.Get(context.TODO(), types.NamespacedName{Name: "test-mutating-webhook-configuration"}, &admissionregistrationv1beta1.MutatingWebhookConfiguration{})
+		}, timeout).Should(Succeed())
+		Eventually(func() error {
+			return helpers.Client.Get(context.TODO(), types.NamespacedName{Name: "test-validating-webhook-configuration"}, &admissionregistrationv1beta1.ValidatingWebhookConfiguration{})
+		}, timeout).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		helpers.TeardownTest()
+	})
+
+	// The webhook server starts in the background, so retry the first create until it's answering.
+	create := func(instance *summonv1beta1.SummonPlatform) error {
+		var err error
+		Eventually(func() error {
+			err = helpers.Client.Create(context.TODO(), instance.DeepCopy())
+			if err != nil && !isRejection(err) {
+				return err
+			}
+			return nil
+		}, timeout).Should(Succeed())
+		return err
+	}
+
+	It("saves defaults on create", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.Spec.Environment).To(Equal(helpers.Namespace))
+		Expect(fetched.Spec.Hostname).To(Equal("foo.ridecell.us"))
+		Expect(fetched.Spec.PullSecret).To(Equal("pull-secret"))
+		Expect(fetched.Spec.Redis.RAM).To(Equal(1))
+		Expect(fetched.Spec.Replicas.Web).To(PointTo(BeEquivalentTo(1)))
+		Expect(fetched.Spec.Replicas.CeleryBeat).To(PointTo(BeEquivalentTo(1)))
+	})
+
+	It("saves the hostname from a non-default region profile", func() {
+		profile := &summonv1beta1.RegionProfile{
+			ObjectMeta: metav1.ObjectMeta{Name: "mars"},
+			Spec: summonv1beta1.RegionProfileSpec{
+				Region:        "mars-north-1",
+				BaseHostnames: map[string]string{"default": "ridecell.mars"},
+			},
+		}
+		Expect(helpers.Client.Create(context.TODO(), profile)).To(Succeed())
+		defer helpers.Client.Delete(context.TODO(), profile)
+
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version:       "1.2.3",
+				RegionProfile: "mars",
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.Spec.Hostname).To(Equal("foo.ridecell.mars"))
+		// Only the hostname is saved, the rest of the profile is applied on every reconcile.
+		Expect(fetched.Spec.AwsRegion).To(BeEmpty())
+
+		// Changing the profile later doesn't move an existing instance.
+		err = helpers.Client.Get(context.TODO(), types.NamespacedName{Name: "mars"}, profile)
+		Expect(err).NotTo(HaveOccurred())
+		profile.Spec.BaseHostnames = map[string]string{"default": "ridecell.phobos"}
+		Expect(helpers.Client.Update(context.TODO(), profile)).To(Succeed())
+		fetched.Spec.Version = "1.2.4"
+		Expect(helpers.Client.Update(context.TODO(), fetched)).To(Succeed())
+		err = helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.Spec.Hostname).To(Equal("foo.ridecell.mars"))
+	})
+
+	It("does not override values that are already set", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version:  "1.2.3",
+				Hostname: "foo.example.com",
+				Replicas: summonv1beta1.ReplicasSpec{
+					Web: intp(3),
+				},
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fetched.Spec.Hostname).To(Equal("foo.example.com"))
+		Expect(fetched.Spec.Replicas.Web).To(PointTo(BeEquivalentTo(3)))
+	})
+
+	It("rejects both Version and AutoDeploy", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version:    "1.2.3",
+				AutoDeploy: "master",
+			},
+		}
+		err := create(instance)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Must specify only one"))
+	})
+
+	It("rejects neither Version nor AutoDeploy", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+		}
+		err := create(instance)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.version"))
+	})
+
+	It("rejects too much redis RAM", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+				Redis:   summonv1beta1.RedisSpec{RAM: 11},
+			},
+		}
+		err := create(instance)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.redis.ram"))
+	})
+
+	It("rejects more than one celerybeat", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+				Replicas: summonv1beta1.ReplicasSpec{
+					CeleryBeat: intp(2),
+				},
+			},
+		}
+		err := create(instance)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.replicas.celeryBeat"))
+	})
+
+	It("rejects an invalid update", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		fetched.Spec.AutoDeploy = "master"
+		err = helpers.Client.Update(context.TODO(), fetched)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects adding cloneFrom to an existing instance", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version: "1.2.3",
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		fetched.Spec.CloneFrom = &summonv1beta1.CloneFromSpec{Instance: "bar"}
+		err = helpers.Client.Update(context.TODO(), fetched)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("spec.cloneFrom"))
+	})
+
+	It("rejects changing cloneFrom but allows removing it", func() {
+		instance := &summonv1beta1.SummonPlatform{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: helpers.Namespace},
+			Spec: summonv1beta1.SummonPlatformSpec{
+				Version:   "1.2.3",
+				CloneFrom: &summonv1beta1.CloneFromSpec{Instance: "bar"},
+			},
+		}
+		Expect(create(instance)).To(Succeed())
+
+		fetched := &summonv1beta1.SummonPlatform{}
+		err := helpers.Client.Get(context.TODO(), helpers.Name("foo"), fetched)
+		Expect(err).NotTo(HaveOccurred())
+		fetched.Spec.CloneFrom.Instance = "baz"
+		err = helpers.Client.Update(context.TODO(), fetched.DeepCopy())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cloneFrom can't be changed"))
+
+		fetched.Spec.CloneFrom = nil
+		Expect(helpers.Client.Update(context.TODO(), fetched)).To(Succeed())
+	})
+})
+
+// Check if an error came from the webhook denying the request, rather than the server not being up yet.
+func isRejection(err error) bool {
+	return strings.Contains(err.Error(), "denied the request")
+}
+
+// Return an int pointer because &1 doesn't work in Go.
+func intp(n int32) *int32 {
+	return &n
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 Ridecell, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AddToManagerFuncs is a list of functions to build all admission webhooks.
+var AddToManagerFuncs []func(manager.Manager) ([]*admission.Webhook, error)
+
+// AddToManager creates the admission webhook server and registers all webhooks with it.
+func AddToManager(m manager.Manager, options webhook.ServerOptions) error {
+	server, err := webhook.NewServer("ridecell-operator-webhook", m, options)
+	if err != nil {
+		return errors.Wrap(err, "unable to create webhook server")
+	}
+	for _, f := range AddToManagerFuncs {
+		webhooks, err := f(m)
+		if err != nil {
+			return err
+		}
+		for _, wh := range webhooks {
+			err = server.Register(wh)
+			if err != nil {
+				return errors.Wrapf(err, "unable to register webhook %s", wh.GetName())
+			}
+		}
+	}
+	return nil
+}